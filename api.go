@@ -0,0 +1,107 @@
+package sourcify
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+//go:generate moq -out sourcifymock/api_mock.go -pkg sourcifymock . API
+
+// API represents every endpoint of the Sourcify API. It is implemented by Client, whose methods call the package
+// level functions of the same name, and by sourcifymock.APIMock, so code depending on Sourcify can accept an API
+// and be unit tested without an HTTP server.
+type API interface {
+	// GetContractByChainIdAndAddress retrieves the details of a verified contract, see GetContractByChainIdAndAddress.
+	GetContractByChainIdAndAddress(chainId int, address common.Address, fields []string, omit []string) (*ContractResponse, error)
+	// GetContractsByChainId lists the verified contracts of a chain, see GetContractsByChainId.
+	GetContractsByChainId(chainId int, sort string, afterMatchId string, limit int) (*ContractsResponse, error)
+	// GetAvailableContractAddresses lists the verified addresses of a chain, see GetAvailableContractAddresses.
+	GetAvailableContractAddresses(chainId int) (*VerifiedContractAddresses, error)
+	// GetContractFiles retrieves the file tree of a verified contract, see GetContractFiles.
+	GetContractFiles(chainId int, contract common.Address, matchType MethodMatchType) (*FileTree, error)
+	// GetContractSourceCode retrieves the files of a verified contract, see GetContractSourceCode.
+	GetContractSourceCode(chainId int, contract common.Address, matchType MethodMatchType) (*SourceCodes, error)
+	// GetContractMetadata retrieves the metadata of a verified contract, see GetContractMetadata.
+	GetContractMetadata(chainId int, contract common.Address, matchType MethodMatchType) (*Metadata, error)
+	// GetContractMetadataAsBytes retrieves the raw metadata of a verified contract, see GetContractMetadataAsBytes.
+	GetContractMetadataAsBytes(chainId int, contract common.Address, matchType MethodMatchType) ([]byte, error)
+	// CheckContractByAddresses checks whether addresses are verified, see CheckContractByAddresses.
+	CheckContractByAddresses(addresses []string, chainIds []int, matchType MethodMatchType) ([]*CheckContractAddress, error)
+	// GetChains lists the chains known to Sourcify, see GetChains.
+	GetChains() ([]Chain, error)
+	// GetHealth reports whether the server is up, see GetHealth.
+	GetHealth() (bool, error)
+	// VerifyContract submits a contract for verification, see VerifyContract.
+	VerifyContract(chainId int, address common.Address, request *VerifyRequest) (string, error)
+	// VerifyContractFromMetadata submits a contract for verification, see VerifyContractFromMetadata.
+	VerifyContractFromMetadata(chainId int, address common.Address, request *VerifyMetadataRequest) (string, error)
+	// GetVerificationJob retrieves the status of a verification job, see GetVerificationJob.
+	GetVerificationJob(verificationId string) (*VerificationJob, error)
+}
+
+// Ensure Client implements API.
+var _ API = (*Client)(nil)
+
+// GetContractByChainIdAndAddress calls GetContractByChainIdAndAddress with the client.
+func (c *Client) GetContractByChainIdAndAddress(chainId int, address common.Address, fields []string, omit []string) (*ContractResponse, error) {
+	return GetContractByChainIdAndAddress(c, chainId, address, fields, omit)
+}
+
+// GetContractsByChainId calls GetContractsByChainId with the client.
+func (c *Client) GetContractsByChainId(chainId int, sort string, afterMatchId string, limit int) (*ContractsResponse, error) {
+	return GetContractsByChainId(c, chainId, sort, afterMatchId, limit)
+}
+
+// GetAvailableContractAddresses calls GetAvailableContractAddresses with the client.
+func (c *Client) GetAvailableContractAddresses(chainId int) (*VerifiedContractAddresses, error) {
+	return GetAvailableContractAddresses(c, chainId)
+}
+
+// GetContractFiles calls GetContractFiles with the client.
+func (c *Client) GetContractFiles(chainId int, contract common.Address, matchType MethodMatchType) (*FileTree, error) {
+	return GetContractFiles(c, chainId, contract, matchType)
+}
+
+// GetContractSourceCode calls GetContractSourceCode with the client.
+func (c *Client) GetContractSourceCode(chainId int, contract common.Address, matchType MethodMatchType) (*SourceCodes, error) {
+	return GetContractSourceCode(c, chainId, contract, matchType)
+}
+
+// GetContractMetadata calls GetContractMetadata with the client.
+func (c *Client) GetContractMetadata(chainId int, contract common.Address, matchType MethodMatchType) (*Metadata, error) {
+	return GetContractMetadata(c, chainId, contract, matchType)
+}
+
+// GetContractMetadataAsBytes calls GetContractMetadataAsBytes with the client.
+func (c *Client) GetContractMetadataAsBytes(chainId int, contract common.Address, matchType MethodMatchType) ([]byte, error) {
+	return GetContractMetadataAsBytes(c, chainId, contract, matchType)
+}
+
+// CheckContractByAddresses calls CheckContractByAddresses with the client.
+func (c *Client) CheckContractByAddresses(addresses []string, chainIds []int, matchType MethodMatchType) ([]*CheckContractAddress, error) {
+	return CheckContractByAddresses(c, addresses, chainIds, matchType)
+}
+
+// GetChains calls GetChains with the client.
+func (c *Client) GetChains() ([]Chain, error) {
+	return GetChains(c)
+}
+
+// GetHealth calls GetHealth with the client.
+func (c *Client) GetHealth() (bool, error) {
+	return GetHealth(c)
+}
+
+// VerifyContract calls VerifyContract with the client.
+func (c *Client) VerifyContract(chainId int, address common.Address, request *VerifyRequest) (string, error) {
+	return VerifyContract(c, chainId, address, request)
+}
+
+// VerifyContractFromMetadata calls VerifyContractFromMetadata with the client.
+func (c *Client) VerifyContractFromMetadata(chainId int, address common.Address, request *VerifyMetadataRequest) (string, error) {
+	return VerifyContractFromMetadata(c, chainId, address, request)
+}
+
+// GetVerificationJob calls GetVerificationJob with the client.
+func (c *Client) GetVerificationJob(verificationId string) (*VerificationJob, error) {
+	return GetVerificationJob(c, verificationId)
+}
@@ -0,0 +1,48 @@
+package sourcify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientImplementsAPI(t *testing.T) {
+	chainID := 1
+	contractAddress := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+
+	localResponse, err := LoadContract(chainID, contractAddress)
+	require.NoError(t, err)
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/chains":
+			_ = json.NewEncoder(w).Encode([]Chain{{Name: "Ethereum Mainnet", ChainID: 1}})
+		case "/v2/contract/1/" + contractAddress.Hex():
+			_ = json.NewEncoder(w).Encode(localResponse)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+
+	var api API = NewClient(WithBaseURL(mockServer.URL))
+
+	healthy, err := api.GetHealth()
+	require.NoError(t, err)
+	assert.True(t, healthy)
+
+	chains, err := api.GetChains()
+	require.NoError(t, err)
+	assert.Len(t, chains, 1)
+
+	contract, err := api.GetContractByChainIdAndAddress(chainID, contractAddress, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, localResponse, contract)
+}
@@ -0,0 +1,318 @@
+package sourcify
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrUnsupportedArchiveFormat is returned when an archive format other than zip or tar.gz is requested.
+	ErrUnsupportedArchiveFormat = errors.New("unsupported archive format")
+)
+
+// ArchiveFormat describes the container format of an exported archive.
+type ArchiveFormat string
+
+const (
+	// ArchiveFormatZip writes a zip archive.
+	ArchiveFormatZip ArchiveFormat = "zip"
+
+	// ArchiveFormatTarGz writes a gzip compressed tar archive.
+	ArchiveFormatTarGz ArchiveFormat = "tar.gz"
+)
+
+const (
+	// ArchiveContractFile is the name of the archive entry holding the full ContractResponse JSON.
+	ArchiveContractFile = "contract.json"
+
+	// ArchiveManifestFile is the name of the archive entry holding the ArchiveManifest.
+	ArchiveManifestFile = "manifest.json"
+
+	// archiveFilesDir is the directory of the archive holding the repository files.
+	archiveFilesDir = "files"
+)
+
+// ArchiveFile describes a single entry of an exported archive.
+type ArchiveFile struct {
+	Path      string `json:"path"`
+	Source    string `json:"source,omitempty"` // URL the file was downloaded from.
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	Keccak256 string `json:"keccak256"`
+}
+
+// ArchiveManifest describes the content of an exported archive. It is written as the last archive entry.
+type ArchiveManifest struct {
+	ChainID       int           `json:"chainId"`
+	Address       string        `json:"address"`
+	Match         string        `json:"match"`
+	CreationMatch string        `json:"creationMatch"`
+	RuntimeMatch  string        `json:"runtimeMatch"`
+	MatchID       string        `json:"matchId"`
+	VerifiedAt    time.Time     `json:"verifiedAt"`
+	ArchivedAt    time.Time     `json:"archivedAt"`
+	Files         []ArchiveFile `json:"files"`
+}
+
+// ExportArchive streams an archive of a verified contract to w. The archive contains every repository file listed
+// by GetContractFiles under `files/`, the full ContractResponse as `contract.json` and a `manifest.json` with the
+// match type and the SHA-256 and Keccak-256 hashes of every entry. Files are downloaded and written one at a time,
+// so the archive is never held in memory; tar entries are spooled to a temporary file as tar requires their size
+// upfront. The returned manifest equals the one written to the archive.
+func ExportArchive(ctx context.Context, client *Client, w io.Writer, format ArchiveFormat, chainId int, address common.Address) (*ArchiveManifest, error) {
+	archive, err := newArchiveWriter(w, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	contract, err := GetContractByChainIdAndAddress(client, chainId, address, []string{"all"}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := GetContractFiles(client, chainId, address, MethodMatchTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := &ArchiveManifest{
+		ChainID:       chainId,
+		Address:       address.Hex(),
+		Match:         contract.Match,
+		CreationMatch: contract.CreationMatch,
+		RuntimeMatch:  contract.RuntimeMatch,
+		MatchID:       contract.MatchID,
+		VerifiedAt:    contract.VerifiedAt,
+		ArchivedAt:    time.Now().UTC(),
+		Files:         make([]ArchiveFile, 0, len(tree.Files)+1),
+	}
+
+	if toReturn.Match == "" {
+		toReturn.Match = tree.Status
+	}
+
+	for _, fileUrl := range tree.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		name, err := archiveFileName(fileUrl, chainId, address)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := archiveRemoteFile(ctx, client, archive, name, fileUrl)
+		if err != nil {
+			return nil, err
+		}
+		toReturn.Files = append(toReturn.Files, *file)
+	}
+
+	contractFile, err := archive.writeEntry(ArchiveContractFile, toReturn.ArchivedAt, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(contract)
+	})
+	if err != nil {
+		return nil, err
+	}
+	toReturn.Files = append(toReturn.Files, *contractFile)
+
+	if _, err := archive.writeEntry(ArchiveManifestFile, toReturn.ArchivedAt, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(toReturn)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return toReturn, nil
+}
+
+// archiveRemoteFile downloads the file at fileUrl and writes it to the archive under name.
+func archiveRemoteFile(ctx context.Context, client *Client, archive *archiveWriter, name string, fileUrl string) (*ArchiveFile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	response, statusCode, err := client.doRequestWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Close the io.ReadCloser interface.
+	// This is important as doRequestWithRetry is NOT closing the response body!
+	defer response.Close()
+
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %w", fileUrl, &StatusError{StatusCode: statusCode})
+	}
+
+	toReturn, err := archive.writeEntry(name, time.Now().UTC(), func(w io.Writer) error {
+		_, err := io.Copy(w, response)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn.Source = fileUrl
+	return toReturn, nil
+}
+
+// archiveFileName maps a repository file URL, e.g.
+// `https://repo.sourcify.dev/contracts/full_match/1/0x.../sources/contracts/Token.sol`, to its archive path
+// `files/sources/contracts/Token.sol`.
+func archiveFileName(fileUrl string, chainId int, address common.Address) (string, error) {
+	name := fileUrl
+	if parsed, err := url.Parse(fileUrl); err == nil && parsed.Path != "" {
+		name = parsed.Path
+	}
+
+	prefix := strings.ToLower(fmt.Sprintf("/%d/%s/", chainId, address.Hex()))
+	if index := strings.Index(strings.ToLower(name), prefix); index >= 0 {
+		name = name[index+len(prefix):]
+	} else {
+		name = path.Base(name)
+	}
+
+	sanitized, err := SanitizeSourcePath(name)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(archiveFilesDir, sanitized), nil
+}
+
+// archiveWriter writes entries to either a zip or a tar.gz archive while hashing their content.
+type archiveWriter struct {
+	zip  *zip.Writer
+	gzip *gzip.Writer
+	tar  *tar.Writer
+}
+
+// newArchiveWriter creates an archive writer of the given format on top of w.
+func newArchiveWriter(w io.Writer, format ArchiveFormat) (*archiveWriter, error) {
+	switch format {
+	case ArchiveFormatZip:
+		return &archiveWriter{zip: zip.NewWriter(w)}, nil
+	case ArchiveFormatTarGz:
+		gz := gzip.NewWriter(w)
+		return &archiveWriter{gzip: gz, tar: tar.NewWriter(gz)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedArchiveFormat, format)
+	}
+}
+
+// writeEntry adds an entry named name whose content is produced by write and returns its description.
+func (a *archiveWriter) writeEntry(name string, modTime time.Time, write func(w io.Writer) error) (*ArchiveFile, error) {
+	sha := sha256.New()
+	keccak := sha3.NewLegacyKeccak256()
+	counter := &countingWriter{}
+	hashes := io.MultiWriter(sha, keccak, counter)
+
+	if a.zip != nil {
+		entry, err := a.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := write(io.MultiWriter(entry, hashes)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+
+		return newArchiveFile(name, counter.n, sha, keccak), nil
+	}
+
+	spool, err := os.CreateTemp("", "sourcify-archive-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+
+	if err := write(io.MultiWriter(spool, hashes)); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if err := a.tar.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     counter.n,
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(a.tar, spool); err != nil {
+		return nil, err
+	}
+
+	return newArchiveFile(name, counter.n, sha, keccak), nil
+}
+
+// Close flushes and closes the archive without closing the underlying writer.
+func (a *archiveWriter) Close() error {
+	if a.zip != nil {
+		return a.zip.Close()
+	}
+
+	if err := a.tar.Close(); err != nil {
+		return err
+	}
+	return a.gzip.Close()
+}
+
+// newArchiveFile describes an archive entry from its size and content hashes.
+func newArchiveFile(name string, size int64, sha hash.Hash, keccak hash.Hash) *ArchiveFile {
+	return &ArchiveFile{
+		Path:      name,
+		Size:      size,
+		SHA256:    hex.EncodeToString(sha.Sum(nil)),
+		Keccak256: "0x" + hex.EncodeToString(keccak.Sum(nil)),
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
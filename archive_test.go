@@ -0,0 +1,148 @@
+package sourcify
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newArchiveTestServer(t *testing.T, chainID int, address common.Address) *httptest.Server {
+	contract, err := LoadContract(chainID, address)
+	require.NoError(t, err)
+
+	repository := fmt.Sprintf("/repository/contracts/full_match/%d/%s/", chainID, address.Hex())
+	files := map[string]string{
+		repository + "metadata.json":               `{"version": 1}`,
+		repository + "sources/TetherToken.sol":     contract.Sources["TetherToken.sol"].Content,
+		repository + "sources/../../../escape.sol": "escape",
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case fmt.Sprintf("/v2/contract/%d/%s", chainID, address.Hex()):
+			require.NoError(t, json.NewEncoder(w).Encode(contract))
+		case fmt.Sprintf("/files/tree/any/%d/%s", chainID, address.Hex()):
+			tree := FileTree{Status: "full"}
+			for _, name := range []string{"metadata.json", "sources/TetherToken.sol"} {
+				tree.Files = append(tree.Files, server.URL+repository+name)
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(tree))
+		default:
+			if content, ok := files[r.URL.Path]; ok {
+				_, _ = w.Write([]byte(content))
+				return
+			}
+			http.NotFound(w, r)
+		}
+	}))
+	return server
+}
+
+func TestExportArchive(t *testing.T) {
+	chainID := 1
+	address := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+
+	server := newArchiveTestServer(t, chainID, address)
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+
+	readZip := func(data []byte) map[string][]byte {
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+
+		toReturn := make(map[string][]byte)
+		for _, file := range reader.File {
+			rc, err := file.Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			toReturn[file.Name] = content
+		}
+		return toReturn
+	}
+
+	readTarGz := func(data []byte) map[string][]byte {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+
+		reader := tar.NewReader(gz)
+		toReturn := make(map[string][]byte)
+		for {
+			header, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			content, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			toReturn[header.Name] = content
+		}
+		return toReturn
+	}
+
+	for format, read := range map[ArchiveFormat]func([]byte) map[string][]byte{
+		ArchiveFormatZip:   readZip,
+		ArchiveFormatTarGz: readTarGz,
+	} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			manifest, err := ExportArchive(context.Background(), client, &buf, format, chainID, address)
+			require.NoError(t, err)
+
+			assert.Equal(t, "match", manifest.Match)
+			require.Len(t, manifest.Files, 3)
+
+			entries := read(buf.Bytes())
+			assert.Len(t, entries, 4)
+			assert.Equal(t, `{"version": 1}`, string(entries["files/metadata.json"]))
+			assert.Contains(t, entries, "files/sources/TetherToken.sol")
+			assert.Contains(t, entries, ArchiveContractFile)
+
+			for _, file := range manifest.Files {
+				sum := sha256.Sum256(entries[file.Path])
+				assert.Equal(t, hex.EncodeToString(sum[:]), file.SHA256, file.Path)
+				assert.Equal(t, int64(len(entries[file.Path])), file.Size, file.Path)
+			}
+
+			var written ArchiveManifest
+			require.NoError(t, json.Unmarshal(entries[ArchiveManifestFile], &written))
+			assert.Equal(t, manifest.Files, written.Files)
+		})
+	}
+
+	_, err := ExportArchive(context.Background(), client, io.Discard, "rar", chainID, address)
+	assert.ErrorIs(t, err, ErrUnsupportedArchiveFormat)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ExportArchive(ctx, client, io.Discard, ArchiveFormatZip, chainID, address)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestArchiveFileName(t *testing.T) {
+	address := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+
+	name, err := archiveFileName("https://repo.sourcify.dev/contracts/full_match/1/0xdac17f958d2ee523a2206206994597c13d831ec7/sources/contracts/Token.sol", 1, address)
+	require.NoError(t, err)
+	assert.Equal(t, "files/sources/contracts/Token.sol", name)
+
+	name, err = archiveFileName("https://example.com/other/metadata.json", 1, address)
+	require.NoError(t, err)
+	assert.Equal(t, "files/metadata.json", name)
+}
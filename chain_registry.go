@@ -0,0 +1,287 @@
+package sourcify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrNoExplorer is returned when a chain lists no block explorer.
+	ErrNoExplorer = errors.New("chain has no explorer")
+
+	// ErrNoRPC is returned when none of the RPC URLs of a chain can be used.
+	ErrNoRPC = errors.New("chain has no usable RPC URL")
+
+	// ErrNoChainClient is returned when refreshing a registry that was not created from a client.
+	ErrNoChainClient = errors.New("chain registry has no client to refresh from")
+)
+
+// rpcPlaceholder matches the `${NAME}` placeholders of RPC URLs, e.g. `https://mainnet.infura.io/v3/${INFURA_API_KEY}`.
+var rpcPlaceholder = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+// ChainFilter selects chains, see ChainRegistry.Filter.
+type ChainFilter func(chain Chain) bool
+
+// SupportedChains selects the chains Sourcify verifies contracts on.
+func SupportedChains(chain Chain) bool {
+	return chain.Supported
+}
+
+// MonitoredChains selects the chains Sourcify monitors for new contracts.
+func MonitoredChains(chain Chain) bool {
+	return chain.Monitored
+}
+
+// ChainsWithStatus selects the chains with the given status, e.g. `active` or `deprecated`, ignoring case.
+func ChainsWithStatus(status string) ChainFilter {
+	return func(chain Chain) bool {
+		return strings.EqualFold(chain.Status, status)
+	}
+}
+
+// ParentChainID returns the ID of the chain a layer 2 chain settles on, parsed from the `eip155-<id>` reference of
+// its parent.
+func (c Chain) ParentChainID() (int, bool) {
+	reference, ok := strings.CutPrefix(c.Parent.Chain, "eip155-")
+	if !ok {
+		return 0, false
+	}
+
+	toReturn, err := strconv.Atoi(reference)
+	if err != nil {
+		return 0, false
+	}
+	return toReturn, true
+}
+
+// AddressURL returns the URL of the address on the block explorer of the chain.
+func (c Chain) AddressURL(address common.Address) (string, error) {
+	explorer, err := c.explorerURL()
+	if err != nil {
+		return "", err
+	}
+	return explorer + "/address/" + address.Hex(), nil
+}
+
+// TxURL returns the URL of the transaction on the block explorer of the chain.
+func (c Chain) TxURL(hash common.Hash) (string, error) {
+	explorer, err := c.explorerURL()
+	if err != nil {
+		return "", err
+	}
+	return explorer + "/tx/" + hash.Hex(), nil
+}
+
+// explorerURL returns the base URL of the block explorer of the chain, preferring explorers following the EIP-3091
+// URL scheme.
+func (c Chain) explorerURL() (string, error) {
+	if len(c.Explorers) == 0 {
+		return "", fmt.Errorf("%w: chain %d", ErrNoExplorer, c.ChainID)
+	}
+
+	toReturn := c.Explorers[0].URL
+	for _, explorer := range c.Explorers {
+		if strings.EqualFold(explorer.Standard, "EIP3091") {
+			toReturn = explorer.URL
+			break
+		}
+	}
+	return strings.TrimSuffix(toReturn, "/"), nil
+}
+
+// RPCURLs returns the HTTP RPC URLs of the chain with their `${NAME}` placeholders substituted by lookup, e.g.
+// os.LookupEnv. URLs with a placeholder that lookup does not resolve are left out, as are WebSocket URLs.
+func (c Chain) RPCURLs(lookup func(name string) (string, bool)) []string {
+	var toReturn []string
+	for _, rpc := range c.RPC {
+		if !strings.HasPrefix(rpc, "http://") && !strings.HasPrefix(rpc, "https://") {
+			continue
+		}
+
+		resolved := true
+		substituted := rpcPlaceholder.ReplaceAllStringFunc(rpc, func(placeholder string) string {
+			value, ok := lookup(rpcPlaceholder.FindStringSubmatch(placeholder)[1])
+			if !ok || value == "" {
+				resolved = false
+			}
+			return value
+		})
+		if resolved {
+			toReturn = append(toReturn, substituted)
+		}
+	}
+	return toReturn
+}
+
+// RPCURL returns the first usable HTTP RPC URL of the chain, see RPCURLs.
+func (c Chain) RPCURL(lookup func(name string) (string, bool)) (string, error) {
+	urls := c.RPCURLs(lookup)
+	if len(urls) == 0 {
+		return "", fmt.Errorf("%w: chain %d", ErrNoRPC, c.ChainID)
+	}
+	return urls[0], nil
+}
+
+// ChainRegistry indexes the chains returned by GetChains by ID and short name. A registry loaded with
+// LoadChainRegistry can be refreshed, either on demand or in the background, while it is being read.
+type ChainRegistry struct {
+	client *Client
+
+	mu          sync.RWMutex
+	chains      []Chain
+	byID        map[int]int    // Index of the chains by ID.
+	byShortName map[string]int // Index of the chains by lower case short name.
+	updatedAt   time.Time
+}
+
+// NewChainRegistry creates a registry of the given chains, which cannot be refreshed.
+func NewChainRegistry(chains []Chain) *ChainRegistry {
+	toReturn := &ChainRegistry{}
+	toReturn.set(chains)
+	return toReturn
+}
+
+// LoadChainRegistry creates a registry of the chains returned by GetChains, refreshed from the same client.
+func LoadChainRegistry(client *Client) (*ChainRegistry, error) {
+	toReturn := &ChainRegistry{client: client}
+	if err := toReturn.Refresh(); err != nil {
+		return nil, err
+	}
+	return toReturn, nil
+}
+
+// Refresh replaces the chains of the registry with the ones returned by GetChains. On error, the registry keeps
+// its previous chains.
+func (r *ChainRegistry) Refresh() error {
+	if r.client == nil {
+		return ErrNoChainClient
+	}
+
+	chains, err := GetChains(r.client)
+	if err != nil {
+		return err
+	}
+
+	r.set(chains)
+	return nil
+}
+
+// RefreshInBackground refreshes the registry at every interval until the context is done. Errors are reported to
+// onError, if not nil, and the registry keeps serving its previous chains.
+func (r *ChainRegistry) RefreshInBackground(ctx context.Context, interval time.Duration, onError func(err error)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Refresh(); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+}
+
+// UpdatedAt returns when the chains of the registry were last set.
+func (r *ChainRegistry) UpdatedAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.updatedAt
+}
+
+// Chains returns every chain of the registry, in the order of GetChains.
+func (r *ChainRegistry) Chains() []Chain {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Chain(nil), r.chains...)
+}
+
+// Chain returns the chain with the given ID.
+func (r *ChainRegistry) Chain(chainId int) (Chain, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	index, ok := r.byID[chainId]
+	if !ok {
+		return Chain{}, false
+	}
+	return r.chains[index], true
+}
+
+// ChainByShortName returns the chain with the given short name, e.g. `eth` or `arb1`, ignoring case.
+func (r *ChainRegistry) ChainByShortName(shortName string) (Chain, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	index, ok := r.byShortName[strings.ToLower(shortName)]
+	if !ok {
+		return Chain{}, false
+	}
+	return r.chains[index], true
+}
+
+// Filter returns the chains selected by every filter, e.g. Filter(SupportedChains, ChainsWithStatus("active")).
+func (r *ChainRegistry) Filter(filters ...ChainFilter) []Chain {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var toReturn []Chain
+	for _, chain := range r.chains {
+		selected := true
+		for _, filter := range filters {
+			if !filter(chain) {
+				selected = false
+				break
+			}
+		}
+		if selected {
+			toReturn = append(toReturn, chain)
+		}
+	}
+	return toReturn
+}
+
+// Parent returns the chain a layer 2 chain settles on, when both are in the registry.
+func (r *ChainRegistry) Parent(chainId int) (Chain, bool) {
+	chain, ok := r.Chain(chainId)
+	if !ok {
+		return Chain{}, false
+	}
+
+	parentId, ok := chain.ParentChainID()
+	if !ok {
+		return Chain{}, false
+	}
+	return r.Chain(parentId)
+}
+
+// set replaces the chains of the registry and rebuilds its indexes.
+func (r *ChainRegistry) set(chains []Chain) {
+	byID := make(map[int]int, len(chains))
+	byShortName := make(map[string]int, len(chains))
+	for i, chain := range chains {
+		byID[chain.ChainID] = i
+		if chain.ShortName != "" {
+			byShortName[strings.ToLower(chain.ShortName)] = i
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chains = chains
+	r.byID = byID
+	r.byShortName = byShortName
+	r.updatedAt = time.Now()
+}
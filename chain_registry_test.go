@@ -0,0 +1,196 @@
+package sourcify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testChains() []Chain {
+	return []Chain{
+		{
+			Name:      "Ethereum Mainnet",
+			ShortName: "eth",
+			ChainID:   1,
+			Supported: true,
+			Monitored: true,
+			Status:    "active",
+			Explorers: []ChainExplorer{
+				{Name: "blockscout", URL: "https://eth.blockscout.com/", Standard: "none"},
+				{Name: "etherscan", URL: "https://etherscan.io", Standard: "EIP3091"},
+			},
+			RPC: []string{
+				"https://mainnet.infura.io/v3/${INFURA_API_KEY}",
+				"wss://mainnet.infura.io/ws/v3/${INFURA_API_KEY}",
+				"https://api.mycryptoapi.com/eth",
+			},
+		},
+		{
+			Name:      "Arbitrum One",
+			ShortName: "arb1",
+			ChainID:   42161,
+			Supported: true,
+			Status:    "active",
+			Parent:    ChainParent{Type: "L2", Chain: "eip155-1"},
+		},
+		{
+			Name:      "Ropsten",
+			ShortName: "rop",
+			ChainID:   3,
+			Status:    "deprecated",
+		},
+	}
+}
+
+func TestChainRegistryLookups(t *testing.T) {
+	registry := NewChainRegistry(testChains())
+
+	chain, ok := registry.Chain(42161)
+	require.True(t, ok)
+	assert.Equal(t, "Arbitrum One", chain.Name)
+
+	chain, ok = registry.ChainByShortName("ETH")
+	require.True(t, ok)
+	assert.Equal(t, 1, chain.ChainID)
+
+	_, ok = registry.Chain(10)
+	assert.False(t, ok)
+	_, ok = registry.ChainByShortName("oeth")
+	assert.False(t, ok)
+
+	parent, ok := registry.Parent(42161)
+	require.True(t, ok)
+	assert.Equal(t, 1, parent.ChainID)
+	_, ok = registry.Parent(1)
+	assert.False(t, ok)
+
+	assert.Len(t, registry.Chains(), 3)
+	assert.Equal(t, ErrNoChainClient, registry.Refresh())
+}
+
+func TestChainRegistryFilter(t *testing.T) {
+	registry := NewChainRegistry(testChains())
+
+	chainIds := func(chains []Chain) []int {
+		var toReturn []int
+		for _, chain := range chains {
+			toReturn = append(toReturn, chain.ChainID)
+		}
+		return toReturn
+	}
+
+	assert.Equal(t, []int{1, 42161}, chainIds(registry.Filter(SupportedChains)))
+	assert.Equal(t, []int{1}, chainIds(registry.Filter(SupportedChains, MonitoredChains)))
+	assert.Equal(t, []int{3}, chainIds(registry.Filter(ChainsWithStatus("Deprecated"))))
+	assert.Equal(t, []int{1, 42161, 3}, chainIds(registry.Filter()))
+}
+
+func TestChainExplorerURLs(t *testing.T) {
+	chains := testChains()
+	address := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	hash := common.HexToHash("0x01")
+
+	url, err := chains[0].AddressURL(address)
+	require.NoError(t, err)
+	assert.Equal(t, "https://etherscan.io/address/0xdAC17F958D2ee523a2206206994597C13D831ec7", url)
+
+	url, err = chains[0].TxURL(hash)
+	require.NoError(t, err)
+	assert.Equal(t, "https://etherscan.io/tx/"+hash.Hex(), url)
+
+	// Without an EIP-3091 explorer, the first explorer is used.
+	chains[0].Explorers = chains[0].Explorers[:1]
+	url, err = chains[0].AddressURL(address)
+	require.NoError(t, err)
+	assert.Equal(t, "https://eth.blockscout.com/address/0xdAC17F958D2ee523a2206206994597C13D831ec7", url)
+
+	_, err = chains[1].TxURL(hash)
+	assert.ErrorIs(t, err, ErrNoExplorer)
+}
+
+func TestChainRPCURL(t *testing.T) {
+	chains := testChains()
+	lookup := func(vars map[string]string) func(string) (string, bool) {
+		return func(name string) (string, bool) {
+			value, ok := vars[name]
+			return value, ok
+		}
+	}
+
+	url, err := chains[0].RPCURL(lookup(map[string]string{"INFURA_API_KEY": "key"}))
+	require.NoError(t, err)
+	assert.Equal(t, "https://mainnet.infura.io/v3/key", url)
+
+	// URLs with unresolved placeholders are skipped.
+	url, err = chains[0].RPCURL(lookup(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.mycryptoapi.com/eth", url)
+	assert.Equal(t, []string{"https://api.mycryptoapi.com/eth"}, chains[0].RPCURLs(lookup(map[string]string{"INFURA_API_KEY": ""})))
+
+	_, err = chains[1].RPCURL(lookup(nil))
+	assert.ErrorIs(t, err, ErrNoRPC)
+}
+
+func TestChainParentChainID(t *testing.T) {
+	chainId, ok := Chain{Parent: ChainParent{Chain: "eip155-10"}}.ParentChainID()
+	assert.True(t, ok)
+	assert.Equal(t, 10, chainId)
+
+	_, ok = Chain{}.ParentChainID()
+	assert.False(t, ok)
+	_, ok = Chain{Parent: ChainParent{Chain: "eip155-x"}}.ParentChainID()
+	assert.False(t, ok)
+}
+
+func TestChainRegistryRefresh(t *testing.T) {
+	var mu sync.Mutex
+	chains := testChains()[:1]
+	failing := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failing {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(chains)
+	}))
+	defer server.Close()
+
+	registry, err := LoadChainRegistry(NewClient(WithBaseURL(server.URL)))
+	require.NoError(t, err)
+	assert.Len(t, registry.Chains(), 1)
+	loadedAt := registry.UpdatedAt()
+
+	mu.Lock()
+	chains = testChains()
+	mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registry.RefreshInBackground(ctx, 10*time.Millisecond, nil)
+
+	assert.Eventually(t, func() bool {
+		_, ok := registry.ChainByShortName("arb1")
+		return ok
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, registry.UpdatedAt().After(loadedAt))
+
+	// A failed refresh keeps the previous chains.
+	mu.Lock()
+	failing = true
+	mu.Unlock()
+
+	assert.Error(t, registry.Refresh())
+	assert.Len(t, registry.Chains(), 3)
+}
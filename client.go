@@ -1,12 +1,17 @@
 package sourcify
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/goccy/go-json"
 )
 
 // RetryOptions represents options for configuring retry settings.
@@ -39,6 +44,7 @@ type Client struct {
 	HTTPClient   *http.Client // The HTTP client to use for making requests.
 	RetryOptions RetryOptions // The retry options for the client.
 	RateLimiter  *RateLimiter // The rate limiter for the client.
+	Recorder     *Recorder    // The recorder capturing or replaying the requests of the client, if any.
 }
 
 // WithHTTPClient allows you to provide your own http.Client for the Sourcify client.
@@ -56,6 +62,7 @@ func WithBaseURL(baseURL string) ClientOption {
 }
 
 // WithRetryOptions allows you to configure retry settings for the Sourcify client.
+// Only idempotent requests are retried; verification submissions are sent once.
 func WithRetryOptions(options ...RetryOption) ClientOption {
 	return func(c *Client) {
 		for _, opt := range options {
@@ -85,6 +92,16 @@ func NewClient(options ...ClientOption) *Client {
 		option(c)
 	}
 
+	// The recorder wraps whichever HTTP client was configured, regardless of the option order.
+	if c.Recorder != nil {
+		httpClient := *c.HTTPClient
+		if c.Recorder.Transport == nil {
+			c.Recorder.Transport = httpClient.Transport
+		}
+		httpClient.Transport = c.Recorder
+		c.HTTPClient = &httpClient
+	}
+
 	return c
 }
 
@@ -103,6 +120,43 @@ func (c *Client) CallMethod(method Method) (io.ReadCloser, int, error) {
 	}
 }
 
+// CallMethodWithBody calls the specified URI-based method function, sending body encoded as JSON.
+// It returns the response body and status code, and an error if any. The response body is NOT closed.
+func (c *Client) CallMethodWithBody(method Method, body any) (io.ReadCloser, int, error) {
+	if method.ParamType != MethodParamTypeUri {
+		return nil, 0, fmt.Errorf("invalid MethodParamType for a request with body: %v", method.ParamType)
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode request body: %w", err)
+	}
+
+	requestUrl, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse API base URL: %w", err)
+	}
+
+	uri, err := method.ParseUri()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse method parameters: %w", err)
+	}
+
+	requestPath, err := url.JoinPath(requestUrl.Path, uri)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse full API URL: %w", err)
+	}
+	requestUrl.Path = requestPath
+
+	req, err := http.NewRequest(method.Method, requestUrl.String(), bytes.NewReader(encoded))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.doRequestWithRetry(req)
+}
+
 // callURIMethod calls the URI-based method function with the provided parameters.
 func (c *Client) callURIMethod(method Method) (io.ReadCloser, int, error) {
 	requestUrl, err := url.Parse(c.BaseURL)
@@ -173,18 +227,37 @@ func (c *Client) callUriAndQueryMethod(method Method) (io.ReadCloser, int, error
 }
 
 // doRequestWithRetry sends the HTTP request with retry according to the configured retry options.
+// Requests that are not idempotent, such as the POST requests submitting verifications, are sent only once: a
+// request that timed out or failed with a 5xx status may still have been processed, and sending it again could
+// create a duplicate verification job.
 func (c *Client) doRequestWithRetry(req *http.Request) (io.ReadCloser, int, error) {
 	attempt := 0
 
+	maxRetries := c.RetryOptions.MaxRetries
+	if !isIdempotentMethod(req.Method) {
+		maxRetries = 0
+	}
+
 	for {
 		if c.RateLimiter != nil {
 			c.RateLimiter.Wait()
 		}
 
 		attempt++
+
+		// The body of a retried request has been consumed by the previous attempt.
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+
 		resp, err := c.HTTPClient.Do(req)
 		if err != nil {
-			if attempt <= c.RetryOptions.MaxRetries {
+			// A cancelled request fails again immediately, so it is not retried.
+			if attempt <= maxRetries && !errors.Is(err, context.Canceled) {
 				time.Sleep(c.RetryOptions.Delay)
 				continue
 			}
@@ -193,17 +266,24 @@ func (c *Client) doRequestWithRetry(req *http.Request) (io.ReadCloser, int, erro
 
 		// We do not want to retry on status codes less than 500 as those are not temporary errors
 		if resp.StatusCode >= 500 {
-			if attempt <= c.RetryOptions.MaxRetries {
+			if attempt <= maxRetries {
 				time.Sleep(c.RetryOptions.Delay)
 				continue
 			}
-			if err == nil {
-				return nil, resp.StatusCode, fmt.Errorf("unexpected response (status: %s) (attempt: %d): no error details available", resp.Status, attempt)
-			}
-
-			return nil, resp.StatusCode, fmt.Errorf("unexpected response (status: %s) (attempt: %d): %w", resp.Status, attempt, err)
+			_ = resp.Body.Close()
+			return nil, resp.StatusCode, fmt.Errorf("unexpected response (status: %s) (attempt: %d): %w", resp.Status, attempt, &StatusError{StatusCode: resp.StatusCode})
 		}
 
 		return resp.Body, resp.StatusCode, nil
 	}
 }
+
+// isIdempotentMethod reports whether a request with the given HTTP method can safely be sent more than once.
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,325 @@
+package sourcify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+)
+
+var (
+	// ErrInteractionNotRecorded is returned in replay mode when the cassette holds no response for a request.
+	ErrInteractionNotRecorded = errors.New("interaction not recorded")
+)
+
+// RecorderMode represents how a Recorder treats requests.
+type RecorderMode string
+
+const (
+	// RecorderModeRecord sends every request upstream and records the response, replacing earlier recordings.
+	RecorderModeRecord RecorderMode = "record"
+
+	// RecorderModeReplay answers every request from the cassette and never reaches the network.
+	RecorderModeReplay RecorderMode = "replay"
+
+	// RecorderModeAuto replays recorded interactions and records the requests that are missing from the cassette.
+	RecorderModeAuto RecorderMode = "auto"
+)
+
+// RecordedRequest represents the request part of a recorded interaction.
+type RecordedRequest struct {
+	Method     string `json:"method"`
+	URI        string `json:"uri"`                  // Path and query, without the host so cassettes replay against any base URL.
+	BodySHA256 string `json:"bodySha256,omitempty"` // Hash of the request body, when the request has one.
+	Sequence   int    `json:"sequence"`             // Position of the interaction among the identical requests, starting at 1.
+}
+
+// RecordedResponse represents the response part of a recorded interaction.
+type RecordedResponse struct {
+	StatusCode   int         `json:"statusCode"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty"` // `base64` when the body is not valid UTF-8.
+}
+
+// Interaction represents a request and the response it received, as stored in a cassette.
+type Interaction struct {
+	Request    RecordedRequest  `json:"request"`
+	Response   RecordedResponse `json:"response"`
+	RecordedAt time.Time        `json:"recordedAt"`
+}
+
+// Recorder is an http.RoundTripper that records the interactions of the client into a cassette directory and
+// replays them deterministically. Each interaction is stored in its own JSON file, named after a hash of the
+// request method, URI and body followed by its sequence number among the identical requests, so cassettes can be
+// reviewed and updated file by file. Identical requests, such as the polls of a verification job, are replayed in
+// the order they were recorded; once the recorded responses are exhausted, the last one is replayed again.
+type Recorder struct {
+	// Dir is the cassette directory.
+	Dir string
+	// Mode determines whether requests are recorded, replayed or both.
+	Mode RecorderMode
+	// Transport sends the requests that are recorded. http.DefaultTransport is used when nil.
+	Transport http.RoundTripper
+
+	mu        sync.Mutex
+	sequences map[string]int // Number of requests seen by key.
+}
+
+// NewRecorder creates a recorder storing its cassette in dir.
+func NewRecorder(dir string, mode RecorderMode) *Recorder {
+	return &Recorder{Dir: dir, Mode: mode}
+}
+
+// WithRecorder records the requests made by the client into the cassette directory dir, or replays them from it,
+// depending on the mode. The recorder wraps the transport of the HTTP client configured on the client.
+func WithRecorder(dir string, mode RecorderMode) ClientOption {
+	return func(c *Client) {
+		c.Recorder = NewRecorder(dir, mode)
+	}
+}
+
+// RoundTrip answers the request from the cassette or sends it upstream, depending on the mode.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	bodySum, err := requestBodySum(req)
+	if err != nil {
+		return nil, err
+	}
+	key := interactionKey(req, bodySum)
+	sequence := r.nextSequence(key)
+
+	if r.Mode != RecorderModeRecord {
+		interaction, err := r.loadSequence(key, sequence)
+		switch {
+		case err == nil:
+			return interaction.response(req)
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		case r.Mode == RecorderModeReplay:
+			return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotRecorded, req.Method, req.URL.RequestURI())
+		}
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response to record: %w", err)
+	}
+
+	interaction := &Interaction{
+		Request: RecordedRequest{Method: req.Method, URI: req.URL.RequestURI(), BodySHA256: bodySum, Sequence: sequence},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       string(body),
+		},
+		RecordedAt: time.Now().UTC(),
+	}
+	interaction.Response.Header.Del("Set-Cookie")
+	if !utf8.Valid(body) {
+		interaction.Response.Body = base64.StdEncoding.EncodeToString(body)
+		interaction.Response.BodyEncoding = "base64"
+	}
+
+	// The first recording of a request replaces every response recorded for it before.
+	if sequence == 1 {
+		if err := r.remove(key); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.save(r.interactionPath(key, sequence), interaction); err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// Interactions returns every interaction stored in the cassette, in file name order.
+func (r *Recorder) Interactions() ([]*Interaction, error) {
+	paths, err := filepath.Glob(filepath.Join(r.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := make([]*Interaction, 0, len(paths))
+	for _, path := range paths {
+		interaction, err := r.load(path)
+		if err != nil {
+			return nil, err
+		}
+		toReturn = append(toReturn, interaction)
+	}
+	return toReturn, nil
+}
+
+// requestBodySum returns the hex encoded SHA-256 of the request body, or an empty string when it has none. The
+// body is restored so the request can still be sent.
+func requestBodySum(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// interactionKey identifies the request by its method, URI and body hash.
+func interactionKey(req *http.Request, bodySum string) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.RequestURI() + "\n" + bodySum))
+	return hex.EncodeToString(sum[:8])
+}
+
+// nextSequence returns the sequence number of the next request with the key, starting at 1.
+func (r *Recorder) nextSequence(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sequences == nil {
+		r.sequences = make(map[string]int)
+	}
+	r.sequences[key]++
+	return r.sequences[key]
+}
+
+// interactionPath returns the cassette file of the interaction with the key and sequence number.
+func (r *Recorder) interactionPath(key string, sequence int) string {
+	return filepath.Join(r.Dir, fmt.Sprintf("%s-%03d.json", key, sequence))
+}
+
+// loadSequence reads the interaction with the key and sequence number, or the last one recorded for the key when
+// fewer were recorded.
+func (r *Recorder) loadSequence(key string, sequence int) (*Interaction, error) {
+	toReturn, err := r.load(r.interactionPath(key, sequence))
+	if !errors.Is(err, os.ErrNotExist) {
+		return toReturn, err
+	}
+
+	paths, globErr := filepath.Glob(filepath.Join(r.Dir, key+"-*.json"))
+	if globErr != nil {
+		return nil, globErr
+	}
+
+	last := 0
+	for _, path := range paths {
+		recorded, convErr := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), key+"-"), ".json"))
+		if convErr == nil && recorded > last {
+			last = recorded
+		}
+	}
+	if last == 0 {
+		return nil, err
+	}
+	return r.load(r.interactionPath(key, last))
+}
+
+// remove deletes every interaction recorded for the key.
+func (r *Recorder) remove(key string) error {
+	paths, err := filepath.Glob(filepath.Join(r.Dir, key+"-*.json"))
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove interaction: %w", err)
+		}
+	}
+	return nil
+}
+
+// load reads a recorded interaction.
+func (r *Recorder) load(path string) (*Interaction, error) {
+	r.mu.Lock()
+	data, err := os.ReadFile(path)
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var toReturn Interaction
+	if err := json.Unmarshal(data, &toReturn); err != nil {
+		return nil, fmt.Errorf("failed to decode interaction %s: %w", filepath.Base(path), err)
+	}
+	return &toReturn, nil
+}
+
+// save writes a recorded interaction, replacing any earlier recording at the same path.
+func (r *Recorder) save(path string, interaction *Interaction) error {
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode interaction: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write interaction: %w", err)
+	}
+	return nil
+}
+
+// response rebuilds the recorded response for the request.
+func (i *Interaction) response(req *http.Request) (*http.Response, error) {
+	body := []byte(i.Response.Body)
+	if i.Response.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(i.Response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode recorded body: %w", err)
+		}
+		body = decoded
+	}
+
+	header := i.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+		StatusCode:    i.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,170 @@
+package sourcify
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	chainID := 1
+	contractAddress := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+
+	localResponse, err := LoadContract(chainID, contractAddress)
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/v2/contract/1/" + contractAddress.Hex():
+			_ = json.NewEncoder(w).Encode(localResponse)
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	dir := t.TempDir()
+
+	client := NewClient(WithBaseURL(mockServer.URL), WithRecorder(dir, RecorderModeRecord))
+	recorded, err := GetContractByChainIdAndAddress(client, chainID, contractAddress, nil, nil)
+	require.NoError(t, err)
+	healthy, err := GetHealth(client)
+	require.NoError(t, err)
+	assert.True(t, healthy)
+	assert.Equal(t, int32(2), calls.Load())
+
+	interactions, err := client.Recorder.Interactions()
+	require.NoError(t, err)
+	assert.Len(t, interactions, 2)
+
+	// Replaying must not reach the server, which is gone, and works against any base URL.
+	mockServer.Close()
+
+	replay := NewClient(WithBaseURL("http://sourcify.invalid"), WithRecorder(dir, RecorderModeReplay))
+	replayed, err := GetContractByChainIdAndAddress(replay, chainID, contractAddress, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, recorded, replayed)
+	assert.Equal(t, localResponse, replayed)
+
+	_, err = GetChains(replay)
+	assert.ErrorIs(t, err, ErrInteractionNotRecorded)
+}
+
+func TestRecorderAutoMode(t *testing.T) {
+	var calls atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_ = json.NewEncoder(w).Encode([]Chain{{Name: "Ethereum Mainnet", ChainID: 1}})
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(WithBaseURL(mockServer.URL), WithRecorder(t.TempDir(), RecorderModeAuto))
+	for i := 0; i < 3; i++ {
+		chains, err := GetChains(client)
+		require.NoError(t, err)
+		require.Len(t, chains, 1)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRecorderBodiesAndSequences(t *testing.T) {
+	var polls atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/verify/1/0x0000000000000000000000000000000000000001":
+			var request VerifyRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = fmt.Fprintf(w, `{"verificationId":%q}`, request.ContractIdentifier)
+		case "/v2/verify/job":
+			completed := polls.Add(1) >= 3
+			_, _ = fmt.Fprintf(w, `{"isJobCompleted":%t,"verificationId":"job"}`, completed)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	submit := func(client *Client, identifier string) (string, error) {
+		return VerifyContract(client, 1, common.HexToAddress("0x01"), &VerifyRequest{
+			StdJSONInput:       &StdJSONInput{Language: "Solidity"},
+			CompilerVersion:    "0.8.19+commit.7dd6d404",
+			ContractIdentifier: identifier,
+		})
+	}
+	poll := func(client *Client) []bool {
+		var toReturn []bool
+		for i := 0; i < 4; i++ {
+			job, err := GetVerificationJob(client, "job")
+			require.NoError(t, err)
+			toReturn = append(toReturn, job.IsJobCompleted)
+		}
+		return toReturn
+	}
+
+	dir := t.TempDir()
+	client := NewClient(WithBaseURL(mockServer.URL), WithRecorder(dir, RecorderModeRecord))
+	for _, identifier := range []string{"A.sol:A", "B.sol:B"} {
+		verificationId, err := submit(client, identifier)
+		require.NoError(t, err)
+		assert.Equal(t, identifier, verificationId)
+	}
+	assert.Equal(t, []bool{false, false, true, true}, poll(client))
+	mockServer.Close()
+
+	// Requests with different bodies replay their own responses, and identical requests replay in order.
+	replay := NewClient(WithBaseURL("http://sourcify.invalid"), WithRecorder(dir, RecorderModeReplay))
+	for _, identifier := range []string{"B.sol:B", "A.sol:A"} {
+		verificationId, err := submit(replay, identifier)
+		require.NoError(t, err)
+		assert.Equal(t, identifier, verificationId)
+	}
+	assert.Equal(t, []bool{false, false, true, true, true}, append(poll(replay), poll(replay)[0]))
+
+	_, err := submit(replay, "C.sol:C")
+	assert.ErrorIs(t, err, ErrInteractionNotRecorded)
+}
+
+func TestRecorderWrapsHTTPClient(t *testing.T) {
+	var used atomic.Bool
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		used.Store(true)
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte{0xff, 0xfe, 0x00})
+	}))
+	defer mockServer.Close()
+
+	dir := t.TempDir()
+	client := NewClient(WithRecorder(dir, RecorderModeRecord), WithBaseURL(mockServer.URL), WithHTTPClient(&http.Client{Transport: transport}))
+	assert.NotSame(t, http.DefaultClient, client.HTTPClient)
+
+	healthy, err := GetHealth(client)
+	require.NoError(t, err)
+	assert.True(t, healthy)
+	assert.True(t, used.Load())
+
+	interactions, err := client.Recorder.Interactions()
+	require.NoError(t, err)
+	require.Len(t, interactions, 1)
+	assert.Equal(t, "base64", interactions[0].Response.BodyEncoding)
+	assert.Equal(t, "/health", interactions[0].Request.URI)
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,66 @@
+// Command sourcify-serve serves the Sourcify API from a local copy of the Sourcify repository, either a directory
+// or a zip or tar.gz archive, so tools speaking the Sourcify API can point at a self hosted instance.
+//
+// Usage:
+//
+//	sourcify-serve -repository ./sourcify-repository -addr :5555
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/unpackdev/sourcify-go"
+	"github.com/unpackdev/sourcify-go/server"
+)
+
+func main() {
+	repositoryPath := flag.String("repository", "", "repository directory, zip or tar.gz archive to serve")
+	addr := flag.String("addr", ":5555", "address to listen on")
+	baseURL := flag.String("base-url", "", "public URL of the server used in file URLs, defaults to the request host")
+	flag.Parse()
+
+	if *repositoryPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	repository, err := sourcify.OpenLocalRepository(*repositoryPath)
+	if err != nil {
+		log.Fatalf("failed to open repository: %s", err)
+	}
+	defer repository.Close()
+
+	var options []server.Option
+	if *baseURL != "" {
+		options = append(options, server.WithBaseURL(*baseURL))
+	}
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(repository, options...),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("serving %s on %s", *repositoryPath, *addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("failed to serve: %s", err)
+	}
+}
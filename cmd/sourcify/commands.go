@@ -0,0 +1,703 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/unpackdev/sourcify-go"
+)
+
+// commandContext holds what commands need to run.
+type commandContext struct {
+	client *sourcify.Client
+	stderr io.Writer
+}
+
+// command represents a subcommand of the command line.
+type command struct {
+	name    string
+	usage   string // Arguments following the command flags, if any.
+	summary string
+	run     func(ctx context.Context, c *commandContext, args []string) (*result, error)
+}
+
+// commands lists the subcommands, in the order they are printed in the usage.
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "health", summary: "Check whether Sourcify is up", run: runHealth},
+		{name: "chains", summary: "List the chains known to Sourcify", run: runChains},
+		{name: "contract", summary: "Get the details of a verified contract", run: runContract},
+		{name: "contracts", summary: "List the verified contracts of a chain", run: runContracts},
+		{name: "addresses", summary: "List the verified addresses of a chain", run: runAddresses},
+		{name: "check", usage: "<address>...", summary: "Check whether addresses are verified", run: runCheck},
+		{name: "tree", summary: "List the repository file URLs of a contract", run: runTree},
+		{name: "files", summary: "Get the repository files of a contract", run: runFiles},
+		{name: "source", summary: "Print the source code of a contract", run: runSource},
+		{name: "metadata", summary: "Get the compiler metadata of a contract", run: runMetadata},
+		{name: "verify", summary: "Submit a contract for verification or follow a verification job", run: runVerify},
+		{name: "export", summary: "Export a contract as a project directory or an archive", run: runExport},
+	}
+}
+
+// lookupCommand returns the command named name.
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// newFlagSet creates the flag set of the command, printing its usage to the standard error of the context.
+func (c *commandContext) newFlagSet(name string) *flag.FlagSet {
+	cmd, _ := lookupCommand(name)
+
+	toReturn := flag.NewFlagSet(name, flag.ContinueOnError)
+	toReturn.SetOutput(c.stderr)
+	toReturn.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: %s\n\n%s.\n\nFlags:\n", strings.TrimSpace("sourcify "+name+" [flags] "+cmd.usage), cmd.summary)
+		toReturn.PrintDefaults()
+	}
+	return toReturn
+}
+
+// parse parses the flags of a command, returning flag.ErrHelp when help is requested and a usage error otherwise.
+func parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{err: err}
+	}
+	return nil
+}
+
+// contractFlags holds the flags identifying a contract.
+type contractFlags struct {
+	chain   int
+	address string
+	match   string
+}
+
+// register adds the contract flags to the flag set. Without a match flag, the contract is looked up with any match.
+func (f *contractFlags) register(flags *flag.FlagSet, withMatch bool) {
+	flags.IntVar(&f.chain, "chain", 1, "chain ID")
+	flags.StringVar(&f.address, "address", "", "contract address (required)")
+	if withMatch {
+		flags.StringVar(&f.match, "match", string(sourcify.MethodMatchTypeAny), "match type: any, full or partial")
+	}
+}
+
+// resolve validates the contract flags.
+func (f *contractFlags) resolve() (common.Address, sourcify.MethodMatchType, error) {
+	if !common.IsHexAddress(f.address) {
+		return common.Address{}, "", usagef("invalid or missing -address %q", f.address)
+	}
+
+	matchType := sourcify.MethodMatchType(f.match)
+	switch matchType {
+	case "":
+		matchType = sourcify.MethodMatchTypeAny
+	case sourcify.MethodMatchTypeAny, sourcify.MethodMatchTypeFull, sourcify.MethodMatchTypePartial:
+	default:
+		return common.Address{}, "", usagef("invalid -match %q, expected any, full or partial", f.match)
+	}
+	return common.HexToAddress(f.address), matchType, nil
+}
+
+func runHealth(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	if err := parse(c.newFlagSet("health"), args); err != nil {
+		return nil, err
+	}
+
+	healthy, err := sourcify.GetHealth(c.client)
+	if err != nil {
+		return nil, err
+	}
+	if !healthy {
+		return nil, errUnhealthy
+	}
+
+	return &result{
+		value:  map[string]bool{"healthy": true},
+		header: []string{"HEALTHY"},
+		rows:   [][]string{{"true"}},
+	}, nil
+}
+
+func runChains(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	flags := c.newFlagSet("chains")
+	supported := flags.Bool("supported", false, "only list the supported chains")
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	chains, err := sourcify.GetChains(c.client)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := &result{header: []string{"ID", "NAME", "SHORT NAME", "SUPPORTED", "MONITORED"}}
+	listed := make([]sourcify.Chain, 0, len(chains))
+	for _, chain := range chains {
+		if *supported && !chain.Supported {
+			continue
+		}
+		listed = append(listed, chain)
+		toReturn.rows = append(toReturn.rows, []string{
+			strconv.Itoa(chain.ChainID),
+			chain.Name,
+			chain.ShortName,
+			strconv.FormatBool(chain.Supported),
+			strconv.FormatBool(chain.Monitored),
+		})
+	}
+	toReturn.value = listed
+	return toReturn, nil
+}
+
+func runContract(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	var target contractFlags
+	flags := c.newFlagSet("contract")
+	target.register(flags, false)
+	fields := flags.String("fields", "", "comma separated fields to return, or all")
+	omit := flags.String("omit", "", "comma separated fields to omit")
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	address, _, err := target.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	contract, err := sourcify.GetContractByChainIdAndAddress(c.client, target.chain, address, splitList(*fields), splitList(*omit))
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{
+		{"address", contract.Address},
+		{"chainId", contract.ChainID},
+		{"match", contract.Match},
+		{"creationMatch", contract.CreationMatch},
+		{"runtimeMatch", contract.RuntimeMatch},
+		{"matchId", contract.MatchID},
+		{"verifiedAt", formatTime(contract.VerifiedAt)},
+	}
+	if contract.Compilation.Name != "" {
+		rows = append(rows,
+			[]string{"name", contract.Compilation.FullyQualifiedName},
+			[]string{"compiler", contract.Compilation.Compiler + " " + contract.Compilation.CompilerVersion},
+		)
+	}
+
+	return &result{value: contract, header: []string{"FIELD", "VALUE"}, rows: rows}, nil
+}
+
+func runContracts(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	flags := c.newFlagSet("contracts")
+	chain := flags.Int("chain", 1, "chain ID")
+	order := flags.String("sort", "desc", "sort order of the match IDs: asc or desc")
+	after := flags.String("after", "", "only list the contracts after this match ID")
+	limit := flags.Int("limit", 200, "maximum number of contracts, up to 200")
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	if *order != "asc" && *order != "desc" {
+		return nil, usagef("invalid -sort %q, expected asc or desc", *order)
+	}
+
+	contracts, err := sourcify.GetContractsByChainId(c.client, *chain, *order, *after, *limit)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := &result{value: contracts, header: []string{"MATCH ID", "ADDRESS", "MATCH", "CREATION", "RUNTIME", "VERIFIED AT"}}
+	for _, contract := range contracts.Results {
+		toReturn.rows = append(toReturn.rows, []string{
+			contract.MatchID,
+			contract.Address,
+			contract.Match,
+			contract.CreationMatch,
+			contract.RuntimeMatch,
+			formatTime(contract.VerifiedAt),
+		})
+	}
+	return toReturn, nil
+}
+
+func runAddresses(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	flags := c.newFlagSet("addresses")
+	chain := flags.Int("chain", 1, "chain ID")
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	addresses, err := sourcify.GetAvailableContractAddresses(c.client, *chain)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := &result{value: addresses, header: []string{"ADDRESS", "MATCH"}}
+	for _, address := range addresses.Full {
+		toReturn.rows = append(toReturn.rows, []string{address.Hex(), "full"})
+	}
+	for _, address := range addresses.Partial {
+		toReturn.rows = append(toReturn.rows, []string{address.Hex(), "partial"})
+	}
+	return toReturn, nil
+}
+
+func runCheck(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	flags := c.newFlagSet("check")
+	chains := flags.String("chains", "1", "comma separated chain IDs")
+	all := flags.Bool("all", false, "also report partial matches")
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() == 0 {
+		return nil, usagef("at least one address is required")
+	}
+	for _, address := range flags.Args() {
+		if !common.IsHexAddress(address) {
+			return nil, usagef("invalid address %q", address)
+		}
+	}
+
+	var chainIds []int
+	for _, chain := range splitList(*chains) {
+		chainId, err := strconv.Atoi(chain)
+		if err != nil {
+			return nil, usagef("invalid chain ID %q", chain)
+		}
+		chainIds = append(chainIds, chainId)
+	}
+
+	matchType := sourcify.MethodMatchTypeFull
+	if *all {
+		matchType = sourcify.MethodMatchTypeAny
+	}
+
+	checks, err := sourcify.CheckContractByAddresses(c.client, flags.Args(), chainIds, matchType)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := &result{value: checks, header: []string{"ADDRESS", "STATUS", "CHAINS"}}
+	for _, check := range checks {
+		toReturn.rows = append(toReturn.rows, []string{check.Address.Hex(), check.Status, strings.Join(check.ChainIDs, ",")})
+	}
+	return toReturn, nil
+}
+
+func runTree(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	var target contractFlags
+	flags := c.newFlagSet("tree")
+	target.register(flags, true)
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	address, matchType, err := target.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := sourcify.GetContractFiles(c.client, target.chain, address, matchType)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := &result{value: tree, header: []string{"STATUS", "FILE"}}
+	for _, file := range tree.Files {
+		toReturn.rows = append(toReturn.rows, []string{tree.Status, file})
+	}
+	return toReturn, nil
+}
+
+func runFiles(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	var target contractFlags
+	flags := c.newFlagSet("files")
+	target.register(flags, true)
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	address, matchType, err := target.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := sourcify.GetContractSourceCode(c.client, target.chain, address, matchType)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := &result{value: codes, header: []string{"STATUS", "NAME", "SIZE", "PATH"}}
+	for _, code := range codes.Code {
+		toReturn.rows = append(toReturn.rows, []string{codes.Status, code.Name, strconv.Itoa(len(code.Content)), code.Path})
+	}
+	return toReturn, nil
+}
+
+func runSource(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	var target contractFlags
+	flags := c.newFlagSet("source")
+	target.register(flags, true)
+	file := flags.String("file", "", "only print the file with this name or path suffix")
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	address, matchType, err := target.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := sourcify.GetContractSourceCode(c.client, target.chain, address, matchType)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []sourcify.SourceCode
+	for _, code := range codes.Code {
+		switch {
+		case *file != "" && (code.Name == *file || strings.HasSuffix(code.Path, "/"+strings.TrimPrefix(*file, "/"))):
+			selected = append(selected, code)
+		case *file == "" && code.Name != "metadata.json" && strings.Contains(code.Path, "/sources/"):
+			selected = append(selected, code)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: %s", sourcify.ErrFileNotFound, *file)
+	}
+
+	// A single file is printed as is, several files are each preceded by their path.
+	if len(selected) == 1 {
+		return &result{raw: []byte(selected[0].Content)}, nil
+	}
+
+	var raw strings.Builder
+	for i, code := range selected {
+		if i > 0 {
+			raw.WriteString("\n")
+		}
+		fmt.Fprintf(&raw, "// File: %s\n\n%s\n", sourcePath(code.Path), strings.TrimRight(code.Content, "\n"))
+	}
+	return &result{raw: []byte(raw.String())}, nil
+}
+
+func runMetadata(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	var target contractFlags
+	flags := c.newFlagSet("metadata")
+	target.register(flags, true)
+	raw := flags.Bool("raw", false, "print metadata.json as stored in the repository")
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	address, matchType, err := target.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	if *raw {
+		data, err := sourcify.GetContractMetadataAsBytes(c.client, target.chain, address, matchType)
+		if err != nil {
+			return nil, err
+		}
+		return &result{raw: data}, nil
+	}
+
+	metadata, err := sourcify.GetContractMetadata(c.client, target.chain, address, matchType)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{
+		{"language", metadata.Language},
+		{"compiler", metadata.Compiler.Version},
+		{"evmVersion", metadata.Settings.EvmVersion},
+		{"optimizer", fmt.Sprintf("%t (%d runs)", metadata.Settings.Optimizer.Enabled, metadata.Settings.Optimizer.Runs)},
+		{"sources", strconv.Itoa(len(metadata.Sources))},
+	}
+	for file, name := range metadata.Settings.CompilationTarget {
+		rows = append(rows, []string{"target", file + ":" + name})
+	}
+	return &result{value: metadata, header: []string{"FIELD", "VALUE"}, rows: rows}, nil
+}
+
+func runVerify(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	var target contractFlags
+	flags := c.newFlagSet("verify")
+	target.register(flags, false)
+	input := flags.String("input", "", "standard JSON input file to verify from")
+	compilerVersion := flags.String("compiler-version", "", "long compiler version, e.g. 0.8.19+commit.7dd6d404, with -input")
+	contractName := flags.String("contract", "", "fully qualified contract name, e.g. contracts/Token.sol:Token, with -input")
+	metadataFile := flags.String("metadata", "", "metadata file to verify from, instead of -input")
+	sourcesDir := flags.String("sources", "", "directory holding the sources listed in -metadata, defaults to its directory")
+	creationTx := flags.String("creation-tx", "", "hash of the creation transaction, if known")
+	job := flags.String("job", "", "only report the status of this verification job")
+	wait := flags.Bool("wait", false, "wait for the verification job to complete")
+	interval := flags.Duration("interval", 2*time.Second, "polling interval of -wait")
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	verificationId := *job
+	if verificationId == "" {
+		address, _, err := target.resolve()
+		if err != nil {
+			return nil, err
+		}
+
+		if verificationId, err = submitVerification(c, target.chain, address, *input, *compilerVersion, *contractName, *metadataFile, *sourcesDir, *creationTx); err != nil {
+			return nil, err
+		}
+	}
+
+	var verification *sourcify.VerificationJob
+	var err error
+	if *wait {
+		verification, err = sourcify.WaitForVerification(ctx, c.client, verificationId, *interval)
+	} else if *job != "" {
+		if verification, err = sourcify.GetVerificationJob(c.client, verificationId); err == nil {
+			err = verification.Err()
+		}
+	} else {
+		return &result{
+			value:  map[string]string{"verificationId": verificationId},
+			header: []string{"VERIFICATION ID"},
+			rows:   [][]string{{verificationId}},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := "pending"
+	switch {
+	case verification.Error != nil:
+		status = "failed: " + verification.Error.Message
+	case verification.IsJobCompleted:
+		status = verification.Contract.Match
+	}
+	return &result{
+		value:  verification,
+		header: []string{"VERIFICATION ID", "COMPLETED", "STATUS"},
+		rows:   [][]string{{verification.VerificationID, strconv.FormatBool(verification.IsJobCompleted), status}},
+	}, nil
+}
+
+func runExport(ctx context.Context, c *commandContext, args []string) (*result, error) {
+	var target contractFlags
+	flags := c.newFlagSet("export")
+	target.register(flags, false)
+	dir := flags.String("dir", "", "directory to export the contract to")
+	layout := flags.String("layout", string(sourcify.ProjectLayoutPlain), "project layout of -dir: plain, foundry or hardhat")
+	archive := flags.String("archive", "", "zip or tar.gz archive to export the contract to, instead of -dir")
+	if err := parse(flags, args); err != nil {
+		return nil, err
+	}
+
+	address, _, err := target.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case *dir != "" && *archive != "":
+		return nil, usagef("-dir and -archive cannot be used together")
+	case *archive != "":
+		format := sourcify.ArchiveFormatZip
+		switch {
+		case strings.HasSuffix(*archive, ".zip"):
+		case strings.HasSuffix(*archive, ".tar.gz"), strings.HasSuffix(*archive, ".tgz"):
+			format = sourcify.ArchiveFormatTarGz
+		default:
+			return nil, usagef("invalid -archive %q, expected a .zip or .tar.gz file", *archive)
+		}
+
+		file, err := os.Create(*archive)
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := sourcify.ExportArchive(ctx, c.client, file, format, target.chain, address)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(*archive)
+			return nil, err
+		}
+
+		toReturn := &result{value: manifest, header: []string{"FILE", "SIZE", "SHA256"}}
+		for _, file := range manifest.Files {
+			toReturn.rows = append(toReturn.rows, []string{file.Path, strconv.FormatInt(file.Size, 10), file.SHA256})
+		}
+		return toReturn, nil
+	case *dir != "":
+		projectLayout := sourcify.ProjectLayout(*layout)
+		switch projectLayout {
+		case sourcify.ProjectLayoutPlain, sourcify.ProjectLayoutFoundry, sourcify.ProjectLayoutHardhat:
+		default:
+			return nil, usagef("invalid -layout %q, expected plain, foundry or hardhat", *layout)
+		}
+
+		contract, err := sourcify.GetContractByChainIdAndAddress(c.client, target.chain, address, []string{"all"}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// The original metadata file is exported, so that it matches the metadata hash embedded in the bytecode.
+		matchType := sourcify.MethodMatchTypePartial
+		if contract.Match == "exact_match" {
+			matchType = sourcify.MethodMatchTypeFull
+		}
+		metadata, err := sourcify.GetContractMetadataAsBytes(c.client, target.chain, address, matchType)
+		if err != nil {
+			return nil, err
+		}
+
+		files, err := sourcify.ExportContract(*dir, contract, sourcify.WithProjectLayout(projectLayout), sourcify.WithMetadataFile(metadata))
+		if err != nil {
+			return nil, err
+		}
+
+		toReturn := &result{value: map[string]any{"dir": *dir, "files": files}, header: []string{"FILE"}}
+		for _, file := range files {
+			toReturn.rows = append(toReturn.rows, []string{filepath.Join(*dir, file)})
+		}
+		return toReturn, nil
+	default:
+		return nil, usagef("one of -dir or -archive is required")
+	}
+}
+
+// submitVerification submits the contract for verification from either its standard JSON input or its metadata.
+func submitVerification(c *commandContext, chainId int, address common.Address, input, compilerVersion, contractName, metadataFile, sourcesDir, creationTx string) (string, error) {
+	switch {
+	case input != "" && metadataFile != "":
+		return "", usagef("-input and -metadata cannot be used together")
+	case input != "":
+		if compilerVersion == "" || contractName == "" {
+			return "", usagef("-compiler-version and -contract are required with -input")
+		}
+
+		var stdJSONInput sourcify.StdJSONInput
+		if err := readJSONFile(input, &stdJSONInput); err != nil {
+			return "", err
+		}
+
+		return sourcify.VerifyContract(c.client, chainId, address, &sourcify.VerifyRequest{
+			StdJSONInput:            &stdJSONInput,
+			CompilerVersion:         compilerVersion,
+			ContractIdentifier:      contractName,
+			CreationTransactionHash: creationTx,
+		})
+	case metadataFile != "":
+		request, err := metadataRequest(metadataFile, sourcesDir)
+		if err != nil {
+			return "", err
+		}
+		request.CreationTransactionHash = creationTx
+
+		return sourcify.VerifyContractFromMetadata(c.client, chainId, address, request)
+	default:
+		return "", usagef("one of -input, -metadata or -job is required")
+	}
+}
+
+// metadataRequest reads the metadata file and the sources it lists from sourcesDir, or from the directory of the
+// metadata file when empty.
+func metadataRequest(metadataFile string, sourcesDir string) (*sourcify.VerifyMetadataRequest, error) {
+	var metadata sourcify.Metadata
+	if err := readJSONFile(metadataFile, &metadata); err != nil {
+		return nil, err
+	}
+
+	if sourcesDir == "" {
+		sourcesDir = filepath.Dir(metadataFile)
+	}
+
+	names := make([]string, 0, len(metadata.Sources))
+	for name := range metadata.Sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	toReturn := &sourcify.VerifyMetadataRequest{Metadata: &metadata, Sources: make(map[string]string, len(names))}
+	for _, name := range names {
+		if content := metadata.Sources[name].Content; content != "" {
+			toReturn.Sources[name] = content
+			continue
+		}
+
+		sanitized, err := sourcify.SanitizeSourcePath(name)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(filepath.Join(sourcesDir, filepath.FromSlash(sanitized)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source %s: %w", name, err)
+		}
+		toReturn.Sources[name] = string(content)
+	}
+	return toReturn, nil
+}
+
+// readJSONFile decodes the JSON file at path into v.
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// sourcePath returns the source path following the `sources/` directory of a repository path.
+func sourcePath(repositoryPath string) string {
+	if index := strings.Index(repositoryPath, "/sources/"); index >= 0 {
+		return repositoryPath[index+len("/sources/"):]
+	}
+	return repositoryPath
+}
+
+// splitList splits a comma separated flag value, dropping empty elements.
+func splitList(value string) []string {
+	var toReturn []string
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			toReturn = append(toReturn, element)
+		}
+	}
+	return toReturn
+}
+
+// formatTime formats a time for the table output, empty when zero.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/unpackdev/sourcify-go"
+)
+
+const (
+	exitOK                 = 0
+	exitError              = 1
+	exitUsage              = 2
+	exitNotFound           = 3
+	exitRejected           = 4
+	exitVerificationFailed = 5
+	exitUnavailable        = 6
+)
+
+// errUnhealthy is returned by the health command when Sourcify is not healthy.
+var errUnhealthy = errors.New("sourcify is not healthy")
+
+// usageError represents an invalid command line.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *usageError) Unwrap() error {
+	return e.err
+}
+
+// usagef returns a usage error formatted from its arguments.
+func usagef(format string, args ...any) error {
+	return &usageError{err: fmt.Errorf(format, args...)}
+}
+
+// exitCode maps the error of a command to the exit code of the command line.
+func exitCode(err error) int {
+	var usageErr *usageError
+	var errorResponse *sourcify.ErrorResponse
+	var statusErr *sourcify.StatusError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.Is(err, sourcify.ErrVerificationFailed):
+		return exitVerificationFailed
+	case errors.Is(err, sourcify.ErrContractNotFound), errors.Is(err, sourcify.ErrFileNotFound):
+		return exitNotFound
+	case errors.Is(err, context.Canceled):
+		return exitError
+	case errors.Is(err, errUnhealthy), errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return exitUnavailable
+	case errors.As(err, &errorResponse):
+		switch {
+		case strings.Contains(errorResponse.CustomCode, "not_found"):
+			return exitNotFound
+		case errorResponse.CustomCode == "internal_error":
+			return exitUnavailable
+		default:
+			return exitRejected
+		}
+	}
+
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == 404:
+			return exitNotFound
+		case statusErr.StatusCode == 429, statusErr.StatusCode >= 500:
+			return exitUnavailable
+		case statusErr.StatusCode >= 400:
+			return exitRejected
+		}
+	}
+	return exitError
+}
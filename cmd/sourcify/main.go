@@ -0,0 +1,194 @@
+// Command sourcify is a command line client for the Sourcify API.
+//
+// Usage:
+//
+//	sourcify [flags] <command> [command flags] [arguments]
+//
+// Every endpoint of the API has a command, see `sourcify -h` for the list. Results are printed as JSON, YAML or a
+// table, selected with -output. The base URL, retries, rate limit and timeout are set with flags or with the
+// SOURCIFY_BASE_URL, SOURCIFY_MAX_RETRIES, SOURCIFY_RETRY_DELAY, SOURCIFY_RATE_LIMIT, SOURCIFY_RATE_PERIOD,
+// SOURCIFY_TIMEOUT and SOURCIFY_OUTPUT environment variables, flags taking precedence.
+//
+// The exit code tells why a command failed:
+//
+//	0  success
+//	1  unexpected error
+//	2  invalid usage
+//	3  contract or file not found
+//	4  request rejected by Sourcify
+//	5  verification failed
+//	6  Sourcify unavailable
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/unpackdev/sourcify-go"
+)
+
+// config holds the global flags.
+type config struct {
+	baseURL    string
+	maxRetries int
+	retryDelay time.Duration
+	rateLimit  int
+	ratePeriod time.Duration
+	timeout    time.Duration
+	output     string
+}
+
+// client creates the Sourcify client configured by the global flags.
+func (c *config) client(ctx context.Context) *sourcify.Client {
+	options := []sourcify.ClientOption{
+		sourcify.WithBaseURL(c.baseURL),
+		sourcify.WithHTTPClient(&http.Client{Timeout: c.timeout, Transport: &contextTransport{ctx: ctx, base: http.DefaultTransport}}),
+		sourcify.WithRetryOptions(
+			sourcify.WithMaxRetries(c.maxRetries),
+			sourcify.WithDelay(c.retryDelay),
+		),
+	}
+	if c.rateLimit > 0 {
+		options = append(options, sourcify.WithRateLimit(c.rateLimit, c.ratePeriod))
+	}
+	return sourcify.NewClient(options...)
+}
+
+// contextTransport sends every request with the context of the command line, so that an interrupt aborts the
+// requests in flight.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+// RoundTrip sends the request with the context of the transport.
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line and returns the exit code.
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer, getenv func(string) string) int {
+	env := &environment{getenv: getenv}
+
+	var cfg config
+	flags := flag.NewFlagSet("sourcify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&cfg.baseURL, "base-url", env.string("SOURCIFY_BASE_URL", "https://sourcify.dev/server"), "base URL of the Sourcify API [SOURCIFY_BASE_URL]")
+	flags.IntVar(&cfg.maxRetries, "retries", env.int("SOURCIFY_MAX_RETRIES", 3), "maximum number of retries of failed requests [SOURCIFY_MAX_RETRIES]")
+	flags.DurationVar(&cfg.retryDelay, "retry-delay", env.duration("SOURCIFY_RETRY_DELAY", time.Second), "delay between retries [SOURCIFY_RETRY_DELAY]")
+	flags.IntVar(&cfg.rateLimit, "rate-limit", env.int("SOURCIFY_RATE_LIMIT", 0), "maximum number of requests per rate period, 0 for no limit [SOURCIFY_RATE_LIMIT]")
+	flags.DurationVar(&cfg.ratePeriod, "rate-period", env.duration("SOURCIFY_RATE_PERIOD", time.Second), "period of the rate limit [SOURCIFY_RATE_PERIOD]")
+	flags.DurationVar(&cfg.timeout, "timeout", env.duration("SOURCIFY_TIMEOUT", 30*time.Second), "timeout of each request [SOURCIFY_TIMEOUT]")
+	flags.StringVar(&cfg.output, "output", env.string("SOURCIFY_OUTPUT", formatJSON), "output format: json, yaml or table [SOURCIFY_OUTPUT]")
+	flags.Usage = func() { printUsage(flags) }
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if env.err != nil {
+		fmt.Fprintf(stderr, "sourcify: %s\n", env.err)
+		return exitUsage
+	}
+
+	if err := validateFormat(cfg.output); err != nil {
+		fmt.Fprintf(stderr, "sourcify: %s\n", err)
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		printUsage(flags)
+		return exitUsage
+	}
+
+	cmd, ok := lookupCommand(flags.Arg(0))
+	if !ok {
+		fmt.Fprintf(stderr, "sourcify: unknown command %q\n", flags.Arg(0))
+		printUsage(flags)
+		return exitUsage
+	}
+
+	result, err := cmd.run(ctx, &commandContext{client: cfg.client(ctx), stderr: stderr}, flags.Args()[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "sourcify %s: %s\n", cmd.name, err)
+		return exitCode(err)
+	}
+
+	if err := writeResult(stdout, cfg.output, result); err != nil {
+		fmt.Fprintf(stderr, "sourcify %s: %s\n", cmd.name, err)
+		return exitError
+	}
+	return exitOK
+}
+
+// printUsage prints the usage of the command line with the list of commands and the global flags.
+func printUsage(flags *flag.FlagSet) {
+	w := flags.Output()
+	fmt.Fprintf(w, "Usage: sourcify [flags] <command> [command flags] [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun `sourcify <command> -h` for the flags of a command.\n\nFlags:\n")
+	flags.PrintDefaults()
+}
+
+// environment reads the defaults of the global flags from environment variables, keeping the first invalid one.
+type environment struct {
+	getenv func(string) string
+	err    error
+}
+
+func (e *environment) string(key string, fallback string) string {
+	if value := e.getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (e *environment) int(key string, fallback int) int {
+	value := e.getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	toReturn, err := strconv.Atoi(value)
+	if err != nil && e.err == nil {
+		e.err = fmt.Errorf("invalid %s: %q is not an integer", key, value)
+	}
+	return toReturn
+}
+
+func (e *environment) duration(key string, fallback time.Duration) time.Duration {
+	value := e.getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	toReturn, err := time.ParseDuration(value)
+	if err != nil && e.err == nil {
+		e.err = fmt.Errorf("invalid %s: %q is not a duration", key, value)
+	}
+	return toReturn
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unpackdev/sourcify-go"
+	"github.com/unpackdev/sourcify-go/sourcifytest"
+)
+
+const usdt = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+
+// runCommand runs the command line against the fake server and returns the exit code and outputs.
+func runCommand(t *testing.T, server *sourcifytest.Server, env map[string]string, args ...string) (int, string, string) {
+	getenv := func(key string) string {
+		if key == "SOURCIFY_BASE_URL" {
+			return server.URL
+		}
+		return env[key]
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), append([]string{"-retries", "0"}, args...), &stdout, &stderr, getenv)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunOutputs(t *testing.T) {
+	server := sourcifytest.NewServer(t, sourcifytest.WithFixtures("../../testdata"))
+
+	code, stdout, stderr := runCommand(t, server, nil, "contract", "-address", usdt, "-fields", "compilation")
+	require.Equal(t, exitOK, code, stderr)
+
+	var contract sourcify.ContractResponse
+	require.NoError(t, json.Unmarshal([]byte(stdout), &contract))
+	assert.Equal(t, "1817159", contract.MatchID)
+	assert.Equal(t, "TetherToken", contract.Compilation.Name)
+
+	code, stdout, stderr = runCommand(t, server, nil, "-output", "table", "contract", "-address", usdt, "-fields", "compilation")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Regexp(t, `(?m)^FIELD\s+VALUE$`, stdout)
+	assert.Regexp(t, `(?m)^matchId\s+1817159$`, stdout)
+	assert.Regexp(t, `(?m)^compiler\s+solc 0\.4\.18\+commit\.9cf6e910$`, stdout)
+
+	code, stdout, stderr = runCommand(t, server, map[string]string{"SOURCIFY_OUTPUT": "yaml"}, "contracts", "-chain", "1")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "results:\n  - address: "+usdt+"\n")
+	assert.Contains(t, stdout, `matchId: "1817159"`)
+}
+
+func TestRunCommands(t *testing.T) {
+	server := sourcifytest.NewServer(t, sourcifytest.WithFixtures("../../testdata"))
+
+	code, stdout, _ := runCommand(t, server, nil, "health")
+	assert.Equal(t, exitOK, code)
+	assert.JSONEq(t, `{"healthy": true}`, stdout)
+
+	code, stdout, _ = runCommand(t, server, nil, "-output", "table", "chains", "-supported")
+	assert.Equal(t, exitOK, code)
+	assert.Regexp(t, `(?m)^1\s+Chain 1\s+true\s+false$`, stdout)
+
+	code, stdout, _ = runCommand(t, server, nil, "-output", "table", "addresses")
+	assert.Equal(t, exitOK, code)
+	assert.Regexp(t, `(?m)^`+usdt+`\s+partial$`, stdout)
+
+	code, stdout, _ = runCommand(t, server, nil, "-output", "table", "check", "-all", "-chains", "1,10", usdt)
+	assert.Equal(t, exitOK, code)
+	assert.Regexp(t, `(?m)^`+usdt+`\s+partial\s+1$`, stdout)
+
+	code, stdout, _ = runCommand(t, server, nil, "-output", "table", "tree", "-address", usdt)
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "/repository/contracts/partial_match/1/"+usdt+"/sources/TetherToken.sol")
+
+	code, stdout, _ = runCommand(t, server, nil, "-output", "table", "files", "-address", usdt)
+	assert.Equal(t, exitOK, code)
+	assert.Regexp(t, `(?m)^partial\s+TetherToken\.sol\s+\d+`, stdout)
+
+	code, stdout, _ = runCommand(t, server, nil, "source", "-address", usdt)
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "contract TetherToken is Pausable, StandardToken, BlackList {")
+
+	code, stdout, _ = runCommand(t, server, nil, "metadata", "-address", usdt, "-match", "partial")
+	assert.Equal(t, exitOK, code)
+	var metadata sourcify.Metadata
+	require.NoError(t, json.Unmarshal([]byte(stdout), &metadata))
+	assert.Equal(t, "0.4.18+commit.9cf6e910", metadata.Compiler.Version)
+
+	dir := t.TempDir()
+	code, _, stderr := runCommand(t, server, nil, "export", "-address", usdt, "-dir", dir, "-layout", "foundry")
+	require.Equal(t, exitOK, code, stderr)
+	assert.FileExists(t, filepath.Join(dir, "src", "TetherToken.sol"))
+
+	exported, err := os.ReadFile(filepath.Join(dir, sourcify.ExportMetadataFile))
+	require.NoError(t, err)
+	original, err := sourcify.GetContractMetadataAsBytes(sourcify.NewClient(sourcify.WithBaseURL(server.URL)), 1, common.HexToAddress(usdt), sourcify.MethodMatchTypePartial)
+	require.NoError(t, err)
+	assert.Equal(t, original, exported)
+
+	archive := filepath.Join(t.TempDir(), "usdt.zip")
+	code, _, stderr = runCommand(t, server, nil, "export", "-address", usdt, "-archive", archive)
+	require.Equal(t, exitOK, code, stderr)
+	assert.FileExists(t, archive)
+}
+
+func TestRunExitCodes(t *testing.T) {
+	server := sourcifytest.NewServer(t, sourcifytest.WithFixtures("../../testdata"))
+	unknown := "0x00000000000000000000000000000000000000aa"
+
+	for _, test := range []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "no command", args: nil, code: exitUsage},
+		{name: "unknown command", args: []string{"deploy"}, code: exitUsage},
+		{name: "invalid output", args: []string{"-output", "xml", "health"}, code: exitUsage},
+		{name: "missing address", args: []string{"contract"}, code: exitUsage},
+		{name: "invalid match", args: []string{"tree", "-address", usdt, "-match", "exact"}, code: exitUsage},
+		{name: "unknown flag", args: []string{"health", "-verbose"}, code: exitUsage},
+		{name: "help", args: []string{"contract", "-h"}, code: exitOK},
+		{name: "contract not found", args: []string{"contract", "-address", unknown}, code: exitNotFound},
+		{name: "files not found", args: []string{"files", "-address", unknown}, code: exitNotFound},
+		{name: "source file not found", args: []string{"source", "-address", usdt, "-file", "Missing.sol"}, code: exitNotFound},
+		{name: "fields and omit", args: []string{"contract", "-address", usdt, "-fields", "abi", "-omit", "sources"}, code: exitRejected},
+		{name: "verify without input", args: []string{"verify", "-address", usdt}, code: exitUsage},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			code, _, stderr := runCommand(t, server, nil, test.args...)
+			assert.Equal(t, test.code, code, stderr)
+		})
+	}
+
+	server.FailNext(1, 503)
+	code, _, _ := runCommand(t, server, nil, "chains")
+	assert.Equal(t, exitUnavailable, code)
+
+	code, _, stderr := runCommand(t, server, map[string]string{"SOURCIFY_MAX_RETRIES": "many"}, "health")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "SOURCIFY_MAX_RETRIES")
+}
+
+func TestRunVerifyFromMetadata(t *testing.T) {
+	server := sourcifytest.NewServer(t)
+
+	dir := t.TempDir()
+	metadata := `{"language":"Solidity","compiler":{"version":"0.8.19+commit.7dd6d404"},"sources":{"contracts/T.sol":{"keccak256":"0x00"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "metadata.json"), []byte(metadata), 0644))
+
+	request, err := metadataRequest(filepath.Join(dir, "metadata.json"), "")
+	assert.ErrorContains(t, err, "failed to read source contracts/T.sol")
+	assert.Nil(t, request)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "contracts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "contracts", "T.sol"), []byte("contract T {}"), 0644))
+
+	request, err = metadataRequest(filepath.Join(dir, "metadata.json"), "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"contracts/T.sol": "contract T {}"}, request.Sources)
+
+	// The fake server does not implement verification.
+	code, _, stderr := runCommand(t, server, nil, "verify", "-address", usdt, "-metadata", filepath.Join(dir, "metadata.json"))
+	assert.NotEqual(t, exitOK, code)
+	assert.True(t, strings.HasPrefix(stderr, "sourcify verify: "), stderr)
+}
+
+func TestRunVerifyJob(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/verify/done":
+			_, _ = w.Write([]byte(`{"isJobCompleted":true,"verificationId":"done","jobStartTime":"2025-01-01T00:00:00Z","contract":{"match":"exact_match","chainId":"1","address":"0x0000000000000000000000000000000000000001"}}`))
+		case "/v2/verify/failed":
+			_, _ = w.Write([]byte(`{"isJobCompleted":true,"verificationId":"failed","jobStartTime":"2025-01-01T00:00:00Z","contract":{"match":null,"chainId":"1","address":"0x0000000000000000000000000000000000000001"},"error":{"customCode":"no_match","message":"The onchain and recompiled bytecodes don't match.","errorId":"a7d1f1a4-5b6c-4d2e-8f9a-0b1c2d3e4f5a"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+
+	getenv := func(key string) string {
+		if key == "SOURCIFY_BASE_URL" {
+			return mockServer.URL
+		}
+		return ""
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-output", "table", "verify", "-job", "done"}, &stdout, &stderr, getenv)
+	assert.Equal(t, exitOK, code, stderr.String())
+	assert.Regexp(t, `(?m)^done\s+true\s+exact_match$`, stdout.String())
+
+	// A job that completed with an error fails the command even without -wait.
+	stderr.Reset()
+	code = run(context.Background(), []string{"verify", "-job", "failed"}, &stdout, &stderr, getenv)
+	assert.Equal(t, exitVerificationFailed, code)
+	assert.Contains(t, stderr.String(), "no_match")
+}
+
+func TestRunCancelled(t *testing.T) {
+	server := sourcifytest.NewServer(t, sourcifytest.WithFixtures("../../testdata"))
+	getenv := func(key string) string {
+		if key == "SOURCIFY_BASE_URL" {
+			return server.URL
+		}
+		return ""
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var stdout, stderr bytes.Buffer
+	code := run(ctx, []string{"-retry-delay", "1h", "contract", "-address", usdt}, &stdout, &stderr, getenv)
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr.String(), context.Canceled.Error())
+	assert.Empty(t, stdout.String())
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-json"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+	formatTable = "table"
+)
+
+// result represents the output of a command.
+type result struct {
+	value  any        // Printed as JSON or YAML.
+	header []string   // Columns of the table output.
+	rows   [][]string // Rows of the table output.
+	raw    []byte     // Printed as is regardless of the format, e.g. source code.
+}
+
+// validateFormat checks the output format.
+func validateFormat(format string) error {
+	switch format {
+	case formatJSON, formatYAML, formatTable:
+		return nil
+	default:
+		return usagef("invalid output format %q, expected json, yaml or table", format)
+	}
+}
+
+// writeResult prints the result in the format.
+func writeResult(w io.Writer, format string, r *result) error {
+	if r == nil {
+		return nil
+	}
+	if r.raw != nil {
+		_, err := w.Write(r.raw)
+		return err
+	}
+
+	switch format {
+	case formatYAML:
+		return writeYAML(w, r.value)
+	case formatTable:
+		return writeTable(w, r.header, r.rows)
+	default:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r.value)
+	}
+}
+
+// writeYAML prints v as YAML. The value goes through its JSON encoding, which is valid YAML, so the field names and
+// their order follow the JSON tags of the API types.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("failed to convert to YAML: %w", err)
+	}
+	blockStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// blockStyle resets the flow style inherited from JSON so the YAML is printed in block style.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// writeTable prints the rows aligned in columns under the header.
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(header) > 0 {
+		fmt.Fprintln(table, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+	return table.Flush()
+}
@@ -0,0 +1,258 @@
+package sourcify
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// evmVersions lists the EVM versions known to the compiler in chronological order.
+	evmVersions = []string{
+		"homestead", "tangerineWhistle", "spuriousDragon", "byzantium", "constantinople", "petersburg",
+		"istanbul", "berlin", "london", "paris", "shanghai", "cancun", "prague", "osaka",
+	}
+
+	// defaultEVMVersions lists the compiler releases that changed the default EVM version, in ascending order.
+	defaultEVMVersions = []struct {
+		since      CompilerVersion
+		evmVersion string
+	}{
+		{since: CompilerVersion{Minor: 4, Patch: 21}, evmVersion: "byzantium"},
+		{since: CompilerVersion{Minor: 5, Patch: 5}, evmVersion: "petersburg"},
+		{since: CompilerVersion{Minor: 5, Patch: 14}, evmVersion: "istanbul"},
+		{since: CompilerVersion{Minor: 8, Patch: 5}, evmVersion: "berlin"},
+		{since: CompilerVersion{Minor: 8, Patch: 7}, evmVersion: "london"},
+		{since: CompilerVersion{Minor: 8, Patch: 18}, evmVersion: "paris"},
+		{since: CompilerVersion{Minor: 8, Patch: 20}, evmVersion: "shanghai"},
+		{since: CompilerVersion{Minor: 8, Patch: 25}, evmVersion: "cancun"},
+		{since: CompilerVersion{Minor: 8, Patch: 30}, evmVersion: "prague"},
+	}
+
+	// bugSeverities lists the severities used by the compiler bug list from lowest to highest.
+	bugSeverities = []string{"very low", "low", "low/medium", "medium", "medium/high", "high"}
+
+	// abiCoderPragma matches pragmas selecting the ABI coder, e.g. `pragma abicoder v2;` or
+	// `pragma experimental ABIEncoderV2;`.
+	abiCoderPragma = regexp.MustCompile(`\bpragma\s+(?:abicoder\s+(v1|v2)|experimental\s+(ABIEncoderV2))\s*;`)
+)
+
+// BugContext represents the compiler configuration of a contract that determines which bugs can be triggered.
+type BugContext struct {
+	Compiler     CompilerVersion `json:"compiler"`
+	Optimizer    bool            `json:"optimizer"`    // Whether the legacy optimizer was enabled.
+	YulOptimizer bool            `json:"yulOptimizer"` // Whether the Yul optimizer was enabled, explicitly or by default.
+	ViaIR        bool            `json:"viaIR"`        // Whether the IR based code generator was used.
+	ABIEncoderV2 bool            `json:"abiEncoderV2"` // Whether any source uses the ABI coder v2, explicitly or by default.
+	EVMVersion   string          `json:"evmVersion"`   // Target EVM version, the compiler default when not set explicitly.
+}
+
+// CompilerBugReport represents the documented compiler bugs that potentially affect a verified contract.
+type CompilerBugReport struct {
+	Address string        `json:"address,omitempty"`
+	ChainID string        `json:"chainId,omitempty"`
+	Context BugContext    `json:"context"`
+	Bugs    []CompilerBug `json:"bugs"` // Bugs whose version range and conditions match, sorted by descending severity.
+}
+
+// HighestSeverity returns the highest severity among the reported bugs, or an empty string when there are none.
+func (r *CompilerBugReport) HighestSeverity() string {
+	if len(r.Bugs) == 0 {
+		return ""
+	}
+	return r.Bugs[0].Severity
+}
+
+// BySeverity groups the reported bugs by severity.
+func (r *CompilerBugReport) BySeverity() map[string][]CompilerBug {
+	toReturn := make(map[string][]CompilerBug)
+	for _, bug := range r.Bugs {
+		toReturn[bug.Severity] = append(toReturn[bug.Severity], bug)
+	}
+	return toReturn
+}
+
+// NewBugContext derives the bug context from the compiler version and settings in the metadata. The ABI coder
+// is detected from the pragmas of the sources, and the EVM version and Yul optimizer fall back to the defaults
+// of the compiler version when they are not set explicitly.
+func NewBugContext(metadata *Metadata, sources Sources) (*BugContext, error) {
+	version, err := ParseCompilerVersion(metadata.Compiler.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := metadata.Settings
+	toReturn := &BugContext{
+		Compiler:   *version,
+		Optimizer:  settings.Optimizer.Enabled,
+		ViaIR:      settings.ViaIR,
+		EVMVersion: settings.EvmVersion,
+	}
+
+	// The Yul optimizer is enabled together with the optimizer since 0.6.0 unless switched off in the details.
+	toReturn.YulOptimizer = settings.Optimizer.Enabled && !version.LessThan(CompilerVersion{Minor: 6})
+	if details := settings.Optimizer.Details; details != nil && details.Yul != nil {
+		toReturn.YulOptimizer = *details.Yul
+	}
+
+	if toReturn.EVMVersion == "" {
+		toReturn.EVMVersion = defaultEVMVersion(*version)
+	}
+
+	// The ABI coder v2 is the default since 0.8.0 for every source not opting out with `pragma abicoder v1`.
+	defaultV2 := !version.LessThan(CompilerVersion{Minor: 8})
+	for _, source := range sources {
+		usesV2 := defaultV2
+		for _, match := range abiCoderPragma.FindAllStringSubmatch(maskComments(source.Content), -1) {
+			usesV2 = match[1] == "v2" || match[2] != ""
+		}
+		if usesV2 {
+			toReturn.ABIEncoderV2 = true
+			break
+		}
+	}
+
+	return toReturn, nil
+}
+
+// Triggered reports whether the bug affects the compiler version of the context and its conditions are met.
+// Unknown EVM versions are assumed to meet the EVM version condition.
+func (b CompilerBug) Triggered(context BugContext) bool {
+	if !b.Affects(context.Compiler) {
+		return false
+	}
+
+	conditions := b.Conditions
+	if conditions == nil {
+		return true
+	}
+
+	for _, condition := range []struct {
+		required *bool
+		actual   bool
+	}{
+		{required: conditions.Optimizer, actual: context.Optimizer},
+		{required: conditions.YulOptimizer, actual: context.YulOptimizer},
+		{required: conditions.ABIEncoderV2, actual: context.ABIEncoderV2},
+		{required: conditions.ViaIR, actual: context.ViaIR},
+	} {
+		if condition.required != nil && *condition.required != condition.actual {
+			return false
+		}
+	}
+
+	return conditions.EVMVersion == "" || matchEVMVersion(conditions.EVMVersion, context.EVMVersion)
+}
+
+// CheckCompilerBugs returns the bugs triggered in the context, sorted by descending severity and then by name.
+func CheckCompilerBugs(context BugContext, bugs []CompilerBug) []CompilerBug {
+	toReturn := []CompilerBug{}
+	for _, bug := range bugs {
+		if bug.Triggered(context) {
+			toReturn = append(toReturn, bug)
+		}
+	}
+
+	sort.SliceStable(toReturn, func(i, j int) bool {
+		ri, rj := bugSeverityRank(toReturn[i].Severity), bugSeverityRank(toReturn[j].Severity)
+		if ri != rj {
+			return ri > rj
+		}
+		return toReturn[i].Name < toReturn[j].Name
+	})
+	return toReturn
+}
+
+// CompilerBugReport lists the embedded compiler bugs that potentially affect the contract, based on its compiler
+// version, optimizer, viaIR and EVM version settings and the ABI coder used by its sources.
+func (c *ContractResponse) CompilerBugReport() (*CompilerBugReport, error) {
+	metadata := c.Metadata
+	if metadata.Compiler.Version == "" {
+		metadata.Compiler.Version = c.Compilation.CompilerVersion
+	}
+
+	context, err := NewBugContext(&metadata, c.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	bugs, err := CompilerBugs()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompilerBugReport{
+		Address: c.Address,
+		ChainID: c.ChainID,
+		Context: *context,
+		Bugs:    CheckCompilerBugs(*context, bugs),
+	}, nil
+}
+
+// GetCompilerBugReport fetches the metadata and sources of a verified contract and reports the compiler bugs
+// that potentially affect it.
+func GetCompilerBugReport(client *Client, chainId int, address common.Address) (*CompilerBugReport, error) {
+	contract, err := GetContractByChainIdAndAddress(client, chainId, address, []string{"compilation", "metadata", "sources"}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract %s: %w", address.Hex(), err)
+	}
+	return contract.CompilerBugReport()
+}
+
+// defaultEVMVersion returns the EVM version targeted by the compiler version when none is set explicitly.
+func defaultEVMVersion(version CompilerVersion) string {
+	toReturn := "homestead"
+	for _, entry := range defaultEVMVersions {
+		if version.LessThan(entry.since) {
+			break
+		}
+		toReturn = entry.evmVersion
+	}
+	return toReturn
+}
+
+// matchEVMVersion reports whether the EVM version meets a condition such as `>=constantinople`.
+func matchEVMVersion(condition string, evmVersion string) bool {
+	operator := strings.TrimRight(condition, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	target := evmVersionIndex(strings.TrimPrefix(condition, operator))
+	actual := evmVersionIndex(evmVersion)
+	if target < 0 || actual < 0 {
+		return true
+	}
+
+	switch operator {
+	case ">=":
+		return actual >= target
+	case ">":
+		return actual > target
+	case "<=":
+		return actual <= target
+	case "<":
+		return actual < target
+	default:
+		return actual == target
+	}
+}
+
+// evmVersionIndex returns the position of the EVM version in evmVersions, or -1 when it is unknown.
+func evmVersionIndex(evmVersion string) int {
+	for i, candidate := range evmVersions {
+		if strings.EqualFold(candidate, evmVersion) {
+			return i
+		}
+	}
+	return -1
+}
+
+// bugSeverityRank returns the position of the severity in bugSeverities, or -1 when it is unknown.
+func bugSeverityRank(severity string) int {
+	for i, candidate := range bugSeverities {
+		if candidate == severity {
+			return i
+		}
+	}
+	return -1
+}
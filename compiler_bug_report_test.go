@@ -0,0 +1,113 @@
+package sourcify
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBugContext(t *testing.T) {
+	yulDisabled := false
+	metadata := &Metadata{
+		Compiler: Compiler{Version: "0.8.19+commit.7dd6d404"},
+		Settings: Settings{Optimizer: Optimizer{Enabled: true, Runs: 200}},
+	}
+
+	context, err := NewBugContext(metadata, Sources{"A.sol": {Content: "pragma solidity ^0.8.0;\ncontract A {}"}})
+	require.NoError(t, err)
+	assert.True(t, context.Optimizer)
+	assert.True(t, context.YulOptimizer)
+	assert.True(t, context.ABIEncoderV2)
+	assert.Equal(t, "paris", context.EVMVersion)
+
+	metadata.Settings.Optimizer.Details = &OptimizerDetails{Yul: &yulDisabled}
+	metadata.Settings.EvmVersion = "london"
+	context, err = NewBugContext(metadata, Sources{"A.sol": {Content: "pragma solidity ^0.8.0;\npragma abicoder v1;\ncontract A {}"}})
+	require.NoError(t, err)
+	assert.False(t, context.YulOptimizer)
+	assert.False(t, context.ABIEncoderV2)
+	assert.Equal(t, "london", context.EVMVersion)
+
+	metadata = &Metadata{Compiler: Compiler{Version: "0.5.17+commit.d19bba13"}}
+	context, err = NewBugContext(metadata, Sources{
+		"A.sol": {Content: "pragma solidity ^0.5.0;\n// pragma experimental ABIEncoderV2;\ncontract A {}"},
+		"B.sol": {Content: "pragma solidity ^0.5.0;\npragma experimental ABIEncoderV2;\ncontract B {}"},
+	})
+	require.NoError(t, err)
+	assert.False(t, context.YulOptimizer)
+	assert.True(t, context.ABIEncoderV2)
+	assert.Equal(t, "istanbul", context.EVMVersion)
+
+	_, err = NewBugContext(&Metadata{}, nil)
+	assert.ErrorIs(t, err, ErrInvalidCompilerVersion)
+}
+
+func TestCompilerBugTriggered(t *testing.T) {
+	enabled := true
+	bug := CompilerBug{
+		Name:       "Example",
+		Introduced: "0.8.13",
+		Fixed:      "0.8.17",
+		Severity:   "medium",
+		Conditions: &BugConditions{YulOptimizer: &enabled, EVMVersion: ">=constantinople"},
+	}
+
+	context := BugContext{Compiler: CompilerVersion{Minor: 8, Patch: 15}, YulOptimizer: true, EVMVersion: "london"}
+	assert.True(t, bug.Triggered(context))
+
+	context.EVMVersion = "byzantium"
+	assert.False(t, bug.Triggered(context))
+
+	context.EVMVersion = "london"
+	context.YulOptimizer = false
+	assert.False(t, bug.Triggered(context))
+
+	context.YulOptimizer = true
+	context.Compiler.Patch = 17
+	assert.False(t, bug.Triggered(context))
+}
+
+func TestMatchEVMVersion(t *testing.T) {
+	assert.True(t, matchEVMVersion(">=constantinople", "petersburg"))
+	assert.False(t, matchEVMVersion(">constantinople", "constantinople"))
+	assert.True(t, matchEVMVersion("<istanbul", "byzantium"))
+	assert.True(t, matchEVMVersion("=london", "london"))
+	assert.True(t, matchEVMVersion(">=unknown", "london"))
+}
+
+func TestCheckCompilerBugs(t *testing.T) {
+	bugs := []CompilerBug{
+		{Name: "B", Fixed: "0.9.0", Severity: "low"},
+		{Name: "A", Fixed: "0.9.0", Severity: "low"},
+		{Name: "C", Fixed: "0.9.0", Severity: "high"},
+		{Name: "D", Fixed: "0.8.0", Severity: "high"},
+	}
+
+	report := &CompilerBugReport{Bugs: CheckCompilerBugs(BugContext{Compiler: CompilerVersion{Minor: 8, Patch: 1}}, bugs)}
+	require.Len(t, report.Bugs, 3)
+	assert.Equal(t, []string{"C", "A", "B"}, []string{report.Bugs[0].Name, report.Bugs[1].Name, report.Bugs[2].Name})
+	assert.Equal(t, "high", report.HighestSeverity())
+	assert.Len(t, report.BySeverity()["low"], 2)
+
+	assert.Empty(t, (&CompilerBugReport{}).HighestSeverity())
+}
+
+func TestContractCompilerBugReport(t *testing.T) {
+	contract, err := LoadContract(1, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	require.NoError(t, err)
+
+	report, err := contract.CompilerBugReport()
+	require.NoError(t, err)
+	assert.Equal(t, "0.4.18", report.Context.Compiler.Release())
+	assert.False(t, report.Context.ABIEncoderV2)
+	assert.NotEmpty(t, report.Bugs)
+
+	for _, bug := range report.Bugs {
+		assert.True(t, bug.Triggered(report.Context), bug.Name)
+		if bug.Conditions != nil && bug.Conditions.ABIEncoderV2 != nil {
+			assert.False(t, *bug.Conditions.ABIEncoderV2, bug.Name)
+		}
+	}
+}
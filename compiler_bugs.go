@@ -0,0 +1,206 @@
+package sourcify
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+//go:generate sh data/solc/update.sh
+
+var (
+	// embeddedCompilerBugs holds the compiler's bugs.json and, when available, bugs_by_version.json, see
+	// data/solc/SOURCE.md for the version they were taken from.
+	//go:embed data/solc/*.json
+	embeddedCompilerBugs embed.FS
+
+	// compilerBugs holds the parsed embedded bug lists, see CompilerBugs and CompilerBugsByVersion.
+	compilerBugs          []CompilerBug
+	compilerBugsByVersion BugsByVersion
+	compilerBugsErr       error
+	compilerBugsOnce      sync.Once
+)
+
+// BugConditions represents the conditions under which a compiler bug is triggered. Nil fields do not restrict the bug.
+type BugConditions struct {
+	Optimizer    *bool  `json:"optimizer,omitempty"`    // Whether the legacy optimizer has to be enabled.
+	YulOptimizer *bool  `json:"yulOptimizer,omitempty"` // Whether the Yul optimizer has to be enabled.
+	ABIEncoderV2 *bool  `json:"ABIEncoderV2,omitempty"` // Whether the ABI coder v2 has to be used.
+	ViaIR        *bool  `json:"viaIR,omitempty"`        // Whether the IR based code generator has to be used.
+	EVMVersion   string `json:"evmVersion,omitempty"`   // EVM version condition, e.g. `>=constantinople`.
+}
+
+// CompilerBug represents a documented Solidity compiler bug, in the format of the compiler's bugs.json.
+type CompilerBug struct {
+	UID         string         `json:"uid,omitempty"`
+	Name        string         `json:"name"`
+	Summary     string         `json:"summary"`
+	Description string         `json:"description,omitempty"`
+	Link        string         `json:"link,omitempty"`
+	Introduced  string         `json:"introduced,omitempty"` // First affected version, empty when all earlier versions are affected.
+	Fixed       string         `json:"fixed,omitempty"`      // First version with the fix, empty when not fixed yet.
+	Severity    string         `json:"severity"`
+	Conditions  *BugConditions `json:"conditions,omitempty"`
+}
+
+// CompilerVersionBugs represents an entry of the compiler's bugs_by_version.json.
+type CompilerVersionBugs struct {
+	Bugs     []string `json:"bugs"`     // Names of the bugs affecting the version.
+	Released string   `json:"released"` // Release date, e.g. `2023-02-22`.
+}
+
+// BugsByVersion maps released compiler versions, e.g. `0.8.19`, to the bugs affecting them, in the format
+// of the compiler's docs/bugs_by_version.json.
+type BugsByVersion map[string]CompilerVersionBugs
+
+// CompilerBugs returns the embedded list of documented compiler bugs, taken from the compiler's docs/bugs.json at
+// the version noted in data/solc/SOURCE.md. Use LoadCompilerBugs to work with another version of the list.
+func CompilerBugs() ([]CompilerBug, error) {
+	loadEmbeddedCompilerBugs()
+	return compilerBugs, compilerBugsErr
+}
+
+// CompilerBugsByVersion returns the embedded bugs affecting each released compiler version, taken from the
+// compiler's docs/bugs_by_version.json. It is nil when the list is not embedded, in which case the affected versions
+// are derived from the ranges of CompilerBugs.
+func CompilerBugsByVersion() (BugsByVersion, error) {
+	loadEmbeddedCompilerBugs()
+	return compilerBugsByVersion, compilerBugsErr
+}
+
+// loadEmbeddedCompilerBugs parses the embedded bug lists once.
+func loadEmbeddedCompilerBugs() {
+	compilerBugsOnce.Do(func() {
+		data, err := embeddedCompilerBugs.ReadFile("data/solc/bugs.json")
+		if err != nil {
+			compilerBugsErr = err
+			return
+		}
+		if compilerBugs, compilerBugsErr = parseCompilerBugs(data); compilerBugsErr != nil {
+			return
+		}
+
+		data, err = embeddedCompilerBugs.ReadFile("data/solc/bugs_by_version.json")
+		if errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		if err != nil {
+			compilerBugsErr = err
+			return
+		}
+		compilerBugsByVersion, compilerBugsErr = parseCompilerBugsByVersion(data)
+	})
+}
+
+// LoadCompilerBugs reads a bug list in the format of the compiler's docs/bugs.json.
+func LoadCompilerBugs(r io.Reader) ([]CompilerBug, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compiler bugs: %w", err)
+	}
+	return parseCompilerBugs(data)
+}
+
+// LoadCompilerBugsByVersion reads a list of the bugs affecting each version in the format of the compiler's
+// docs/bugs_by_version.json.
+func LoadCompilerBugsByVersion(r io.Reader) (BugsByVersion, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compiler bugs by version: %w", err)
+	}
+	return parseCompilerBugsByVersion(data)
+}
+
+// Affects reports whether the compiler version lies in the range of versions affected by the bug.
+// The conditions of the bug are not taken into account.
+func (b CompilerBug) Affects(version CompilerVersion) bool {
+	release := CompilerVersion{Major: version.Major, Minor: version.Minor, Patch: version.Patch}
+
+	if b.Introduced != "" {
+		introduced, err := ParseCompilerVersion(b.Introduced)
+		if err != nil || release.LessThan(*introduced) {
+			return false
+		}
+	}
+	if b.Fixed != "" {
+		fixed, err := ParseCompilerVersion(b.Fixed)
+		if err != nil || !release.LessThan(*fixed) {
+			return false
+		}
+	}
+	return true
+}
+
+// KnownBugs returns the embedded compiler bugs affecting the version, regardless of their conditions. Released
+// versions listed in CompilerBugsByVersion use that list, other versions the ranges of the bugs.
+func (v CompilerVersion) KnownBugs() ([]CompilerBug, error) {
+	bugs, err := CompilerBugs()
+	if err != nil {
+		return nil, err
+	}
+	byVersion, err := CompilerBugsByVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := []CompilerBug{}
+	if entry, ok := byVersion[v.Release()]; ok && v.Prerelease == "" {
+		names := make(map[string]bool, len(entry.Bugs))
+		for _, name := range entry.Bugs {
+			names[name] = true
+		}
+		for _, bug := range bugs {
+			if names[bug.Name] {
+				toReturn = append(toReturn, bug)
+			}
+		}
+		return toReturn, nil
+	}
+
+	for _, bug := range bugs {
+		if bug.Affects(v) {
+			toReturn = append(toReturn, bug)
+		}
+	}
+	return toReturn, nil
+}
+
+// parseCompilerBugs decodes a bug list and validates its version ranges.
+func parseCompilerBugs(data []byte) ([]CompilerBug, error) {
+	var toReturn []CompilerBug
+	if err := json.Unmarshal(data, &toReturn); err != nil {
+		return nil, fmt.Errorf("failed to decode compiler bugs: %w", err)
+	}
+
+	for _, bug := range toReturn {
+		for _, version := range []string{bug.Introduced, bug.Fixed} {
+			if version == "" {
+				continue
+			}
+			if _, err := ParseCompilerVersion(version); err != nil {
+				return nil, fmt.Errorf("compiler bug %s: %w", bug.Name, err)
+			}
+		}
+	}
+	return toReturn, nil
+}
+
+// parseCompilerBugsByVersion decodes a list of the bugs affecting each version and validates its versions.
+func parseCompilerBugsByVersion(data []byte) (BugsByVersion, error) {
+	var toReturn BugsByVersion
+	if err := json.Unmarshal(data, &toReturn); err != nil {
+		return nil, fmt.Errorf("failed to decode compiler bugs by version: %w", err)
+	}
+
+	for version := range toReturn {
+		if _, err := ParseCompilerVersion(version); err != nil {
+			return nil, fmt.Errorf("compiler bugs by version: %w", err)
+		}
+	}
+	return toReturn, nil
+}
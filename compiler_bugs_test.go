@@ -0,0 +1,71 @@
+package sourcify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompilerBugs(t *testing.T) {
+	bugs, err := CompilerBugs()
+	require.NoError(t, err)
+	require.NotEmpty(t, bugs)
+
+	for _, bug := range bugs {
+		assert.NotEmpty(t, bug.Name)
+		assert.NotEmpty(t, bug.Severity, bug.Name)
+	}
+}
+
+func TestCompilerBugAffects(t *testing.T) {
+	bug := CompilerBug{Name: "Example", Introduced: "0.5.0", Fixed: "0.8.3"}
+
+	assert.False(t, bug.Affects(CompilerVersion{Minor: 4, Patch: 26}))
+	assert.True(t, bug.Affects(CompilerVersion{Minor: 5}))
+	assert.True(t, bug.Affects(CompilerVersion{Minor: 8, Patch: 2, Commit: "661d1103"}))
+	assert.False(t, bug.Affects(CompilerVersion{Minor: 8, Patch: 3}))
+	assert.False(t, bug.Affects(CompilerVersion{Minor: 8, Patch: 3, Prerelease: "nightly.2021.3.1"}))
+
+	unbounded := CompilerBug{Name: "Ancient", Fixed: "0.3.0"}
+	assert.True(t, unbounded.Affects(CompilerVersion{Minor: 1, Patch: 7}))
+}
+
+func TestCompilerVersionKnownBugs(t *testing.T) {
+	recent, err := CompilerVersion{Minor: 8, Patch: 28}.KnownBugs()
+	require.NoError(t, err)
+
+	old, err := CompilerVersion{Minor: 4, Patch: 18}.KnownBugs()
+	require.NoError(t, err)
+	assert.Greater(t, len(old), len(recent))
+
+	names := make([]string, 0, len(old))
+	for _, bug := range old {
+		names = append(names, bug.Name)
+	}
+	assert.Contains(t, names, "DynamicConstructorArgumentsClippedABIV2")
+}
+
+func TestLoadCompilerBugs(t *testing.T) {
+	bugs, err := LoadCompilerBugs(strings.NewReader(`[{"uid":"SOL-2023-1","name":"Example","summary":"s","introduced":"0.8.13","fixed":"0.8.17","severity":"medium","conditions":{"yulOptimizer":true,"evmVersion":">=constantinople"}}]`))
+	require.NoError(t, err)
+	require.Len(t, bugs, 1)
+	require.NotNil(t, bugs[0].Conditions)
+	assert.True(t, *bugs[0].Conditions.YulOptimizer)
+	assert.Nil(t, bugs[0].Conditions.Optimizer)
+	assert.Equal(t, ">=constantinople", bugs[0].Conditions.EVMVersion)
+
+	_, err = LoadCompilerBugs(strings.NewReader(`[{"name":"Broken","fixed":"next"}]`))
+	assert.Error(t, err)
+}
+
+func TestLoadCompilerBugsByVersion(t *testing.T) {
+	byVersion, err := LoadCompilerBugsByVersion(strings.NewReader(`{"0.8.19":{"bugs":["VerbatimInvalidDeduplication"],"released":"2023-02-22"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"VerbatimInvalidDeduplication"}, byVersion["0.8.19"].Bugs)
+	assert.Equal(t, "2023-02-22", byVersion["0.8.19"].Released)
+
+	_, err = LoadCompilerBugsByVersion(strings.NewReader(`{"next":{"bugs":[]}}`))
+	assert.Error(t, err)
+}
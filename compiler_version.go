@@ -0,0 +1,395 @@
+package sourcify
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidCompilerVersion is returned when a compiler version string cannot be parsed.
+	ErrInvalidCompilerVersion = errors.New("invalid compiler version")
+
+	// ErrInvalidVersionConstraint is returned when a pragma version constraint cannot be parsed.
+	ErrInvalidVersionConstraint = errors.New("invalid version constraint")
+)
+
+var (
+	// compilerVersionPattern matches versions such as `v0.8.20-nightly.2023.4.21+commit.7dd6d404.Emscripten.clang`.
+	compilerVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+(?:commit\.([0-9a-fA-F]+))?\.?([0-9A-Za-z.-]*))?$`)
+
+	// constraintOperatorSpacing matches operators separated from their version by whitespace, e.g. `>= 0.6.0`.
+	constraintOperatorSpacing = regexp.MustCompile(`(>=|<=|>|<|=|\^|~)\s+`)
+
+	// solidityPragma matches the version constraint of a `pragma solidity` directive.
+	solidityPragma = regexp.MustCompile(`\bpragma\s+solidity\s+([^;]+);`)
+)
+
+// CompilerVersion represents a parsed Solidity compiler version.
+type CompilerVersion struct {
+	Major      int    `json:"major"`
+	Minor      int    `json:"minor"`
+	Patch      int    `json:"patch"`
+	Prerelease string `json:"prerelease,omitempty"` // Pre-release tag, e.g. `nightly.2023.4.21`.
+	Commit     string `json:"commit,omitempty"`     // Commit hash of the build, e.g. `7dd6d404`.
+	Platform   string `json:"platform,omitempty"`   // Platform suffix of old builds, e.g. `Emscripten.clang`.
+}
+
+// ParseCompilerVersion parses compiler versions as found in metadata and Sourcify responses, such as
+// `0.8.19+commit.7dd6d404`, `v0.4.18+commit.9cf6e910` or `0.8.20-nightly.2023.4.21+commit.b5c11a5b`.
+func ParseCompilerVersion(version string) (*CompilerVersion, error) {
+	match := compilerVersionPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCompilerVersion, version)
+	}
+
+	toReturn := &CompilerVersion{
+		Prerelease: match[4],
+		Commit:     strings.ToLower(match[5]),
+		Platform:   match[6],
+	}
+	toReturn.Major, _ = strconv.Atoi(match[1])
+	toReturn.Minor, _ = strconv.Atoi(match[2])
+	toReturn.Patch, _ = strconv.Atoi(match[3])
+	return toReturn, nil
+}
+
+// CompilerVersionFromCBOR extracts the compiler version embedded in the CBOR auxdata appended to the bytecode.
+// Release builds embed the version as three bytes and pre-release builds as the full version string.
+func CompilerVersionFromCBOR(auxdata []byte) (*CompilerVersion, error) {
+	key := append([]byte{0x64}, "solc"...)
+
+	index := strings.Index(string(auxdata), string(key))
+	if index < 0 {
+		return nil, fmt.Errorf("%w: no solc entry in CBOR auxdata", ErrInvalidCompilerVersion)
+	}
+
+	value := auxdata[index+len(key):]
+	switch {
+	case len(value) >= 4 && value[0] == 0x43:
+		return &CompilerVersion{Major: int(value[1]), Minor: int(value[2]), Patch: int(value[3])}, nil
+	case len(value) >= 1 && value[0] >= 0x60 && value[0] <= 0x77 && len(value) > int(value[0]-0x60):
+		return ParseCompilerVersion(string(value[1 : 1+int(value[0]-0x60)]))
+	case len(value) >= 2 && value[0] == 0x78 && len(value) > 1+int(value[1]):
+		return ParseCompilerVersion(string(value[2 : 2+int(value[1])]))
+	default:
+		return nil, fmt.Errorf("%w: unsupported solc entry in CBOR auxdata", ErrInvalidCompilerVersion)
+	}
+}
+
+// CompilerVersion returns the parsed version of the compiler the contract was verified with.
+func (c *ContractResponse) CompilerVersion() (*CompilerVersion, error) {
+	version := c.Metadata.Compiler.Version
+	if version == "" {
+		version = c.Compilation.CompilerVersion
+	}
+	return ParseCompilerVersion(version)
+}
+
+// Release returns the release part of the version, e.g. `0.8.19`.
+func (v CompilerVersion) Release() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// String returns the version in the long form used by the compiler, e.g. `0.8.19+commit.7dd6d404`.
+func (v CompilerVersion) String() string {
+	toReturn := v.Release()
+	if v.Prerelease != "" {
+		toReturn += "-" + v.Prerelease
+	}
+	if v.Commit != "" {
+		toReturn += "+commit." + v.Commit
+		if v.Platform != "" {
+			toReturn += "." + v.Platform
+		}
+	}
+	return toReturn
+}
+
+// IsNightly reports whether the version is a nightly build.
+func (v CompilerVersion) IsNightly() bool {
+	return strings.HasPrefix(v.Prerelease, "nightly")
+}
+
+// Compare returns -1, 0 or 1 when the version is lower than, equal to or greater than other. Pre-releases are
+// lower than the corresponding release and compared by their dot separated parts, numerically when both parts are
+// numbers, so that `nightly.2023.4.21` is lower than `nightly.2023.10.1`. Build metadata such as the commit is ignored.
+func (v CompilerVersion) Compare(other CompilerVersion) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff != 0 {
+			return sign(diff)
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	default:
+		return comparePrerelease(v.Prerelease, other.Prerelease)
+	}
+}
+
+// comparePrerelease compares pre-release tags by their dot separated parts following semver: numeric parts are
+// compared numerically and are lower than alphanumeric parts, and a tag is lower than the longer tags it prefixes.
+func comparePrerelease(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNumber, aErr := strconv.ParseUint(aParts[i], 10, 64)
+		bNumber, bErr := strconv.ParseUint(bParts[i], 10, 64)
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNumber != bNumber {
+				if aNumber < bNumber {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if diff := strings.Compare(aParts[i], bParts[i]); diff != 0 {
+				return diff
+			}
+		}
+	}
+	return sign(len(aParts) - len(bParts))
+}
+
+// LessThan reports whether the version is lower than other.
+func (v CompilerVersion) LessThan(other CompilerVersion) bool {
+	return v.Compare(other) < 0
+}
+
+// Satisfies reports whether the version satisfies a pragma version constraint such as `^0.8.0`,
+// `>=0.6.0 <0.9.0`, `~0.4.24`, `0.8` or `0.5.0 - 0.7.6 || ^0.8.0`.
+func (v CompilerVersion) Satisfies(constraint string) (bool, error) {
+	ranges, err := parseVersionConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+
+	// Like the compiler itself, only the release is matched against the constraint.
+	release := CompilerVersion{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	for _, comparators := range ranges {
+		satisfied := true
+		for _, comparator := range comparators {
+			if !comparator.matches(release) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PragmaCheck represents the result of checking a `pragma solidity` directive against a compiler version.
+type PragmaCheck struct {
+	File       string `json:"file"`
+	Constraint string `json:"constraint"`
+	Satisfied  bool   `json:"satisfied"`
+}
+
+// CheckPragmas checks the version against every `pragma solidity` directive found in the sources.
+// The checks are sorted by file name.
+func (v CompilerVersion) CheckPragmas(sources Sources) ([]PragmaCheck, error) {
+	toReturn := []PragmaCheck{}
+	for _, name := range sortedSourceNames(sources) {
+		content := sources[name].Content
+		masked := maskComments(content)
+
+		for _, match := range solidityPragma.FindAllStringSubmatchIndex(masked, -1) {
+			constraint := strings.TrimSpace(content[match[2]:match[3]])
+			satisfied, err := v.Satisfies(constraint)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			toReturn = append(toReturn, PragmaCheck{File: name, Constraint: constraint, Satisfied: satisfied})
+		}
+	}
+	return toReturn, nil
+}
+
+// versionComparator represents a single `operator version` condition of a constraint.
+type versionComparator struct {
+	operator string
+	version  CompilerVersion
+}
+
+func (c versionComparator) matches(v CompilerVersion) bool {
+	compared := v.Compare(c.version)
+	switch c.operator {
+	case ">":
+		return compared > 0
+	case ">=":
+		return compared >= 0
+	case "<":
+		return compared < 0
+	case "<=":
+		return compared <= 0
+	default:
+		return compared == 0
+	}
+}
+
+// parseVersionConstraint parses a constraint into alternatives of comparators that must all match.
+func parseVersionConstraint(constraint string) ([][]versionComparator, error) {
+	var toReturn [][]versionComparator
+	for _, alternative := range strings.Split(constraint, "||") {
+		alternative = constraintOperatorSpacing.ReplaceAllString(strings.TrimSpace(alternative), "$1")
+		fields := strings.Fields(alternative)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidVersionConstraint, constraint)
+		}
+
+		var comparators []versionComparator
+		if len(fields) == 3 && fields[1] == "-" {
+			lower, _, err := parsePartialVersion(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			upper, components, err := parsePartialVersion(fields[2])
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, versionComparator{operator: ">=", version: lower})
+			if components < 3 {
+				comparators = append(comparators, versionComparator{operator: "<", version: bumpVersion(upper, components)})
+			} else {
+				comparators = append(comparators, versionComparator{operator: "<=", version: upper})
+			}
+			toReturn = append(toReturn, comparators)
+			continue
+		}
+
+		for _, field := range fields {
+			parsed, err := parseVersionComparator(field)
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, parsed...)
+		}
+		toReturn = append(toReturn, comparators)
+	}
+	return toReturn, nil
+}
+
+// parseVersionComparator expands a single comparator, such as `^0.8.0` or `<=0.8`, into primitive comparators.
+func parseVersionComparator(field string) ([]versionComparator, error) {
+	operator := ""
+	for _, candidate := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(field, candidate) {
+			operator = candidate
+			break
+		}
+	}
+
+	version, components, err := parsePartialVersion(strings.TrimPrefix(field, operator))
+	if err != nil {
+		return nil, err
+	}
+
+	switch operator {
+	case "^":
+		// The left most non zero component is kept, e.g. ^0.8.1 allows 0.8.x and ^1.2.0 allows 1.x.
+		upper := bumpVersion(version, 1)
+		if version.Major == 0 && components > 1 {
+			upper = bumpVersion(version, 2)
+			if version.Minor == 0 && components > 2 {
+				upper = bumpVersion(version, 3)
+			}
+		}
+		return []versionComparator{{operator: ">=", version: version}, {operator: "<", version: upper}}, nil
+	case "~":
+		upper := bumpVersion(version, min(components, 2))
+		return []versionComparator{{operator: ">=", version: version}, {operator: "<", version: upper}}, nil
+	case ">":
+		if components < 3 {
+			return []versionComparator{{operator: ">=", version: bumpVersion(version, components)}}, nil
+		}
+	case "<=":
+		if components < 3 {
+			return []versionComparator{{operator: "<", version: bumpVersion(version, components)}}, nil
+		}
+	case ">=", "<":
+	default:
+		if components < 3 {
+			return []versionComparator{{operator: ">=", version: version}, {operator: "<", version: bumpVersion(version, components)}}, nil
+		}
+		operator = "="
+	}
+
+	return []versionComparator{{operator: operator, version: version}}, nil
+}
+
+// parsePartialVersion parses versions with up to three components, where missing and wildcard (`x`, `*`)
+// components are zero. It returns the number of components that were given explicitly.
+func parsePartialVersion(version string) (CompilerVersion, int, error) {
+	parts := strings.Split(version, ".")
+	if version == "" || len(parts) > 3 {
+		return CompilerVersion{}, 0, fmt.Errorf("%w: %q", ErrInvalidVersionConstraint, version)
+	}
+
+	var numbers [3]int
+	components := 0
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return CompilerVersion{}, 0, fmt.Errorf("%w: %q", ErrInvalidVersionConstraint, version)
+		}
+		numbers[i] = number
+		components++
+	}
+
+	return CompilerVersion{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, components, nil
+}
+
+// bumpVersion increments the given component (1 for major, 2 for minor, 3 for patch) and zeroes the following ones.
+// A version without any component bumps to an unreachable major version.
+func bumpVersion(version CompilerVersion, component int) CompilerVersion {
+	switch component {
+	case 0:
+		return CompilerVersion{Major: int(^uint(0) >> 1)}
+	case 1:
+		return CompilerVersion{Major: version.Major + 1}
+	case 2:
+		return CompilerVersion{Major: version.Major, Minor: version.Minor + 1}
+	default:
+		return CompilerVersion{Major: version.Major, Minor: version.Minor, Patch: version.Patch + 1}
+	}
+}
+
+// sortedSourceNames returns the names of the sources in ascending order.
+func sortedSourceNames(sources Sources) []string {
+	set := make(map[string]bool, len(sources))
+	for name := range sources {
+		set[name] = true
+	}
+	return sortedKeys(set)
+}
+
+// sign returns -1, 0 or 1 depending on the sign of n.
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,148 @@
+package sourcify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompilerVersion(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected CompilerVersion
+		nightly  bool
+	}{
+		{raw: "0.8.19+commit.7dd6d404", expected: CompilerVersion{Major: 0, Minor: 8, Patch: 19, Commit: "7dd6d404"}},
+		{raw: "v0.4.18+commit.9cf6e910", expected: CompilerVersion{Major: 0, Minor: 4, Patch: 18, Commit: "9cf6e910"}},
+		{raw: "0.4.11+commit.68ef5810.Emscripten.clang", expected: CompilerVersion{Major: 0, Minor: 4, Patch: 11, Commit: "68ef5810", Platform: "Emscripten.clang"}},
+		{raw: "0.8.20-nightly.2023.4.21+commit.b5c11a5b", expected: CompilerVersion{Major: 0, Minor: 8, Patch: 20, Prerelease: "nightly.2023.4.21", Commit: "b5c11a5b"}, nightly: true},
+		{raw: "0.8.26", expected: CompilerVersion{Major: 0, Minor: 8, Patch: 26}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			version, err := ParseCompilerVersion(tt.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *version)
+			assert.Equal(t, tt.nightly, version.IsNightly())
+			assert.Equal(t, strings.TrimPrefix(tt.raw, "v"), version.String())
+		})
+	}
+
+	_, err := ParseCompilerVersion("latest")
+	assert.ErrorIs(t, err, ErrInvalidCompilerVersion)
+}
+
+func TestCompilerVersionCompare(t *testing.T) {
+	parse := func(raw string) CompilerVersion {
+		version, err := ParseCompilerVersion(raw)
+		require.NoError(t, err)
+		return *version
+	}
+
+	assert.Equal(t, 0, parse("0.8.19+commit.7dd6d404").Compare(parse("0.8.19")))
+	assert.Equal(t, -1, parse("0.8.9").Compare(parse("0.8.19")))
+	assert.Equal(t, 1, parse("0.10.0").Compare(parse("0.9.9")))
+	assert.Equal(t, -1, parse("0.8.20-nightly.2023.4.21").Compare(parse("0.8.20")))
+	assert.True(t, parse("0.8.20-nightly.2023.4.21").LessThan(parse("0.8.20-nightly.2023.5.1")))
+	assert.True(t, parse("0.8.22-nightly.2023.4.21").LessThan(parse("0.8.22-nightly.2023.10.1")))
+	assert.Equal(t, 1, parse("0.8.22-nightly.2023.10.1").Compare(parse("0.8.22-nightly.2023.9.30")))
+	assert.Equal(t, -1, parse("0.8.22-nightly.2023").Compare(parse("0.8.22-nightly.2023.1.1")))
+	assert.Equal(t, -1, parse("0.8.22-1").Compare(parse("0.8.22-nightly")))
+}
+
+func TestCompilerVersionSatisfies(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		expected   bool
+	}{
+		{version: "0.8.19", constraint: "^0.8.0", expected: true},
+		{version: "0.9.0", constraint: "^0.8.0", expected: false},
+		{version: "0.7.6", constraint: "^0.8.0", expected: false},
+		{version: "0.4.18", constraint: "^0.4.17", expected: true},
+		{version: "0.4.24", constraint: "~0.4.24", expected: true},
+		{version: "0.4.25", constraint: "~0.4.24", expected: true},
+		{version: "0.5.0", constraint: "~0.4.24", expected: false},
+		{version: "0.8.4", constraint: ">=0.6.0 <0.9.0", expected: true},
+		{version: "0.8.4", constraint: ">= 0.6.0 < 0.8.0", expected: false},
+		{version: "0.8.4", constraint: "0.8.4", expected: true},
+		{version: "0.8.4", constraint: "=0.8.3", expected: false},
+		{version: "0.8.4", constraint: "0.8", expected: true},
+		{version: "0.8.4", constraint: "0.8.x", expected: true},
+		{version: "0.8.4", constraint: ">0.8", expected: false},
+		{version: "0.8.4", constraint: "<=0.8", expected: true},
+		{version: "0.6.12", constraint: "0.5.0 - 0.7.6", expected: true},
+		{version: "0.7.6", constraint: "0.5.0 - 0.7", expected: true},
+		{version: "0.8.0", constraint: "0.5.0 - 0.7", expected: false},
+		{version: "0.8.0", constraint: "0.5.0 - 0.7.6 || ^0.8.0", expected: true},
+		{version: "0.8.20-nightly.2023.4.21", constraint: "^0.8.20", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.constraint, func(t *testing.T) {
+			version, err := ParseCompilerVersion(tt.version)
+			require.NoError(t, err)
+
+			satisfied, err := version.Satisfies(tt.constraint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, satisfied)
+		})
+	}
+
+	_, err := CompilerVersion{Minor: 8}.Satisfies(">=0.a")
+	assert.ErrorIs(t, err, ErrInvalidVersionConstraint)
+
+	_, err = CompilerVersion{Minor: 8}.Satisfies("^0.8.0 ||")
+	assert.ErrorIs(t, err, ErrInvalidVersionConstraint)
+}
+
+func TestCompilerVersionCheckPragmas(t *testing.T) {
+	sources := Sources{
+		"A.sol": {Content: "// pragma solidity ^0.4.0;\npragma solidity ^0.8.0;\npragma abicoder v2;\ncontract A {}"},
+		"B.sol": {Content: "pragma solidity >=0.6.0 <0.8.0;\ncontract B {}"},
+	}
+
+	checks, err := CompilerVersion{Minor: 8, Patch: 19}.CheckPragmas(sources)
+	require.NoError(t, err)
+	assert.Equal(t, []PragmaCheck{
+		{File: "A.sol", Constraint: "^0.8.0", Satisfied: true},
+		{File: "B.sol", Constraint: ">=0.6.0 <0.8.0", Satisfied: false},
+	}, checks)
+}
+
+func TestCompilerVersionFromCBOR(t *testing.T) {
+	// a2 64 69706673 5822 <34 bytes> 64 736f6c63 43 000813
+	auxdata := append([]byte{0xa2, 0x64, 'i', 'p', 'f', 's', 0x58, 0x22}, make([]byte, 34)...)
+	auxdata = append(auxdata, 0x64, 's', 'o', 'l', 'c', 0x43, 0x00, 0x08, 0x13)
+
+	version, err := CompilerVersionFromCBOR(auxdata)
+	require.NoError(t, err)
+	assert.Equal(t, "0.8.19", version.String())
+
+	nightly := "0.8.20-nightly.2023.4.21+commit.b5c11a5b"
+	auxdata = append([]byte{0xa1, 0x64, 's', 'o', 'l', 'c', 0x78, byte(len(nightly))}, nightly...)
+	version, err = CompilerVersionFromCBOR(auxdata)
+	require.NoError(t, err)
+	assert.True(t, version.IsNightly())
+
+	_, err = CompilerVersionFromCBOR([]byte{0xa0})
+	assert.ErrorIs(t, err, ErrInvalidCompilerVersion)
+}
+
+func TestContractCompilerVersion(t *testing.T) {
+	contract, err := LoadContract(1, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	require.NoError(t, err)
+
+	version, err := contract.CompilerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "0.4.18", version.Release())
+
+	checks, err := version.CheckPragmas(contract.Sources)
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	assert.True(t, checks[0].Satisfied)
+}
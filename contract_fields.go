@@ -0,0 +1,99 @@
+package sourcify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+var (
+	// ErrUnknownContractField is returned when a selected contract field does not exist.
+	ErrUnknownContractField = errors.New("unknown contract field")
+
+	// ErrFieldsAndOmit is returned when both fields to select and fields to omit are given.
+	ErrFieldsAndOmit = errors.New("fields and omit cannot be used together")
+)
+
+// ContractMinimalFields lists the fields of `/v2/contract` responses that are returned regardless of the selection.
+var ContractMinimalFields = []string{"match", "creationMatch", "runtimeMatch", "chainId", "address", "verifiedAt", "matchId"}
+
+// FilterContractFields applies the field selection of the `/v2/contract` endpoint to a contract, e.g. for serving
+// the endpoint from a local copy. Fields are top level or dotted names such as `compilation.name`; `all` selects
+// every field. Without fields, only ContractMinimalFields are kept, and with fields to omit, every other field is
+// kept.
+func FilterContractFields(contract *ContractResponse, fields []string, omit []string) (map[string]any, error) {
+	if len(fields) > 0 && len(omit) > 0 {
+		return nil, ErrFieldsAndOmit
+	}
+
+	data, err := json.Marshal(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	var encoded map[string]any
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+
+	if len(omit) > 0 {
+		for _, field := range omit {
+			omitContractField(encoded, strings.Split(field, "."))
+		}
+		return encoded, nil
+	}
+
+	if len(fields) == 1 && fields[0] == "all" {
+		return encoded, nil
+	}
+
+	toReturn := make(map[string]any)
+	for _, field := range ContractMinimalFields {
+		selectContractField(toReturn, encoded, []string{field})
+	}
+	for _, field := range fields {
+		if !selectContractField(toReturn, encoded, strings.Split(field, ".")) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownContractField, field)
+		}
+	}
+	return toReturn, nil
+}
+
+// selectContractField copies the value at the dotted path from src into dst, reporting whether it exists.
+func selectContractField(dst map[string]any, src map[string]any, field []string) bool {
+	value, ok := src[field[0]]
+	if !ok {
+		return false
+	}
+	if len(field) == 1 {
+		dst[field[0]] = value
+		return true
+	}
+
+	nested, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+	child, ok := dst[field[0]].(map[string]any)
+	if !ok {
+		child = make(map[string]any)
+	}
+	if !selectContractField(child, nested, field[1:]) {
+		return false
+	}
+	dst[field[0]] = child
+	return true
+}
+
+// omitContractField removes the value at the dotted path from m.
+func omitContractField(m map[string]any, field []string) {
+	if len(field) == 1 {
+		delete(m, field[0])
+		return
+	}
+	if nested, ok := m[field[0]].(map[string]any); ok {
+		omitContractField(nested, field[1:])
+	}
+}
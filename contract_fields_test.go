@@ -0,0 +1,41 @@
+package sourcify
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterContractFields(t *testing.T) {
+	contract, err := LoadContract(1, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	require.NoError(t, err)
+
+	minimal, err := FilterContractFields(contract, nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, minimal, len(ContractMinimalFields))
+	assert.Equal(t, "1817159", minimal["matchId"])
+
+	selected, err := FilterContractFields(contract, []string{"abi", "compilation.name"}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, selected, "abi")
+	assert.Equal(t, map[string]any{"name": "TetherToken"}, selected["compilation"])
+	assert.NotContains(t, selected, "sources")
+
+	all, err := FilterContractFields(contract, []string{"all"}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, all, "sources")
+
+	omitted, err := FilterContractFields(contract, nil, []string{"sources", "compilation.name"})
+	require.NoError(t, err)
+	assert.NotContains(t, omitted, "sources")
+	assert.NotContains(t, omitted["compilation"], "name")
+	assert.Contains(t, omitted["compilation"], "compilerVersion")
+
+	_, err = FilterContractFields(contract, []string{"unknown"}, nil)
+	assert.ErrorIs(t, err, ErrUnknownContractField)
+
+	_, err = FilterContractFields(contract, []string{"abi"}, []string{"sources"})
+	assert.ErrorIs(t, err, ErrFieldsAndOmit)
+}
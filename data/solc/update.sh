@@ -0,0 +1,14 @@
+#!/bin/sh
+# Replaces the embedded compiler bug lists with verbatim copies of docs/bugs.json and docs/bugs_by_version.json
+# from the Solidity repository. Run through `go generate` and record the version in SOURCE.md.
+set -eu
+
+SOLC_VERSION="${SOLC_VERSION:-v0.8.28}"
+dir="$(dirname "$0")"
+
+for file in bugs.json bugs_by_version.json; do
+	curl -fsSL "https://raw.githubusercontent.com/ethereum/solidity/${SOLC_VERSION}/docs/${file}" -o "${dir}/${file}.tmp"
+	mv "${dir}/${file}.tmp" "${dir}/${file}"
+done
+
+echo "Updated compiler bug lists to ${SOLC_VERSION}, remember to update ${dir}/SOURCE.md"
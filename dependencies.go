@@ -0,0 +1,277 @@
+package sourcify
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// packageDetector recognizes the files of a well known package by their source path.
+type packageDetector struct {
+	name    func(match []string) string
+	pattern *regexp.Regexp
+}
+
+var (
+	// packageDetectors lists the well known packages recognized by BuildDependencyGraph. The last submatch of
+	// each pattern captures a version embedded in the path, e.g. `@openzeppelin/contracts@4.9.3/`.
+	packageDetectors = []packageDetector{
+		{
+			name:    func(match []string) string { return "@openzeppelin/contracts" + match[1] },
+			pattern: regexp.MustCompile(`(?:^|/)(?:@openzeppelin/contracts|openzeppelin-contracts)(-upgradeable)?(?:@(\d[\w.-]*))?/`),
+		},
+		{
+			name:    func(match []string) string { return "solmate" },
+			pattern: regexp.MustCompile(`(?:^|/)solmate(?:@(\d[\w.-]*))?/`),
+		},
+		{
+			name:    func(match []string) string { return "@uniswap/" + strings.TrimPrefix(match[1], "@uniswap/") },
+			pattern: regexp.MustCompile(`(?:^|/)(@uniswap/(?:v[234]-(?:core|periphery)|lib|solidity-lib)|v[234]-(?:core|periphery))(?:@(\d[\w.-]*))?/`),
+		},
+	}
+
+	// openZeppelinHeader matches the version header of OpenZeppelin files, e.g.
+	// `// OpenZeppelin Contracts (last updated v4.9.0) (access/Ownable.sol)` or `// OpenZeppelin Contracts v4.4.1 (...)`.
+	openZeppelinHeader = regexp.MustCompile(`OpenZeppelin Contracts(?:\s+\(last updated v(\d[\w.-]*)\)|\s+v(\d[\w.-]*))`)
+)
+
+// Package represents a well known package used by the sources.
+type Package struct {
+	Name    string   `json:"name"`              // Package name, e.g. `@openzeppelin/contracts`.
+	Version string   `json:"version,omitempty"` // Highest version found in the paths or file headers, empty if unknown.
+	Files   []string `json:"files"`             // Sources belonging to the package.
+}
+
+// HasFile reports whether the package contains a file whose path ends with suffix, e.g. `access/Ownable.sol`.
+func (p *Package) HasFile(suffix string) bool {
+	for _, file := range p.Files {
+		if strings.HasSuffix(file, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MajorVersion returns the major component of the package version, or an empty string when it is unknown.
+func (p *Package) MajorVersion() string {
+	major, _, _ := strings.Cut(p.Version, ".")
+	return major
+}
+
+// DependencyGraph represents the import relations between the sources of a contract.
+type DependencyGraph struct {
+	Files      []string            `json:"files"`                // Every source, sorted.
+	Imports    map[string][]string `json:"imports"`              // Source to the sources it imports directly.
+	Unresolved []ImportIssue       `json:"unresolved,omitempty"` // Imports that could not be resolved.
+	Packages   []Package           `json:"packages"`             // Well known packages in use, sorted by name.
+}
+
+// BuildDependencyGraph parses the import directives of every source and resolves them, applying the remappings,
+// into a dependency graph. It also detects the well known packages the sources belong to, currently OpenZeppelin
+// (including the upgradeable variant), solmate and Uniswap, with their version when the paths or the OpenZeppelin
+// file headers reveal it.
+func BuildDependencyGraph(sources Sources, remappings []string) *DependencyGraph {
+	resolver := NewImportResolver(sources, remappings)
+
+	toReturn := &DependencyGraph{
+		Files:    make([]string, 0, len(sources)),
+		Imports:  make(map[string][]string, len(sources)),
+		Packages: []Package{},
+	}
+
+	for name := range sources {
+		toReturn.Files = append(toReturn.Files, name)
+	}
+	sort.Strings(toReturn.Files)
+
+	packages := make(map[string]*Package)
+	for _, name := range toReturn.Files {
+		imports := []string{}
+		for _, imported := range parseImports(sources[name].Content) {
+			resolved, issue := resolver.Resolve(name, imported.path)
+			if issue != nil {
+				toReturn.Unresolved = append(toReturn.Unresolved, *issue)
+				continue
+			}
+			imports = append(imports, resolved)
+		}
+		toReturn.Imports[name] = imports
+
+		if packageName, version, ok := detectPackage(name, sources[name].Content); ok {
+			pkg, exists := packages[packageName]
+			if !exists {
+				pkg = &Package{Name: packageName}
+				packages[packageName] = pkg
+			}
+			pkg.Files = append(pkg.Files, name)
+			if compareVersions(version, pkg.Version) > 0 {
+				pkg.Version = version
+			}
+		}
+	}
+
+	for _, pkg := range packages {
+		toReturn.Packages = append(toReturn.Packages, *pkg)
+	}
+	sort.Slice(toReturn.Packages, func(i, j int) bool {
+		return toReturn.Packages[i].Name < toReturn.Packages[j].Name
+	})
+
+	return toReturn
+}
+
+// DependencyGraph builds the dependency graph of the verified sources of the contract.
+func (c *ContractResponse) DependencyGraph() *DependencyGraph {
+	return BuildDependencyGraph(c.Sources, c.Metadata.Settings.Remappings)
+}
+
+// Package returns the detected package with the given name.
+func (g *DependencyGraph) Package(name string) (*Package, bool) {
+	for i := range g.Packages {
+		if g.Packages[i].Name == name {
+			return &g.Packages[i], true
+		}
+	}
+	return nil, false
+}
+
+// Dependencies returns every source the file imports, directly or transitively, sorted.
+func (g *DependencyGraph) Dependencies(file string) []string {
+	visited := make(map[string]bool)
+
+	var visit func(name string)
+	visit = func(name string) {
+		for _, imported := range g.Imports[name] {
+			if !visited[imported] {
+				visited[imported] = true
+				visit(imported)
+			}
+		}
+	}
+	visit(file)
+	delete(visited, file)
+
+	return sortedKeys(visited)
+}
+
+// Dependents returns every source importing the file, directly or transitively, sorted.
+func (g *DependencyGraph) Dependents(file string) []string {
+	toReturn := make(map[string]bool)
+	for _, name := range g.Files {
+		if name == file {
+			continue
+		}
+		for _, dependency := range g.Dependencies(name) {
+			if dependency == file {
+				toReturn[name] = true
+				break
+			}
+		}
+	}
+	return sortedKeys(toReturn)
+}
+
+// DOT renders the graph in the Graphviz DOT language. Files of a detected package are grouped in a cluster
+// labelled with the package name and version.
+func (g *DependencyGraph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph imports {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box];\n")
+
+	grouped := make(map[string]bool)
+	for i, pkg := range g.Packages {
+		label := pkg.Name
+		if pkg.Version != "" {
+			label += " " + pkg.Version
+		}
+		sb.WriteString(fmt.Sprintf("  subgraph cluster_%d {\n    label=%q;\n", i, label))
+		for _, file := range pkg.Files {
+			grouped[file] = true
+			sb.WriteString(fmt.Sprintf("    %q;\n", file))
+		}
+		sb.WriteString("  }\n")
+	}
+
+	for _, file := range g.Files {
+		if !grouped[file] {
+			sb.WriteString(fmt.Sprintf("  %q;\n", file))
+		}
+	}
+
+	for _, file := range g.Files {
+		for _, imported := range g.Imports[file] {
+			sb.WriteString(fmt.Sprintf("  %q -> %q;\n", file, imported))
+		}
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// detectPackage returns the well known package the source belongs to and the version revealed by its path or header.
+func detectPackage(name string, content string) (string, string, bool) {
+	for _, detector := range packageDetectors {
+		match := detector.pattern.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+
+		version := match[len(match)-1]
+		if header := openZeppelinHeader.FindStringSubmatch(content); header != nil && strings.HasPrefix(detector.name(match), "@openzeppelin/") {
+			if header[1] != "" {
+				version = header[1]
+			} else {
+				version = header[2]
+			}
+		}
+
+		return detector.name(match), version, true
+	}
+	return "", "", false
+}
+
+// compareVersions compares two dotted version strings numerically, ignoring pre-release suffixes.
+// An empty version is lower than any other.
+func compareVersions(a string, b string) int {
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	if a == "" || b == "" {
+		return len(a) - len(b)
+	}
+
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		var numberA, numberB int
+		if i < len(partsA) {
+			numberA = leadingNumber(partsA[i])
+		}
+		if i < len(partsB) {
+			numberB = leadingNumber(partsB[i])
+		}
+		if numberA != numberB {
+			return numberA - numberB
+		}
+	}
+	return 0
+}
+
+// leadingNumber parses the digits at the start of s, e.g. 3 for `3-rc.1`.
+func leadingNumber(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	toReturn, _ := strconv.Atoi(s[:end])
+	return toReturn
+}
+
+// sortedKeys returns the keys of the set in ascending order.
+func sortedKeys(set map[string]bool) []string {
+	toReturn := make([]string, 0, len(set))
+	for key := range set {
+		toReturn = append(toReturn, key)
+	}
+	sort.Strings(toReturn)
+	return toReturn
+}
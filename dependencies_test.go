@@ -0,0 +1,108 @@
+package sourcify
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDependencyGraph(t *testing.T) {
+	sources := testFlattenSources()
+	sources["lib/openzeppelin-contracts/contracts/access/Ownable.sol"] = SourceContent{Content: `// SPDX-License-Identifier: MIT
+// OpenZeppelin Contracts (last updated v4.9.0) (access/Ownable.sol)
+pragma solidity ^0.8.20;
+
+import {Context} from "../utils/Context.sol";
+import "missing/Thing.sol";
+
+abstract contract Ownable is Context {}
+`}
+	sources["lib/openzeppelin-contracts/contracts/utils/Context.sol"] = SourceContent{Content: `// SPDX-License-Identifier: MIT
+// OpenZeppelin Contracts v4.4.1 (utils/Context.sol)
+pragma solidity ^0.8.20;
+
+abstract contract Context {}
+`}
+	sources["lib/solmate/src/tokens/ERC20.sol"] = SourceContent{Content: "abstract contract SolmateERC20 {}"}
+	sources["@uniswap/v3-core/contracts/interfaces/IUniswapV3Pool.sol"] = SourceContent{Content: "interface IUniswapV3Pool {}"}
+	sources["lib/forge-std/src/Test.sol"] = SourceContent{Content: "contract Test {}"}
+
+	graph := BuildDependencyGraph(sources, []string{"@openzeppelin/=lib/openzeppelin-contracts/"})
+
+	assert.Equal(t, []string{
+		"lib/openzeppelin-contracts/contracts/token/ERC20/ERC20.sol",
+		"lib/openzeppelin-contracts/contracts/access/Ownable.sol",
+		"contracts/lib/Math.sol",
+	}, graph.Imports["contracts/Token.sol"])
+
+	require.Len(t, graph.Unresolved, 1)
+	assert.Equal(t, "missing/Thing.sol", graph.Unresolved[0].Import)
+
+	assert.Equal(t, []string{
+		"contracts/lib/Math.sol",
+		"lib/openzeppelin-contracts/contracts/access/Ownable.sol",
+		"lib/openzeppelin-contracts/contracts/token/ERC20/ERC20.sol",
+		"lib/openzeppelin-contracts/contracts/utils/Context.sol",
+	}, graph.Dependencies("contracts/Token.sol"))
+	assert.Equal(t, []string{
+		"contracts/Token.sol",
+		"lib/openzeppelin-contracts/contracts/access/Ownable.sol",
+		"lib/openzeppelin-contracts/contracts/token/ERC20/ERC20.sol",
+	}, graph.Dependents("lib/openzeppelin-contracts/contracts/utils/Context.sol"))
+
+	names := make([]string, 0, len(graph.Packages))
+	for _, pkg := range graph.Packages {
+		names = append(names, pkg.Name)
+	}
+	assert.Equal(t, []string{"@openzeppelin/contracts", "@uniswap/v3-core", "solmate"}, names)
+
+	oz, ok := graph.Package("@openzeppelin/contracts")
+	require.True(t, ok)
+	assert.Equal(t, "4.9.0", oz.Version)
+	assert.Equal(t, "4", oz.MajorVersion())
+	assert.True(t, oz.HasFile("access/Ownable.sol"))
+	assert.False(t, oz.HasFile("access/AccessControl.sol"))
+
+	dot := graph.DOT()
+	assert.Contains(t, dot, `label="@openzeppelin/contracts 4.9.0";`)
+	assert.Contains(t, dot, `"contracts/Token.sol" -> "contracts/lib/Math.sol";`)
+
+	encoded, err := json.Marshal(graph)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"packages":[{"name":"@openzeppelin/contracts","version":"4.9.0"`)
+}
+
+func TestDetectPackage(t *testing.T) {
+	tests := []struct {
+		path    string
+		name    string
+		version string
+	}{
+		{path: "@openzeppelin/contracts@4.9.3/token/ERC20/ERC20.sol", name: "@openzeppelin/contracts", version: "4.9.3"},
+		{path: "@openzeppelin/contracts-upgradeable/proxy/utils/Initializable.sol", name: "@openzeppelin/contracts-upgradeable"},
+		{path: "lib/openzeppelin-contracts-upgradeable/contracts/access/OwnableUpgradeable.sol", name: "@openzeppelin/contracts-upgradeable"},
+		{path: "solmate/src/auth/Owned.sol", name: "solmate"},
+		{path: "@uniswap/lib/contracts/libraries/TransferHelper.sol", name: "@uniswap/lib"},
+		{path: "lib/v4-core/src/PoolManager.sol", name: "@uniswap/v4-core"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			name, version, ok := detectPackage(tt.path, "")
+			require.True(t, ok)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+
+	_, _, ok := detectPackage("lib/forge-std/src/Test.sol", "")
+	assert.False(t, ok)
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Positive(t, compareVersions("4.10.0", "4.9.3"))
+	assert.Negative(t, compareVersions("", "0.0.1"))
+	assert.Zero(t, compareVersions("5.0.0-rc.0", "5.0.0"))
+}
@@ -0,0 +1,362 @@
+package sourcify
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrEmptyBytecode is returned when there is no bytecode to work with.
+	ErrEmptyBytecode = errors.New("bytecode is empty")
+)
+
+// CodeReference represents a byte range within bytecode as reported by the compiler,
+// used by both immutable and link references.
+type CodeReference struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// SectionKind describes the kind of a bytecode section.
+type SectionKind string
+
+const (
+	// SectionKindCode denotes a section containing executable instructions.
+	SectionKindCode SectionKind = "code"
+
+	// SectionKindAuxdata denotes a section containing CBOR encoded metadata appended by the compiler.
+	SectionKindAuxdata SectionKind = "auxdata"
+
+	// SectionKindTrailing denotes data following the last auxdata section, such as constructor arguments.
+	SectionKindTrailing SectionKind = "trailing"
+)
+
+// Section represents a contiguous part of the bytecode.
+type Section struct {
+	Kind   SectionKind // Kind of the section.
+	Offset int         // Offset of the section within the bytecode.
+	Data   []byte      // Raw bytes of the section.
+}
+
+// Instruction represents a single disassembled EVM instruction.
+type Instruction struct {
+	PC         int             // Program counter of the instruction.
+	OpCode     OpCode          // Opcode of the instruction.
+	Immediate  []byte          // Immediate data of PUSH instructions.
+	JumpTarget bool            // Whether the immediate is a valid jump destination consumed by the following JUMP or JUMPI.
+	Immutable  string          // AST id of the immutable variable whose value is pushed, if any.
+	Library    string          // Fully qualified name (file:Library) of the library whose address is pushed, if any.
+	Source     *SourceMapEntry // Source map entry of the instruction, if a source map was provided.
+}
+
+// String returns the instruction as a single assembly line without annotations.
+func (i Instruction) String() string {
+	if len(i.Immediate) > 0 {
+		return fmt.Sprintf("%s 0x%x", i.OpCode, i.Immediate)
+	}
+	return i.OpCode.String()
+}
+
+// Disassembly represents the result of disassembling bytecode.
+type Disassembly struct {
+	Sections     []Section     // Sections of the bytecode in order of appearance.
+	Instructions []Instruction // Instructions of all code sections in order of appearance.
+	JumpDests    []int         // Program counters of all JUMPDEST instructions.
+}
+
+// InstructionAt returns the instruction starting at the given program counter.
+func (d *Disassembly) InstructionAt(pc int) (*Instruction, bool) {
+	idx := sort.Search(len(d.Instructions), func(i int) bool {
+		return d.Instructions[i].PC >= pc
+	})
+	if idx < len(d.Instructions) && d.Instructions[idx].PC == pc {
+		return &d.Instructions[idx], true
+	}
+	return nil, false
+}
+
+// String returns a human readable listing of the disassembly including annotations.
+func (d *Disassembly) String() string {
+	var sb strings.Builder
+
+	next := 0
+	for _, section := range d.Sections {
+		if section.Kind == SectionKindCode {
+			end := section.Offset + len(section.Data)
+			for ; next < len(d.Instructions) && d.Instructions[next].PC < end; next++ {
+				instruction := d.Instructions[next]
+				sb.WriteString(fmt.Sprintf("%06x: %s", instruction.PC, instruction))
+
+				var notes []string
+				if instruction.OpCode.IsJumpDest() {
+					notes = append(notes, "jumpdest")
+				}
+				if instruction.JumpTarget {
+					notes = append(notes, "jump target")
+				}
+				if instruction.Immutable != "" {
+					notes = append(notes, fmt.Sprintf("immutable %s", instruction.Immutable))
+				}
+				if instruction.Library != "" {
+					notes = append(notes, fmt.Sprintf("library %s", instruction.Library))
+				}
+				if instruction.Source != nil {
+					notes = append(notes, fmt.Sprintf("src %d:%d:%d:%s", instruction.Source.Start, instruction.Source.Length, instruction.Source.File, instruction.Source.Jump))
+				}
+				if len(notes) > 0 {
+					sb.WriteString(" ; " + strings.Join(notes, ", "))
+				}
+				sb.WriteString("\n")
+			}
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("%06x: [%s] 0x%x\n", section.Offset, section.Kind, section.Data))
+	}
+
+	return sb.String()
+}
+
+// disassembleOptions holds the optional inputs used to annotate a disassembly.
+type disassembleOptions struct {
+	auxdata             map[string]CborAuxData
+	immutableReferences ImmutableReferences
+	linkReferences      LinkReferences
+	sourceMap           SourceMap
+}
+
+// DisassembleOption sets a configuration option for the disassembler.
+type DisassembleOption func(*disassembleOptions)
+
+// WithAuxdata sets the CBOR auxdata positions used to split code from metadata.
+// When not provided, the disassembler detects a single auxdata section at the end of the bytecode.
+func WithAuxdata(auxdata map[string]CborAuxData) DisassembleOption {
+	return func(o *disassembleOptions) {
+		o.auxdata = auxdata
+	}
+}
+
+// WithImmutableReferences sets the immutable reference positions, keyed by AST id, to annotate.
+func WithImmutableReferences(references ImmutableReferences) DisassembleOption {
+	return func(o *disassembleOptions) {
+		o.immutableReferences = references
+	}
+}
+
+// WithLinkReferences sets the link reference positions, keyed by file and library name, to annotate.
+func WithLinkReferences(references LinkReferences) DisassembleOption {
+	return func(o *disassembleOptions) {
+		o.linkReferences = references
+	}
+}
+
+// WithSourceMap sets the decompressed source map used to attach source entries to instructions.
+func WithSourceMap(sourceMap SourceMap) DisassembleOption {
+	return func(o *disassembleOptions) {
+		o.sourceMap = sourceMap
+	}
+}
+
+// Disassemble disassembles the given bytecode into instructions, splitting executable code
+// from CBOR auxdata and trailing data and annotating instructions according to the options.
+func Disassemble(code []byte, options ...DisassembleOption) (*Disassembly, error) {
+	if len(code) == 0 {
+		return nil, ErrEmptyBytecode
+	}
+
+	opts := &disassembleOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	toReturn := &Disassembly{
+		Sections: splitSections(code, opts.auxdata),
+	}
+
+	for _, section := range toReturn.Sections {
+		if section.Kind != SectionKindCode {
+			continue
+		}
+
+		for pos := 0; pos < len(section.Data); pos++ {
+			op := OpCode(section.Data[pos])
+			instruction := Instruction{PC: section.Offset + pos, OpCode: op}
+
+			if size := op.PushSize(); size > 0 {
+				end := pos + 1 + size
+				if end > len(section.Data) {
+					end = len(section.Data)
+				}
+				instruction.Immediate = section.Data[pos+1 : end]
+				pos = end - 1
+			}
+
+			if op.IsJumpDest() {
+				toReturn.JumpDests = append(toReturn.JumpDests, instruction.PC)
+			}
+
+			toReturn.Instructions = append(toReturn.Instructions, instruction)
+		}
+	}
+
+	jumpDests := make(map[int]bool, len(toReturn.JumpDests))
+	for _, pc := range toReturn.JumpDests {
+		jumpDests[pc] = true
+	}
+
+	immutables := make(map[int]string)
+	for astId, references := range opts.immutableReferences {
+		for _, reference := range references {
+			immutables[reference.Start] = astId
+		}
+	}
+
+	libraries := make(map[int]string)
+	for _, reference := range opts.linkReferences.List() {
+		libraries[reference.Start] = reference.FullyQualifiedName()
+	}
+
+	for idx := range toReturn.Instructions {
+		instruction := &toReturn.Instructions[idx]
+
+		if len(instruction.Immediate) > 0 {
+			// Immutable and link references point at the first byte of the immediate data.
+			if astId, ok := immutables[instruction.PC+1]; ok {
+				instruction.Immutable = astId
+			}
+			if library, ok := libraries[instruction.PC+1]; ok {
+				instruction.Library = library
+			}
+
+			if idx+1 < len(toReturn.Instructions) && toReturn.Instructions[idx+1].OpCode.IsJump() {
+				target := new(big.Int).SetBytes(instruction.Immediate)
+				if target.IsInt64() && jumpDests[int(target.Int64())] {
+					instruction.JumpTarget = true
+				}
+			}
+		}
+
+		if idx < len(opts.sourceMap) {
+			entry := opts.sourceMap[idx]
+			instruction.Source = &entry
+		}
+	}
+
+	return toReturn, nil
+}
+
+// DisassembleOnchain disassembles the onchain bytecode, annotated with the auxdata,
+// immutable and link references of the bytecode. Additional options are applied afterwards.
+func (b Bytecode) DisassembleOnchain(options ...DisassembleOption) (*Disassembly, error) {
+	return b.disassemble(b.OnchainBytecode, options...)
+}
+
+// DisassembleRecompiled disassembles the recompiled bytecode, annotated with the auxdata,
+// immutable and link references of the bytecode. Additional options are applied afterwards.
+func (b Bytecode) DisassembleRecompiled(options ...DisassembleOption) (*Disassembly, error) {
+	return b.disassemble(b.RecompiledBytecode, options...)
+}
+
+// disassemble decodes the hex encoded, possibly unlinked, bytecode and disassembles it with the bytecode annotations.
+func (b Bytecode) disassemble(bytecode string, options ...DisassembleOption) (*Disassembly, error) {
+	code, err := decodeHex(zeroPlaceholders(bytecode))
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := []DisassembleOption{
+		WithAuxdata(b.CborAuxdata),
+		WithImmutableReferences(b.ImmutableReferences),
+		WithLinkReferences(b.LinkReferences),
+	}
+
+	return Disassemble(code, append(defaults, options...)...)
+}
+
+// splitSections splits the bytecode into code, auxdata and trailing sections.
+func splitSections(code []byte, auxdata map[string]CborAuxData) []Section {
+	type span struct{ start, end int }
+
+	var spans []span
+	for _, aux := range auxdata {
+		value, err := decodeHex(aux.Value)
+		if err != nil || len(value) == 0 {
+			continue
+		}
+
+		start := int(aux.Offset)
+		end := start + len(value)
+		if start < 0 || end > len(code) {
+			continue
+		}
+		spans = append(spans, span{start, end})
+	}
+
+	if len(spans) == 0 {
+		if start, ok := detectAuxdata(code); ok {
+			spans = append(spans, span{start, len(code)})
+		}
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	var toReturn []Section
+	pos := 0
+	for _, s := range spans {
+		if s.start < pos {
+			continue
+		}
+		if s.start > pos {
+			toReturn = append(toReturn, Section{Kind: SectionKindCode, Offset: pos, Data: code[pos:s.start]})
+		}
+		toReturn = append(toReturn, Section{Kind: SectionKindAuxdata, Offset: s.start, Data: code[s.start:s.end]})
+		pos = s.end
+	}
+
+	if pos < len(code) {
+		kind := SectionKindCode
+		if len(spans) > 0 {
+			kind = SectionKindTrailing
+		}
+		toReturn = append(toReturn, Section{Kind: kind, Offset: pos, Data: code[pos:]})
+	}
+
+	return toReturn
+}
+
+// detectAuxdata detects CBOR auxdata at the end of the bytecode using the two byte length suffix
+// appended by the Solidity and Vyper compilers. It returns the offset at which the auxdata starts.
+func detectAuxdata(code []byte) (int, bool) {
+	if len(code) < 2 {
+		return 0, false
+	}
+
+	length := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	start := len(code) - 2 - length
+	if length == 0 || start < 0 {
+		return 0, false
+	}
+
+	// The metadata is encoded as a CBOR map with up to a handful of entries (0xa1 - 0xa5).
+	if code[start] < 0xa1 || code[start] > 0xa5 {
+		return 0, false
+	}
+
+	return start, true
+}
+
+// decodeHex decodes a hex string with or without the 0x prefix.
+func decodeHex(value string) ([]byte, error) {
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	toReturn, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode hex: %w", err)
+	}
+	return toReturn, nil
+}
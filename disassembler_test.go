@@ -0,0 +1,114 @@
+package sourcify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpCode_String(t *testing.T) {
+	assert.Equal(t, "STOP", OpCode(0x00).String())
+	assert.Equal(t, "PUSH0", OpCode(0x5f).String())
+	assert.Equal(t, "PUSH1", OpCode(0x60).String())
+	assert.Equal(t, "PUSH32", OpCode(0x7f).String())
+	assert.Equal(t, "DUP16", OpCode(0x8f).String())
+	assert.Equal(t, "SWAP1", OpCode(0x90).String())
+	assert.Equal(t, "UNKNOWN(0x0c)", OpCode(0x0c).String())
+	assert.False(t, OpCode(0x0c).IsDefined())
+	assert.Equal(t, 20, OpCode(0x73).PushSize())
+	assert.Equal(t, 0, OpCode(0x5f).PushSize())
+}
+
+func TestDisassemble(t *testing.T) {
+	code := []byte{
+		0x60, 0x04, // PUSH1 0x04
+		0x56, // JUMP
+		0x00, // STOP
+		0x5b, // JUMPDEST
+		0x7f, // PUSH32 <immutable>
+	}
+	code = append(code, make([]byte, 32)...)
+	code = append(code, 0x73) // PUSH20 <library>
+	code = append(code, make([]byte, 20)...)
+	code = append(code, 0x00) // STOP
+
+	// Auxdata: {"a": 1} as CBOR followed by its length.
+	code = append(code, 0xa1, 0x61, 0x61, 0x01, 0x00, 0x04)
+
+	disassembly, err := Disassemble(code,
+		WithImmutableReferences(map[string][]CodeReference{"12": {{Start: 6, Length: 32}}}),
+		WithLinkReferences(map[string]map[string][]CodeReference{"lib/Math.sol": {"Math": {{Start: 39, Length: 20}}}}),
+		WithSourceMap(SourceMap{{Start: 1, Length: 2, File: 0, Jump: JumpTypeRegular}}),
+	)
+	require.NoError(t, err)
+
+	require.Len(t, disassembly.Sections, 2)
+	assert.Equal(t, SectionKindCode, disassembly.Sections[0].Kind)
+	assert.Equal(t, SectionKindAuxdata, disassembly.Sections[1].Kind)
+	assert.Equal(t, 60, disassembly.Sections[1].Offset)
+
+	require.Len(t, disassembly.Instructions, 7)
+	assert.Equal(t, []int{4}, disassembly.JumpDests)
+
+	push := disassembly.Instructions[0]
+	assert.True(t, push.JumpTarget)
+	require.NotNil(t, push.Source)
+	assert.Equal(t, 1, push.Source.Start)
+
+	immutable, ok := disassembly.InstructionAt(5)
+	require.True(t, ok)
+	assert.Equal(t, "12", immutable.Immutable)
+
+	library, ok := disassembly.InstructionAt(38)
+	require.True(t, ok)
+	assert.Equal(t, "lib/Math.sol:Math", library.Library)
+	assert.Nil(t, library.Source)
+
+	_, ok = disassembly.InstructionAt(1)
+	assert.False(t, ok)
+
+	listing := disassembly.String()
+	assert.Contains(t, listing, "000000: PUSH1 0x04 ; jump target, src 1:2:0:-")
+	assert.Contains(t, listing, "000004: JUMPDEST ; jumpdest")
+	assert.Contains(t, listing, "immutable 12")
+	assert.Contains(t, listing, "library lib/Math.sol:Math")
+	assert.Contains(t, listing, "00003c: [auxdata] 0xa1616101")
+}
+
+func TestDisassemble_Empty(t *testing.T) {
+	_, err := Disassemble(nil)
+	assert.ErrorIs(t, err, ErrEmptyBytecode)
+}
+
+func TestBytecode_Disassemble(t *testing.T) {
+	contract, err := LoadContract(1, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	require.NoError(t, err)
+
+	runtime, err := contract.RuntimeBytecode.DisassembleRecompiled()
+	require.NoError(t, err)
+	require.Len(t, runtime.Sections, 2)
+	assert.Equal(t, SectionKindCode, runtime.Sections[0].Kind)
+	assert.Len(t, runtime.Sections[0].Data, 11032)
+	assert.Equal(t, SectionKindAuxdata, runtime.Sections[1].Kind)
+	assert.True(t, strings.HasPrefix(runtime.Instructions[0].String(), "PUSH1 0x60"))
+
+	// Onchain creation bytecode carries the constructor arguments after the auxdata.
+	creation, err := contract.CreationBytecode.DisassembleOnchain()
+	require.NoError(t, err)
+	require.Len(t, creation.Sections, 3)
+	assert.Equal(t, SectionKindAuxdata, creation.Sections[1].Kind)
+	assert.Equal(t, 11601, creation.Sections[1].Offset)
+	assert.Equal(t, SectionKindTrailing, creation.Sections[2].Kind)
+	assert.Len(t, creation.Sections[2].Data, 256)
+
+	sourceMap, err := ParseSourceMap(contract.RuntimeBytecode.SourceMap)
+	require.NoError(t, err)
+
+	annotated, err := contract.RuntimeBytecode.DisassembleRecompiled(WithSourceMap(sourceMap))
+	require.NoError(t, err)
+	require.NotNil(t, annotated.Instructions[0].Source)
+	assert.Equal(t, 9971, annotated.Instructions[0].Source.Start)
+}
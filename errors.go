@@ -14,16 +14,34 @@ var (
 	}
 )
 
+// StatusError is returned when Sourcify answers with an unexpected HTTP status and no error message, so that
+// callers can inspect the status code with errors.As.
+type StatusError struct {
+	StatusCode int
+}
+
+// Error formats the status code of the response.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
 type ErrorResponse struct {
 	ErrorId    uuid.UUID `json:"errorId"`
 	CustomCode string    `json:"customCode"`
 	Message    string    `json:"message"`
 }
 
+// Error formats the error returned by Sourcify, so that callers can match it with errors.As.
+func (e *ErrorResponse) Error() string {
+	return fmt.Sprintf("sourcify returned error (%s): %s", e.CustomCode, e.Message)
+}
+
+// ToErrorResponse decodes the error returned by Sourcify from the response body as an *ErrorResponse.
+// It returns nil when the body does not hold an error message.
 func ToErrorResponse(response io.ReadCloser) error {
 	var errorResp ErrorResponse
 	if err := json.NewDecoder(response).Decode(&errorResp); err == nil && errorResp.Message != "" {
-		return fmt.Errorf("sourcify returned error (%s): %s", errorResp.CustomCode, errorResp.Message)
+		return &errorResp
 	}
 	return nil
 }
@@ -0,0 +1,40 @@
+package sourcify
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToErrorResponse(t *testing.T) {
+	err := ToErrorResponse(io.NopCloser(strings.NewReader(`{"customCode":"not_found","message":"Contract not found","errorId":"6f3c4c3e-9a4b-4f8e-8a55-2f6f3c1f0c11"}`)))
+	require.EqualError(t, err, "sourcify returned error (not_found): Contract not found")
+
+	var errorResponse *ErrorResponse
+	require.True(t, errors.As(err, &errorResponse))
+	assert.Equal(t, "not_found", errorResponse.CustomCode)
+	assert.Equal(t, "6f3c4c3e-9a4b-4f8e-8a55-2f6f3c1f0c11", errorResponse.ErrorId.String())
+
+	assert.NoError(t, ToErrorResponse(io.NopCloser(strings.NewReader(`{}`))))
+	assert.NoError(t, ToErrorResponse(io.NopCloser(strings.NewReader(`not json`))))
+}
+
+func TestStatusError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer mockServer.Close()
+
+	_, err := GetChains(NewClient(WithBaseURL(mockServer.URL)))
+	require.EqualError(t, err, "unexpected status code: 418")
+
+	var statusErr *StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusTeapot, statusErr.StatusCode)
+}
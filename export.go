@@ -0,0 +1,463 @@
+package sourcify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrUnsafePath is returned when a source path cannot be mapped to a file inside the export directory.
+	ErrUnsafePath = errors.New("unsafe source path")
+
+	// ErrNoSources is returned when there is nothing to export.
+	ErrNoSources = errors.New("no sources to export")
+)
+
+// ProjectLayout describes how exported sources are laid out on disk.
+type ProjectLayout string
+
+const (
+	// ProjectLayoutPlain writes the sources under the export directory with their original paths.
+	ProjectLayoutPlain ProjectLayout = "plain"
+
+	// ProjectLayoutFoundry writes the sources under `src` and scaffolds `foundry.toml` and `remappings.txt`.
+	ProjectLayoutFoundry ProjectLayout = "foundry"
+
+	// ProjectLayoutHardhat writes the sources under `contracts` and scaffolds `hardhat.config.js`, `package.json`,
+	// `remappings.txt` and `foundry.toml`. Hardhat does not read `remappings.txt` by itself, so the configuration
+	// loads the `@nomicfoundation/hardhat-foundry` plugin, which requires `forge` to be installed, to resolve the
+	// remapped imports.
+	ProjectLayoutHardhat ProjectLayout = "hardhat"
+)
+
+const (
+	// ExportMetadataFile is the name of the exported compiler metadata file.
+	ExportMetadataFile = "metadata.json"
+
+	// ExportStdJSONInputFile is the name of the exported standard JSON input file.
+	ExportStdJSONInputFile = "std-json-input.json"
+)
+
+// ExportOption configures how a contract is exported.
+type ExportOption func(*exportOptions)
+
+type exportOptions struct {
+	layout   ProjectLayout
+	metadata []byte
+}
+
+// WithProjectLayout sets the project layout scaffolded around the exported sources. Defaults to ProjectLayoutPlain.
+func WithProjectLayout(layout ProjectLayout) ExportOption {
+	return func(o *exportOptions) {
+		o.layout = layout
+	}
+}
+
+// WithMetadataFile sets the original `metadata.json` of the contract, e.g. as returned by
+// GetContractMetadataAsBytes, which is written as is. The metadata of the contract response is decoded, so by
+// default it is re-encoded the way solc does, which only matches the metadata hash embedded in the bytecode when
+// the response holds every metadata field.
+func WithMetadataFile(metadata []byte) ExportOption {
+	return func(o *exportOptions) {
+		o.metadata = metadata
+	}
+}
+
+// sourcesDir returns the directory, relative to the export root, the sources are written to.
+func (l ProjectLayout) sourcesDir() string {
+	switch l {
+	case ProjectLayoutFoundry:
+		return "src"
+	case ProjectLayoutHardhat:
+		return "contracts"
+	default:
+		return ""
+	}
+}
+
+// ExportContract writes the verified sources of the contract under dir using their original paths, together
+// with `metadata.json` and the standard JSON input. Source paths are sanitized so that no file is written outside
+// of dir. With ProjectLayoutFoundry or ProjectLayoutHardhat a project configured with the exact compiler version,
+// EVM version, optimizer settings and libraries of the verification is scaffolded around the sources.
+// Returns the paths of the written files relative to dir.
+func ExportContract(dir string, contract *ContractResponse, opts ...ExportOption) ([]string, error) {
+	options := exportOptions{layout: ProjectLayoutPlain}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if len(contract.Sources) == 0 {
+		return nil, ErrNoSources
+	}
+
+	files := make(map[string][]byte, len(contract.Sources)+4)
+	for sourcePath, source := range contract.Sources {
+		sanitized, err := SanitizeSourcePath(sourcePath)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Join(options.layout.sourcesDir(), sanitized)] = []byte(source.Content)
+	}
+
+	metadata := options.metadata
+	if metadata == nil {
+		encoded, err := canonicalMetadata(&contract.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		metadata = encoded
+	}
+	files[ExportMetadataFile] = metadata
+
+	// The standard JSON input is reconstructed from the metadata when the response does not include it,
+	// and skipped when neither is complete.
+	if stdJSONInput, err := contract.StandardJSONInput(); err == nil && len(stdJSONInput.Sources) > 0 {
+		input, err := json.MarshalIndent(stdJSONInput, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode standard JSON input: %w", err)
+		}
+		files[ExportStdJSONInputFile] = input
+	}
+
+	if options.layout == ProjectLayoutFoundry || options.layout == ProjectLayoutHardhat {
+		config, err := newProjectConfig(contract, options.layout)
+		if err != nil {
+			return nil, err
+		}
+
+		files["remappings.txt"] = []byte(strings.Join(config.remappings, "\n") + "\n")
+		files["foundry.toml"] = config.foundryToml()
+		if options.layout == ProjectLayoutHardhat {
+			hardhatConfig, err := config.hardhatConfig()
+			if err != nil {
+				return nil, err
+			}
+			files["hardhat.config.js"] = hardhatConfig
+			files["package.json"] = []byte(hardhatPackageJSON)
+		}
+	}
+
+	return writeExportFiles(dir, files)
+}
+
+// ExportSourceCodes writes the files returned by GetContractSourceCode under dir. Repository paths are reduced
+// to the source path following the `sources/` directory, and metadata.json is written to the root of dir.
+// Returns the paths of the written files relative to dir.
+func ExportSourceCodes(dir string, codes *SourceCodes) ([]string, error) {
+	if codes == nil || len(codes.Code) == 0 {
+		return nil, ErrNoSources
+	}
+
+	files := make(map[string][]byte, len(codes.Code))
+	for _, code := range codes.Code {
+		sourcePath := code.Path
+		if index := strings.Index(sourcePath, "/sources/"); index >= 0 {
+			sourcePath = sourcePath[index+len("/sources/"):]
+		} else {
+			sourcePath = code.Name
+		}
+
+		sanitized, err := SanitizeSourcePath(sourcePath)
+		if err != nil {
+			return nil, err
+		}
+		files[sanitized] = []byte(code.Content)
+	}
+
+	return writeExportFiles(dir, files)
+}
+
+// SanitizeSourcePath converts a source path as found in the compiler input into a relative, slash separated path
+// that cannot escape the directory it is joined with. Absolute paths, Windows drive letters, backslashes and
+// `..` segments are neutralized, e.g. `/home/user/../../etc/passwd` becomes `etc/passwd`.
+func SanitizeSourcePath(sourcePath string) (string, error) {
+	if strings.ContainsRune(sourcePath, 0) {
+		return "", fmt.Errorf("%w: %q contains a null byte", ErrUnsafePath, sourcePath)
+	}
+
+	sanitized := strings.ReplaceAll(sourcePath, "\\", "/")
+	if len(sanitized) >= 2 && sanitized[1] == ':' {
+		sanitized = sanitized[2:]
+	}
+
+	// Cleaning a rooted path drops every `..` segment that would climb above the root.
+	sanitized = strings.TrimPrefix(path.Clean("/"+sanitized), "/")
+	if sanitized == "" || sanitized == "." {
+		return "", fmt.Errorf("%w: %q", ErrUnsafePath, sourcePath)
+	}
+
+	return sanitized, nil
+}
+
+// writeExportFiles writes the files, keyed by slash separated paths, under dir and returns the sorted paths.
+func writeExportFiles(dir string, files map[string][]byte) ([]string, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := make([]string, 0, len(files))
+	for name := range files {
+		toReturn = append(toReturn, name)
+	}
+	sort.Strings(toReturn)
+
+	for _, name := range toReturn {
+		target := filepath.Join(root, filepath.FromSlash(name))
+		if rel, err := filepath.Rel(root, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%w: %q", ErrUnsafePath, name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+
+		if err := os.WriteFile(target, files[name], 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	return toReturn, nil
+}
+
+// projectConfig holds the compiler configuration shared by the scaffolded project files.
+type projectConfig struct {
+	sourcesDir   string
+	solcVersion  string
+	evmVersion   string
+	optimizer    Optimizer
+	viaIR        bool
+	bytecodeHash string
+	remappings   []string
+	libraries    []string
+}
+
+// newProjectConfig derives the project configuration from the verified compiler settings.
+func newProjectConfig(contract *ContractResponse, layout ProjectLayout) (*projectConfig, error) {
+	version := contract.Metadata.Compiler.Version
+	if version == "" {
+		version = contract.Compilation.CompilerVersion
+	}
+	if version == "" {
+		return nil, errors.New("compiler version is unknown")
+	}
+
+	settings := contract.Metadata.Settings
+	toReturn := &projectConfig{
+		sourcesDir:   layout.sourcesDir(),
+		solcVersion:  strings.TrimPrefix(strings.SplitN(version, "+", 2)[0], "v"),
+		evmVersion:   settings.EvmVersion,
+		optimizer:    settings.Optimizer,
+		viaIR:        settings.ViaIR,
+		bytecodeHash: settings.Metadata.BytecodeHash,
+	}
+
+	// Sources are stored under their import paths, so every top level directory of a non relative import path
+	// is remapped into the sources directory, e.g. `@openzeppelin/=src/@openzeppelin/`.
+	prefixes := make(map[string]bool)
+	for sourcePath := range contract.Sources {
+		sanitized, err := SanitizeSourcePath(sourcePath)
+		if err != nil {
+			return nil, err
+		}
+		if first, _, ok := strings.Cut(sanitized, "/"); ok {
+			prefixes[first] = true
+		}
+	}
+	remappings := make(map[string]string, len(prefixes)+len(settings.Remappings))
+	for prefix := range prefixes {
+		remappings[prefix+"/"] = fmt.Sprintf("%s/=%s/%s/", prefix, toReturn.sourcesDir, prefix)
+	}
+
+	// The remappings of the verification, e.g. `foo/=lib/foo/src/`, point to the paths the sources are stored
+	// under, so their context and target are moved into the sources directory, e.g. `foo/=src/lib/foo/src/`. They
+	// take precedence over the remapping derived for the same prefix.
+	for _, remapping := range settings.Remappings {
+		key, translated, ok := toReturn.translateRemapping(remapping)
+		if ok {
+			remappings[key] = translated
+		}
+	}
+
+	for _, remapping := range remappings {
+		toReturn.remappings = append(toReturn.remappings, remapping)
+	}
+	sort.Strings(toReturn.remappings)
+
+	for name, address := range settings.Libraries {
+		file, library := splitFullyQualifiedName(name)
+		if file == "" {
+			continue
+		}
+		sanitized, err := SanitizeSourcePath(file)
+		if err != nil {
+			return nil, err
+		}
+		toReturn.libraries = append(toReturn.libraries, fmt.Sprintf("%s/%s:%s:%s", toReturn.sourcesDir, sanitized, library, address))
+	}
+	sort.Strings(toReturn.libraries)
+
+	return toReturn, nil
+}
+
+// translateRemapping moves the context and target of a `[context:]prefix=target` remapping into the sources
+// directory. It returns the context and prefix identifying the remapping, and false when it cannot be translated.
+func (c *projectConfig) translateRemapping(remapping string) (string, string, bool) {
+	context := ""
+	rest := remapping
+	if colon, equals := strings.Index(remapping, ":"), strings.Index(remapping, "="); colon >= 0 && colon < equals {
+		context, rest = remapping[:colon], remapping[colon+1:]
+	}
+
+	prefix, target, ok := strings.Cut(rest, "=")
+	if !ok || prefix == "" {
+		return "", "", false
+	}
+
+	target, ok = c.sourcesPath(target)
+	if !ok {
+		return "", "", false
+	}
+
+	key := prefix
+	if context != "" {
+		if context, ok = c.sourcesPath(context); !ok {
+			return "", "", false
+		}
+		key = context + ":" + prefix
+	}
+	return key, key + "=" + target, true
+}
+
+// sourcesPath returns the path of a source path prefix inside the sources directory, keeping its trailing slash.
+func (c *projectConfig) sourcesPath(prefix string) (string, bool) {
+	sanitized, err := SanitizeSourcePath(prefix)
+	if err != nil {
+		return "", false
+	}
+
+	toReturn := c.sourcesDir + "/" + sanitized
+	if strings.HasSuffix(prefix, "/") {
+		toReturn += "/"
+	}
+	return toReturn, true
+}
+
+// foundryToml renders the `foundry.toml` of the scaffolded Foundry project.
+func (c *projectConfig) foundryToml() []byte {
+	var sb strings.Builder
+	sb.WriteString("[profile.default]\n")
+	sb.WriteString(fmt.Sprintf("src = %q\n", c.sourcesDir))
+	sb.WriteString("out = \"out\"\n")
+	sb.WriteString("libs = []\n")
+	sb.WriteString("auto_detect_remappings = false\n")
+	sb.WriteString(fmt.Sprintf("solc_version = %q\n", c.solcVersion))
+	if c.evmVersion != "" {
+		sb.WriteString(fmt.Sprintf("evm_version = %q\n", c.evmVersion))
+	}
+	sb.WriteString(fmt.Sprintf("optimizer = %t\n", c.optimizer.Enabled))
+	sb.WriteString(fmt.Sprintf("optimizer_runs = %d\n", c.optimizer.Runs))
+	if c.viaIR {
+		sb.WriteString("via_ir = true\n")
+	}
+	if c.bytecodeHash != "" {
+		sb.WriteString(fmt.Sprintf("bytecode_hash = %q\n", c.bytecodeHash))
+	}
+	if len(c.libraries) > 0 {
+		sb.WriteString(fmt.Sprintf("libraries = [%s]\n", quoteList(c.libraries)))
+	}
+	return []byte(sb.String())
+}
+
+// hardhatPackageJSON is the `package.json` of the scaffolded Hardhat project, installing Hardhat and the plugin
+// resolving imports through `remappings.txt`.
+const hardhatPackageJSON = `{
+  "private": true,
+  "devDependencies": {
+    "@nomicfoundation/hardhat-foundry": "^1.1.0",
+    "hardhat": "^2.22.0"
+  }
+}
+`
+
+// hardhatConfig renders the `hardhat.config.js` of the scaffolded Hardhat project.
+func (c *projectConfig) hardhatConfig() ([]byte, error) {
+	settings := map[string]any{
+		"optimizer": map[string]any{
+			"enabled": c.optimizer.Enabled,
+			"runs":    c.optimizer.Runs,
+		},
+	}
+	if c.optimizer.Details != nil {
+		settings["optimizer"].(map[string]any)["details"] = c.optimizer.Details
+	}
+	if c.evmVersion != "" {
+		settings["evmVersion"] = c.evmVersion
+	}
+	if c.viaIR {
+		settings["viaIR"] = true
+	}
+	if c.bytecodeHash != "" {
+		settings["metadata"] = map[string]any{"bytecodeHash": c.bytecodeHash}
+	}
+
+	solidity := map[string]any{
+		"version":  c.solcVersion,
+		"settings": settings,
+	}
+
+	// encoding/json sorts map keys, which keeps the generated file stable.
+	encoded, err := json.MarshalIndent(solidity, "  ", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Hardhat configuration: %w", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("require(\"@nomicfoundation/hardhat-foundry\");\n\n")
+	sb.WriteString("/** @type import('hardhat/config').HardhatUserConfig */\n")
+	sb.WriteString("module.exports = {\n")
+	sb.WriteString(fmt.Sprintf("  solidity: %s,\n", encoded))
+	sb.WriteString(fmt.Sprintf("  paths: {\n    sources: %q,\n  },\n", "./"+c.sourcesDir))
+	sb.WriteString("};\n")
+	return []byte(sb.String()), nil
+}
+
+// canonicalMetadata encodes the metadata the way solc does, as compact JSON with sorted keys.
+func canonicalMetadata(metadata *Metadata) ([]byte, error) {
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	// Decoding into generic values and encoding them again sorts the keys of every object.
+	var generic any
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	var toReturn bytes.Buffer
+	encoder := json.NewEncoder(&toReturn)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(generic); err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	return bytes.TrimSuffix(toReturn.Bytes(), []byte("\n")), nil
+}
+
+// quoteList renders the values as a comma separated list of quoted strings.
+func quoteList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, fmt.Sprintf("%q", value))
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,150 @@
+package sourcify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeSourcePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+		err      bool
+	}{
+		{path: "contracts/Token.sol", expected: "contracts/Token.sol"},
+		{path: "@openzeppelin/contracts/token/ERC20/ERC20.sol", expected: "@openzeppelin/contracts/token/ERC20/ERC20.sol"},
+		{path: "/home/user/project/Token.sol", expected: "home/user/project/Token.sol"},
+		{path: "../../etc/passwd", expected: "etc/passwd"},
+		{path: "contracts/../../../Token.sol", expected: "Token.sol"},
+		{path: `C:\Users\dev\Token.sol`, expected: "Users/dev/Token.sol"},
+		{path: "./Token.sol", expected: "Token.sol"},
+		{path: "..", err: true},
+		{path: "", err: true},
+		{path: "Token\x00.sol", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			sanitized, err := SanitizeSourcePath(tt.path)
+			if tt.err {
+				assert.ErrorIs(t, err, ErrUnsafePath)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sanitized)
+		})
+	}
+}
+
+func TestExportContract(t *testing.T) {
+	contract, err := LoadContract(1, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	files, err := ExportContract(dir, contract)
+	require.NoError(t, err)
+	assert.Contains(t, files, "TetherToken.sol")
+	assert.Contains(t, files, ExportMetadataFile)
+
+	content, err := os.ReadFile(filepath.Join(dir, "TetherToken.sol"))
+	require.NoError(t, err)
+	assert.Equal(t, contract.Sources["TetherToken.sol"].Content, string(content))
+
+	_, err = ExportContract(t.TempDir(), &ContractResponse{})
+	assert.ErrorIs(t, err, ErrNoSources)
+}
+
+func TestExportContract_Foundry(t *testing.T) {
+	contract := &ContractResponse{
+		Sources: Sources{
+			"contracts/Token.sol":               {Content: "contract Token {}"},
+			"@openzeppelin/contracts/ERC20.sol": {Content: "contract ERC20 {}"},
+			"../../outside/Evil.sol":            {Content: "contract Evil {}"},
+			"lib/foo/src/Foo.sol":               {Content: "contract Foo {}"},
+		},
+		Metadata: Metadata{
+			Compiler: Compiler{Version: "0.8.20+commit.a1b79de6"},
+			Settings: Settings{
+				EvmVersion: "paris",
+				Optimizer:  Optimizer{Enabled: true, Runs: 200},
+				Metadata:   MetadataDetail{BytecodeHash: "ipfs"},
+				Libraries:  Libraries{"contracts/Math.sol:Math": "0x0000000000000000000000000000000000000001"},
+				Remappings: []string{"foo/=lib/foo/src/", "@openzeppelin/=lib/oz/", "contracts:bar/=lib/bar/"},
+			},
+		},
+		StdJSONInput: StdJSONInput{
+			Language: "Solidity",
+			Sources:  map[string]ContentReference{"contracts/Token.sol": {Content: "contract Token {}"}},
+		},
+	}
+
+	dir := t.TempDir()
+	files, err := ExportContract(dir, contract, WithProjectLayout(ProjectLayoutFoundry))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"foundry.toml",
+		ExportMetadataFile,
+		"remappings.txt",
+		"src/@openzeppelin/contracts/ERC20.sol",
+		"src/contracts/Token.sol",
+		"src/lib/foo/src/Foo.sol",
+		"src/outside/Evil.sol",
+		ExportStdJSONInputFile,
+	}, files)
+
+	foundry, err := os.ReadFile(filepath.Join(dir, "foundry.toml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(foundry), `solc_version = "0.8.20"`)
+	assert.Contains(t, string(foundry), `evm_version = "paris"`)
+	assert.Contains(t, string(foundry), "optimizer = true\noptimizer_runs = 200")
+	assert.Contains(t, string(foundry), `libraries = ["src/contracts/Math.sol:Math:0x0000000000000000000000000000000000000001"]`)
+
+	remappings, err := os.ReadFile(filepath.Join(dir, "remappings.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "@openzeppelin/=src/lib/oz/\ncontracts/=src/contracts/\nfoo/=src/lib/foo/src/\nlib/=src/lib/\noutside/=src/outside/\nsrc/contracts:bar/=src/lib/bar/\n", string(remappings))
+
+	// Without the original metadata file, the metadata is encoded the way solc does.
+	metadata, err := os.ReadFile(filepath.Join(dir, ExportMetadataFile))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(metadata), `{"compiler":{"version":"0.8.20+commit.a1b79de6"},"language":""`), string(metadata))
+
+	dir = t.TempDir()
+	original := []byte(`{"compiler":{"version":"0.8.20+commit.a1b79de6"}, "language":"Solidity"}`)
+	files, err = ExportContract(dir, contract, WithProjectLayout(ProjectLayoutHardhat), WithMetadataFile(original))
+	require.NoError(t, err)
+	assert.Contains(t, files, "package.json")
+	assert.Contains(t, files, "foundry.toml")
+
+	metadata, err = os.ReadFile(filepath.Join(dir, ExportMetadataFile))
+	require.NoError(t, err)
+	assert.Equal(t, original, metadata)
+
+	hardhat, err := os.ReadFile(filepath.Join(dir, "hardhat.config.js"))
+	require.NoError(t, err)
+	assert.Contains(t, string(hardhat), `require("@nomicfoundation/hardhat-foundry");`)
+	assert.Contains(t, string(hardhat), `"version": "0.8.20"`)
+	assert.Contains(t, string(hardhat), `sources: "./contracts"`)
+	assert.FileExists(t, filepath.Join(dir, "contracts", "contracts", "Token.sol"))
+}
+
+func TestExportSourceCodes(t *testing.T) {
+	codes := &SourceCodes{
+		Status: "full",
+		Code: []SourceCode{
+			{Name: "metadata.json", Path: "/data/repository/contracts/full_match/1/0xabc/metadata.json", Content: "{}"},
+			{Name: "Token.sol", Path: "/data/repository/contracts/full_match/1/0xabc/sources/contracts/Token.sol", Content: "contract Token {}"},
+		},
+	}
+
+	dir := t.TempDir()
+	files, err := ExportSourceCodes(dir, codes)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"contracts/Token.sol", "metadata.json"}, files)
+	assert.FileExists(t, filepath.Join(dir, "contracts", "Token.sol"))
+}
@@ -0,0 +1,389 @@
+package sourcify
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrImportUnresolved is returned when imports cannot be resolved unambiguously against the available sources.
+	ErrImportUnresolved = errors.New("import resolution failed")
+
+	// ErrCompilationTargetMissing is returned when the compilation target cannot be determined.
+	ErrCompilationTargetMissing = errors.New("compilation target missing")
+)
+
+var (
+	// importStatement matches the path of every form of Solidity import statement.
+	importStatement = regexp.MustCompile(`\bimport\s+(?:[^;"']*?\s*\bfrom\s*)?["']([^"']+)["']\s*(?:as\s+\w+\s*)?;`)
+
+	// pragmaStatement matches pragma directives such as `pragma solidity ^0.8.0;`.
+	pragmaStatement = regexp.MustCompile(`\bpragma\s+[^;]+;`)
+
+	// importAlias matches aliased imports whose symbols would be renamed by the compiler.
+	importAlias = regexp.MustCompile(`\*\s*as\s+\w+|\bas\s+\w+`)
+)
+
+// ImportIssueKind describes why an import could not be flattened cleanly.
+type ImportIssueKind string
+
+const (
+	// ImportIssueUnresolved denotes an import that does not match any available source.
+	ImportIssueUnresolved ImportIssueKind = "unresolved"
+
+	// ImportIssueAmbiguous denotes an import that matches more than one available source.
+	ImportIssueAmbiguous ImportIssueKind = "ambiguous"
+
+	// ImportIssueAlias denotes an import that renames symbols, which a flattened file cannot preserve.
+	ImportIssueAlias ImportIssueKind = "alias"
+)
+
+// ImportIssue represents a single problem found while resolving the imports of a source.
+type ImportIssue struct {
+	Kind       ImportIssueKind `json:"kind"`
+	File       string          `json:"file"`                 // Source containing the import.
+	Import     string          `json:"import"`               // Import path as written.
+	Candidates []string        `json:"candidates,omitempty"` // Sources the import could refer to.
+}
+
+// String returns a human readable description of the issue.
+func (i ImportIssue) String() string {
+	switch i.Kind {
+	case ImportIssueAmbiguous:
+		return fmt.Sprintf("%s: import %q is ambiguous, candidates: %s", i.File, i.Import, strings.Join(i.Candidates, ", "))
+	case ImportIssueAlias:
+		return fmt.Sprintf("%s: import %q renames symbols, the flattened source may not compile", i.File, i.Import)
+	default:
+		return fmt.Sprintf("%s: import %q does not match any source", i.File, i.Import)
+	}
+}
+
+// ImportResolutionError reports every import that could not be resolved. It unwraps to ErrImportUnresolved.
+type ImportResolutionError struct {
+	Issues []ImportIssue
+}
+
+func (e *ImportResolutionError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		messages = append(messages, issue.String())
+	}
+	return fmt.Sprintf("%s: %s", ErrImportUnresolved, strings.Join(messages, "; "))
+}
+
+func (e *ImportResolutionError) Unwrap() error {
+	return ErrImportUnresolved
+}
+
+// FlattenResult represents a flattened source.
+type FlattenResult struct {
+	Target   string        `json:"target"`   // Source the flattening started from.
+	Source   string        `json:"source"`   // Flattened Solidity source.
+	Files    []string      `json:"files"`    // Sources included, in dependency order.
+	Licenses []string      `json:"licenses"` // Distinct SPDX license identifiers found.
+	Pragmas  []string      `json:"pragmas"`  // Distinct pragma directives found.
+	Issues   []ImportIssue `json:"issues"`   // Non fatal issues, such as aliased imports.
+}
+
+// ImportResolver resolves Solidity import paths to the names of the available sources the way the compiler
+// does: relative imports against the importing file, everything else through the remappings.
+type ImportResolver struct {
+	sources    Sources
+	remappings []remapping
+}
+
+// remapping represents a single `context:prefix=target` compiler remapping.
+type remapping struct {
+	context string
+	prefix  string
+	target  string
+}
+
+// NewImportResolver creates an import resolver over the sources using the given compiler remappings.
+func NewImportResolver(sources Sources, remappings []string) *ImportResolver {
+	toReturn := &ImportResolver{sources: sources}
+	for _, raw := range remappings {
+		prefix, target, ok := strings.Cut(raw, "=")
+		if !ok {
+			continue
+		}
+
+		var context string
+		if index := strings.Index(prefix, ":"); index >= 0 {
+			context, prefix = prefix[:index], prefix[index+1:]
+		}
+		toReturn.remappings = append(toReturn.remappings, remapping{context: context, prefix: prefix, target: target})
+	}
+	return toReturn
+}
+
+// Resolve returns the name of the source imported by importPath from file. When the import matches no source,
+// or more than one, the returned issue describes why and the name is empty.
+func (r *ImportResolver) Resolve(file string, importPath string) (string, *ImportIssue) {
+	var candidates []string
+	addCandidate := func(name string) {
+		if _, ok := r.sources[name]; !ok {
+			return
+		}
+		for _, candidate := range candidates {
+			if candidate == name {
+				return
+			}
+		}
+		candidates = append(candidates, name)
+	}
+
+	if strings.HasPrefix(importPath, "./") || strings.HasPrefix(importPath, "../") {
+		addCandidate(path.Join(path.Dir(file), importPath))
+	} else {
+		// The compiler only considers the remapped name once a remapping applies.
+		addCandidate(r.remap(file, importPath))
+	}
+
+	// Sources may be stored under absolute or otherwise prefixed names, fall back to a unique suffix match.
+	if len(candidates) == 0 {
+		suffix := "/" + strings.TrimLeft(path.Clean(importPath), "./")
+		for name := range r.sources {
+			if strings.HasSuffix(name, suffix) {
+				candidates = append(candidates, name)
+			}
+		}
+		sort.Strings(candidates)
+	}
+
+	switch len(candidates) {
+	case 1:
+		return candidates[0], nil
+	case 0:
+		return "", &ImportIssue{Kind: ImportIssueUnresolved, File: file, Import: importPath}
+	default:
+		return "", &ImportIssue{Kind: ImportIssueAmbiguous, File: file, Import: importPath, Candidates: candidates}
+	}
+}
+
+// remap applies the longest matching remapping whose context applies to file.
+func (r *ImportResolver) remap(file string, importPath string) string {
+	var best *remapping
+	for i, candidate := range r.remappings {
+		if !strings.HasPrefix(file, candidate.context) || !strings.HasPrefix(importPath, candidate.prefix) {
+			continue
+		}
+		if best == nil || len(candidate.context) > len(best.context) ||
+			(len(candidate.context) == len(best.context) && len(candidate.prefix) > len(best.prefix)) {
+			best = &r.remappings[i]
+		}
+	}
+
+	if best == nil {
+		return importPath
+	}
+	return best.target + strings.TrimPrefix(importPath, best.prefix)
+}
+
+// sourceImport represents an import statement found in a source.
+type sourceImport struct {
+	path    string
+	aliased bool
+	start   int
+	end     int
+}
+
+// parseImports returns the import statements of the source, ignoring those in comments and strings.
+func parseImports(source string) []sourceImport {
+	masked := maskComments(source)
+
+	var toReturn []sourceImport
+	for _, match := range importStatement.FindAllStringSubmatchIndex(masked, -1) {
+		statement := masked[match[0]:match[1]]
+		toReturn = append(toReturn, sourceImport{
+			path:    source[match[2]:match[3]],
+			aliased: importAlias.MatchString(statement),
+			start:   match[0],
+			end:     match[1],
+		})
+	}
+	return toReturn
+}
+
+// Flatten merges the target source and everything it imports into a single Solidity source. Sources are emitted in
+// dependency order, each once, with their import statements removed. SPDX license identifiers and pragma directives
+// are deduplicated and hoisted to the top; distinct licenses are combined with `AND`.
+// When an import cannot be resolved or is ambiguous, an *ImportResolutionError listing every such import is returned.
+func Flatten(sources Sources, target string, remappings []string) (*FlattenResult, error) {
+	if _, ok := sources[target]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSourceMissing, target)
+	}
+
+	resolver := NewImportResolver(sources, remappings)
+	toReturn := &FlattenResult{Target: target, Issues: []ImportIssue{}}
+
+	var failed []ImportIssue
+	visited := make(map[string]bool)
+
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+
+		for _, imported := range parseImports(sources[name].Content) {
+			if imported.aliased {
+				toReturn.Issues = append(toReturn.Issues, ImportIssue{Kind: ImportIssueAlias, File: name, Import: imported.path})
+			}
+
+			resolved, issue := resolver.Resolve(name, imported.path)
+			if issue != nil {
+				failed = append(failed, *issue)
+				continue
+			}
+			visit(resolved)
+		}
+
+		toReturn.Files = append(toReturn.Files, name)
+	}
+	visit(target)
+
+	if len(failed) > 0 {
+		return nil, &ImportResolutionError{Issues: failed}
+	}
+
+	seenLicenses := make(map[string]bool)
+	seenPragmas := make(map[string]bool)
+	bodies := make([]string, 0, len(toReturn.Files))
+
+	for _, name := range toReturn.Files {
+		content := sources[name].Content
+		masked := maskComments(content)
+
+		var removed [][2]int
+		for _, comment := range findLicenseComments(content) {
+			if comment.start == comment.end {
+				continue
+			}
+			if comment.license != "" && !seenLicenses[comment.license] {
+				seenLicenses[comment.license] = true
+				toReturn.Licenses = append(toReturn.Licenses, comment.license)
+			}
+			removed = append(removed, [2]int{comment.start, comment.end})
+		}
+
+		for _, match := range pragmaStatement.FindAllStringIndex(masked, -1) {
+			pragma := strings.Join(strings.Fields(content[match[0]:match[1]]), " ")
+			if !seenPragmas[pragma] {
+				seenPragmas[pragma] = true
+				toReturn.Pragmas = append(toReturn.Pragmas, pragma)
+			}
+			removed = append(removed, [2]int{match[0], match[1]})
+		}
+
+		for _, imported := range parseImports(content) {
+			removed = append(removed, [2]int{imported.start, imported.end})
+		}
+
+		bodies = append(bodies, fmt.Sprintf("// File: %s\n\n%s\n", name, strings.TrimSpace(removeRanges(content, removed))))
+	}
+
+	var sb strings.Builder
+	if len(toReturn.Licenses) > 0 {
+		combined := make([]string, 0, len(toReturn.Licenses))
+		for _, license := range toReturn.Licenses {
+			if len(toReturn.Licenses) > 1 && strings.Contains(license, " ") {
+				license = "(" + license + ")"
+			}
+			combined = append(combined, license)
+		}
+		sb.WriteString("// SPDX-License-Identifier: " + strings.Join(combined, " AND ") + "\n")
+	}
+	for _, pragma := range toReturn.Pragmas {
+		sb.WriteString(pragma + "\n")
+	}
+	for _, body := range bodies {
+		sb.WriteString("\n" + body)
+	}
+	toReturn.Source = sb.String()
+
+	return toReturn, nil
+}
+
+// Flatten flattens the compilation target of the contract using its verified sources and remappings.
+func (c *ContractResponse) Flatten() (*FlattenResult, error) {
+	target := ""
+	for file := range c.Metadata.Settings.CompilationTarget {
+		target = file
+	}
+
+	if target == "" {
+		if file, _ := splitFullyQualifiedName(c.Compilation.FullyQualifiedName); file != "" {
+			target = file
+		}
+	}
+
+	if target == "" {
+		return nil, ErrCompilationTargetMissing
+	}
+
+	return Flatten(c.Sources, target, c.Metadata.Settings.Remappings)
+}
+
+// removeRanges returns the source without the given, non overlapping, byte ranges.
+func removeRanges(source string, ranges [][2]int) string {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i][0] < ranges[j][0]
+	})
+
+	var sb strings.Builder
+	last := 0
+	for _, r := range ranges {
+		if r[0] < last {
+			continue
+		}
+		sb.WriteString(source[last:r[0]])
+		last = r[1]
+	}
+	sb.WriteString(source[last:])
+	return sb.String()
+}
+
+// maskComments returns the source with comments and string literal contents replaced by spaces, keeping byte offsets
+// and line breaks intact so that matches on the result can be mapped back to the source.
+func maskComments(source string) string {
+	masked := []byte(source)
+
+	for i := 0; i < len(masked); i++ {
+		switch {
+		case masked[i] == '/' && i+1 < len(masked) && masked[i+1] == '/':
+			for ; i < len(masked) && masked[i] != '\n'; i++ {
+				masked[i] = ' '
+			}
+		case masked[i] == '/' && i+1 < len(masked) && masked[i+1] == '*':
+			end := strings.Index(source[i+2:], "*/")
+			stop := len(masked)
+			if end >= 0 {
+				stop = i + 2 + end + 2
+			}
+			for ; i < stop; i++ {
+				if masked[i] != '\n' {
+					masked[i] = ' '
+				}
+			}
+			i--
+		case masked[i] == '"' || masked[i] == '\'':
+			// Keep the quotes so that import statements remain matchable, the path is read from the source.
+			quote := masked[i]
+			for i++; i < len(masked) && masked[i] != quote && masked[i] != '\n'; i++ {
+				if masked[i] == '\\' && i+1 < len(masked) {
+					masked[i] = ' '
+					i++
+				}
+				masked[i] = ' '
+			}
+		}
+	}
+
+	return string(masked)
+}
@@ -0,0 +1,128 @@
+package sourcify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFlattenSources() Sources {
+	return Sources{
+		"contracts/Token.sol": {Content: `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+import "@openzeppelin/contracts/token/ERC20/ERC20.sol";
+import {Ownable} from "@openzeppelin/contracts/access/Ownable.sol";
+import "./lib/Math.sol";
+
+// import "./Ignored.sol";
+contract Token is ERC20, Ownable {
+    string constant NOTE = "import 'x.sol';";
+}
+`},
+		"contracts/lib/Math.sol": {Content: `// SPDX-License-Identifier: GPL-3.0
+pragma solidity ^0.8.20;
+
+library Math {}
+`},
+		"lib/openzeppelin-contracts/contracts/token/ERC20/ERC20.sol": {Content: `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+import {Context} from "../../utils/Context.sol";
+
+abstract contract ERC20 is Context {}
+`},
+		"lib/openzeppelin-contracts/contracts/access/Ownable.sol": {Content: `// SPDX-License-Identifier: MIT
+pragma   solidity   ^0.8.20;
+
+import {Context} from "../utils/Context.sol";
+
+abstract contract Ownable is Context {}
+`},
+		"lib/openzeppelin-contracts/contracts/utils/Context.sol": {Content: `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+abstract contract Context {}
+`},
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	result, err := Flatten(testFlattenSources(), "contracts/Token.sol", []string{"@openzeppelin/=lib/openzeppelin-contracts/"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"lib/openzeppelin-contracts/contracts/utils/Context.sol",
+		"lib/openzeppelin-contracts/contracts/token/ERC20/ERC20.sol",
+		"lib/openzeppelin-contracts/contracts/access/Ownable.sol",
+		"contracts/lib/Math.sol",
+		"contracts/Token.sol",
+	}, result.Files)
+	assert.Equal(t, []string{"MIT", "GPL-3.0"}, result.Licenses)
+	assert.Equal(t, []string{"pragma solidity ^0.8.20;"}, result.Pragmas)
+	assert.Empty(t, result.Issues)
+
+	assert.Contains(t, result.Source, "// SPDX-License-Identifier: MIT AND GPL-3.0\npragma solidity ^0.8.20;\n")
+	assert.NotContains(t, result.Source, "import {")
+	assert.NotContains(t, result.Source, `import "./lib`)
+	assert.Contains(t, result.Source, `// import "./Ignored.sol";`)
+	assert.Contains(t, result.Source, `string constant NOTE = "import 'x.sol';";`)
+	assert.Less(t, strings.Index(result.Source, "abstract contract Context"), strings.Index(result.Source, "abstract contract ERC20"))
+}
+
+func TestFlatten_ImportErrors(t *testing.T) {
+	sources := testFlattenSources()
+	sources["Wrapper.sol"] = SourceContent{Content: "import \"utils/Context.sol\";\ncontract Wrapper {}\n"}
+	sources["other/utils/Context.sol"] = SourceContent{Content: "contract Context {}"}
+
+	// The import only matches by suffix, and two sources qualify.
+	_, err := Flatten(sources, "Wrapper.sol", nil)
+	require.ErrorIs(t, err, ErrImportUnresolved)
+
+	var resolutionErr *ImportResolutionError
+	require.True(t, errors.As(err, &resolutionErr))
+	require.Len(t, resolutionErr.Issues, 1)
+	assert.Equal(t, ImportIssueAmbiguous, resolutionErr.Issues[0].Kind)
+	assert.Equal(t, []string{"lib/openzeppelin-contracts/contracts/utils/Context.sol", "other/utils/Context.sol"}, resolutionErr.Issues[0].Candidates)
+
+	delete(sources, "other/utils/Context.sol")
+	result, err := Flatten(sources, "Wrapper.sol", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lib/openzeppelin-contracts/contracts/utils/Context.sol", "Wrapper.sol"}, result.Files)
+
+	delete(sources, "contracts/lib/Math.sol")
+	_, err = Flatten(sources, "contracts/Token.sol", []string{"@openzeppelin/=lib/openzeppelin-contracts/"})
+	require.True(t, errors.As(err, &resolutionErr))
+	assert.Equal(t, ImportIssueUnresolved, resolutionErr.Issues[0].Kind)
+	assert.Equal(t, "./lib/Math.sol", resolutionErr.Issues[0].Import)
+}
+
+func TestFlatten_Alias(t *testing.T) {
+	sources := Sources{
+		"A.sol": {Content: "import * as B from \"./B.sol\";\ncontract A {}\n"},
+		"B.sol": {Content: "contract B {}\n"},
+	}
+
+	result, err := Flatten(sources, "A.sol", nil)
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, ImportIssueAlias, result.Issues[0].Kind)
+}
+
+func TestContractResponse_Flatten(t *testing.T) {
+	contract, err := LoadContract(1, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	require.NoError(t, err)
+
+	result, err := contract.Flatten()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TetherToken.sol"}, result.Files)
+	assert.Contains(t, result.Source, "contract TetherToken")
+	assert.Equal(t, []string{"pragma solidity ^0.4.17;"}, result.Pragmas)
+
+	_, err = (&ContractResponse{}).Flatten()
+	assert.ErrorIs(t, err, ErrCompilationTargetMissing)
+}
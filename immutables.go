@@ -0,0 +1,160 @@
+package sourcify
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ImmutableReferences maps the AST id of every immutable variable to the positions its value
+// is placed at in the runtime bytecode, as found in the compiler output.
+type ImmutableReferences map[string][]CodeReference
+
+// AstIDs returns the AST ids of all immutable variables in ascending numeric order.
+func (r ImmutableReferences) AstIDs() []string {
+	toReturn := make([]string, 0, len(r))
+	for astId := range r {
+		toReturn = append(toReturn, astId)
+	}
+
+	sort.Slice(toReturn, func(i, j int) bool {
+		a, aErr := strconv.Atoi(toReturn[i])
+		b, bErr := strconv.Atoi(toReturn[j])
+		if aErr != nil || bErr != nil {
+			return toReturn[i] < toReturn[j]
+		}
+		return a < b
+	})
+
+	return toReturn
+}
+
+// Values extracts the value of every immutable variable from the given runtime bytecode, keyed by AST id.
+// It returns an error when a reference is out of bounds or the references of a variable hold different values.
+func (r ImmutableReferences) Values(code []byte) (map[string][]byte, error) {
+	toReturn := make(map[string][]byte, len(r))
+	for astId, references := range r {
+		for _, reference := range references {
+			end := reference.Start + reference.Length
+			if reference.Start < 0 || end > len(code) {
+				return nil, fmt.Errorf("immutable reference %s at %d is out of bounds", astId, reference.Start)
+			}
+
+			value := code[reference.Start:end]
+			if existing, ok := toReturn[astId]; ok && !bytes.Equal(existing, value) {
+				return nil, fmt.Errorf("immutable reference %s holds different values at different positions", astId)
+			}
+			toReturn[astId] = value
+		}
+	}
+	return toReturn, nil
+}
+
+// ImmutableValue represents the value of an immutable variable as deployed on chain.
+type ImmutableValue struct {
+	AstID     string          `json:"astId"`               // AST id of the variable declaration.
+	Name      string          `json:"name,omitempty"`      // Name of the variable, empty when it could not be determined.
+	Type      string          `json:"type,omitempty"`      // Solidity type of the variable, empty when it could not be determined.
+	Value     []byte          `json:"value"`               // Raw 32 byte word holding the value.
+	Positions []CodeReference `json:"positions,omitempty"` // Positions of the value in the runtime bytecode.
+}
+
+// Hex returns the raw value as a 0x prefixed hex string.
+func (v ImmutableValue) Hex() string {
+	return "0x" + hex.EncodeToString(v.Value)
+}
+
+// Address interprets the value as an address.
+func (v ImmutableValue) Address() common.Address {
+	return common.BytesToAddress(v.Value)
+}
+
+// Uint interprets the value as an unsigned integer.
+func (v ImmutableValue) Uint() *big.Int {
+	return new(big.Int).SetBytes(v.Value)
+}
+
+// Bool interprets the value as a boolean.
+func (v ImmutableValue) Bool() bool {
+	return v.Uint().Sign() != 0
+}
+
+// immutableDeclaration matches immutable state variable declarations such as `address public immutable owner`.
+var immutableDeclaration = regexp.MustCompile(`([A-Za-z_][\w.]*(?:\s*\[\s*\d*\s*\])*)\s+(?:(?:public|private|internal|override)\s+)*immutable\s+(?:(?:public|private|internal|override)\s+)*([A-Za-z_]\w*)`)
+
+// ImmutableValues extracts the values of all immutable variables from the onchain runtime bytecode.
+// Variable names are recovered through the runtime source map, which maps the instruction pushing
+// the value to the identifier it was read through, and types through the variable declarations in
+// the verified sources. Name and type are left empty when they cannot be determined.
+func (c *ContractResponse) ImmutableValues() ([]ImmutableValue, error) {
+	references := c.RuntimeBytecode.ImmutableReferences
+	if len(references) == 0 {
+		references = c.compilationTargetOutput().Evm.DeployedBytecode.ImmutableReferences
+	}
+	if len(references) == 0 {
+		return []ImmutableValue{}, nil
+	}
+
+	code, err := decodeHex(zeroPlaceholders(c.RuntimeBytecode.OnchainBytecode))
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := references.Values(code)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]string)
+	for _, source := range c.Sources {
+		for _, match := range immutableDeclaration.FindAllStringSubmatch(source.Content, -1) {
+			types[match[2]] = match[1]
+		}
+	}
+
+	// The source resolver is optional, values are still returned without names when it is unavailable.
+	resolver, _ := c.NewRuntimeSourceResolver()
+
+	toReturn := make([]ImmutableValue, 0, len(references))
+	for _, astId := range references.AstIDs() {
+		value := ImmutableValue{
+			AstID:     astId,
+			Value:     values[astId],
+			Positions: references[astId],
+		}
+
+		if resolver != nil {
+			for _, reference := range references[astId] {
+				// The reference points at the immediate data, the PUSH32 instruction precedes it.
+				location, err := resolver.Resolve(reference.Start - 1)
+				if err != nil {
+					continue
+				}
+
+				name := strings.TrimSpace(location.Snippet(c.Sources))
+				if _, ok := types[name]; ok {
+					value.Name = name
+					value.Type = types[name]
+					break
+				}
+			}
+		}
+
+		toReturn = append(toReturn, value)
+	}
+
+	return toReturn, nil
+}
+
+// compilationTargetOutput returns the compiler output of the contract's compilation target.
+func (c *ContractResponse) compilationTargetOutput() ContractOutput {
+	file, name := splitFullyQualifiedName(c.Compilation.FullyQualifiedName)
+	return c.StdJSONOutput.Contracts[file][name]
+}
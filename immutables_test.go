@@ -0,0 +1,87 @@
+package sourcify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmutableReferences_UnmarshalJSON(t *testing.T) {
+	var bytecode EVMDeployedBytecode
+	require.NoError(t, json.Unmarshal([]byte(`{"immutableReferences": {"12": [{"start": 1, "length": 32}, {"start": 70, "length": 32}], "3": [{"start": 40, "length": 32}]}}`), &bytecode))
+
+	assert.Equal(t, ImmutableReferences{
+		"12": {{Start: 1, Length: 32}, {Start: 70, Length: 32}},
+		"3":  {{Start: 40, Length: 32}},
+	}, bytecode.ImmutableReferences)
+	assert.Equal(t, []string{"3", "12"}, bytecode.ImmutableReferences.AstIDs())
+}
+
+func TestImmutableReferences_Values(t *testing.T) {
+	code := make([]byte, 66)
+	code[32] = 0x01
+	code[65] = 0x02
+
+	values, err := ImmutableReferences{"1": {{Start: 1, Length: 32}}, "2": {{Start: 34, Length: 32}}}.Values(code)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x01), values["1"][31])
+	assert.Equal(t, byte(0x02), values["2"][31])
+
+	_, err = ImmutableReferences{"1": {{Start: 1, Length: 32}, {Start: 34, Length: 32}}}.Values(code)
+	assert.Error(t, err)
+
+	_, err = ImmutableReferences{"1": {{Start: 60, Length: 32}}}.Values(code)
+	assert.Error(t, err)
+}
+
+func TestContractResponse_ImmutableValues(t *testing.T) {
+	source := "contract Vault {\n    address public immutable owner;\n    uint256 internal immutable fee;\n\n    function f() external view returns (address) { return owner; }\n}\n"
+	owner := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	// PUSH32 <owner>, PUSH32 <fee>, STOP
+	recompiled := "0x7f" + strings.Repeat("00", 32) + "7f" + strings.Repeat("00", 32) + "00"
+	onchain := "0x7f" + strings.Repeat("00", 12) + strings.TrimPrefix(strings.ToLower(owner.Hex()), "0x") + "7f" + strings.Repeat("00", 31) + "64" + "00"
+
+	contract := &ContractResponse{
+		Sources:   Sources{"Vault.sol": {Content: source}},
+		SourceIds: SourceIds{"Vault.sol": {ID: 0}},
+		RuntimeBytecode: Bytecode{
+			OnchainBytecode:    onchain,
+			RecompiledBytecode: recompiled,
+			SourceMap:          fmt.Sprintf("%d:5:0:-:0;-1:-1:-1;0:10:0", strings.LastIndex(source, "owner")),
+			ImmutableReferences: ImmutableReferences{
+				"3":  {{Start: 1, Length: 32}},
+				"10": {{Start: 34, Length: 32}},
+			},
+		},
+	}
+
+	values, err := contract.ImmutableValues()
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+
+	assert.Equal(t, "3", values[0].AstID)
+	assert.Equal(t, "owner", values[0].Name)
+	assert.Equal(t, "address", values[0].Type)
+	assert.Equal(t, owner, values[0].Address())
+
+	// The second value has no source mapping so only its value is known.
+	assert.Equal(t, "10", values[1].AstID)
+	assert.Empty(t, values[1].Name)
+	assert.Equal(t, int64(100), values[1].Uint().Int64())
+	assert.True(t, values[1].Bool())
+}
+
+func TestContractResponse_ImmutableValuesWithoutReferences(t *testing.T) {
+	contract, err := LoadContract(1, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	require.NoError(t, err)
+
+	values, err := contract.ImmutableValues()
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
@@ -0,0 +1,444 @@
+package sourcify
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// spdxIdentifier matches the license expression of an SPDX license identifier comment in any comment style.
+	spdxIdentifier = regexp.MustCompile(`SPDX-License-Identifier:[ \t]*([^\r\n*]*)`)
+
+	// licenseToken splits a license expression into identifiers, operators and parentheses.
+	licenseToken = regexp.MustCompile(`\(|\)|[^\s()]+`)
+)
+
+// spdxLicenses maps lower cased SPDX identifiers and their common misspellings to the canonical identifier.
+var spdxLicenses = map[string]string{
+	"mit":               "MIT",
+	"mit license":       "MIT",
+	"isc":               "ISC",
+	"0bsd":              "0BSD",
+	"bsd-2-clause":      "BSD-2-Clause",
+	"bsd-3-clause":      "BSD-3-Clause",
+	"apache-2.0":        "Apache-2.0",
+	"apache2":           "Apache-2.0",
+	"apache-2":          "Apache-2.0",
+	"apache2.0":         "Apache-2.0",
+	"unlicense":         "Unlicense",
+	"unlicensed":        "UNLICENSED",
+	"none":              "UNLICENSED",
+	"cc0-1.0":           "CC0-1.0",
+	"cc0":               "CC0-1.0",
+	"wtfpl":             "WTFPL",
+	"mpl-2.0":           "MPL-2.0",
+	"lgpl-2.1":          "LGPL-2.1",
+	"lgpl-2.1-only":     "LGPL-2.1-only",
+	"lgpl-2.1-or-later": "LGPL-2.1-or-later",
+	"lgpl-3.0":          "LGPL-3.0",
+	"lgpl-3.0-only":     "LGPL-3.0-only",
+	"lgpl-3.0-or-later": "LGPL-3.0-or-later",
+	"gpl-2.0":           "GPL-2.0",
+	"gpl-2.0-only":      "GPL-2.0-only",
+	"gpl-2.0-or-later":  "GPL-2.0-or-later",
+	"gpl-2.0+":          "GPL-2.0-or-later",
+	"gpl-3.0":           "GPL-3.0",
+	"gpl-3":             "GPL-3.0",
+	"gplv3":             "GPL-3.0",
+	"gpl-3.0-only":      "GPL-3.0-only",
+	"gpl-3.0-or-later":  "GPL-3.0-or-later",
+	"gpl-3.0+":          "GPL-3.0-or-later",
+	"agpl-3.0":          "AGPL-3.0",
+	"agpl-3.0-only":     "AGPL-3.0-only",
+	"agpl-3.0-or-later": "AGPL-3.0-or-later",
+	"busl-1.1":          "BUSL-1.1",
+	"bsl-1.1":           "BUSL-1.1",
+	"bsl-1.0":           "BSL-1.0",
+}
+
+// LicenseFamily groups licenses by the obligations they impose on combined works.
+type LicenseFamily string
+
+const (
+	// LicenseFamilyPermissive denotes licenses such as MIT or Apache-2.0.
+	LicenseFamilyPermissive LicenseFamily = "permissive"
+
+	// LicenseFamilyWeakCopyleft denotes file or library scoped copyleft licenses such as LGPL or MPL.
+	LicenseFamilyWeakCopyleft LicenseFamily = "weak-copyleft"
+
+	// LicenseFamilyCopyleft denotes licenses such as GPL or AGPL that extend to the combined work.
+	LicenseFamilyCopyleft LicenseFamily = "copyleft"
+
+	// LicenseFamilyProprietary denotes UNLICENSED sources and source available licenses such as BUSL-1.1.
+	LicenseFamilyProprietary LicenseFamily = "proprietary"
+
+	// LicenseFamilyUnknown denotes licenses that could not be classified.
+	LicenseFamilyUnknown LicenseFamily = "unknown"
+)
+
+// LicenseIssueKind describes a licensing problem found in the sources of a contract.
+type LicenseIssueKind string
+
+const (
+	// LicenseIssueMissing denotes a source without any license declaration.
+	LicenseIssueMissing LicenseIssueKind = "missing"
+
+	// LicenseIssueUnknown denotes a license that is not a known SPDX identifier.
+	LicenseIssueUnknown LicenseIssueKind = "unknown"
+
+	// LicenseIssueMismatch denotes a source whose SPDX comment disagrees with the license recorded in the metadata.
+	LicenseIssueMismatch LicenseIssueKind = "mismatch"
+
+	// LicenseIssueConflict denotes licenses that cannot be combined in the same contract.
+	LicenseIssueConflict LicenseIssueKind = "conflict"
+)
+
+// LicenseIssue represents a single licensing problem.
+type LicenseIssue struct {
+	Kind    LicenseIssueKind `json:"kind"`
+	Files   []string         `json:"files"`
+	Message string           `json:"message"`
+}
+
+// FileLicense represents the license of a single source.
+type FileLicense struct {
+	Path     string        `json:"path"`
+	Declared string        `json:"declared,omitempty"` // License expression as written in the SPDX comment.
+	Metadata string        `json:"metadata,omitempty"` // License recorded in the metadata for the source.
+	License  string        `json:"license,omitempty"`  // Normalized license expression.
+	Known    bool          `json:"known"`              // Whether every identifier of the expression is known.
+	Family   LicenseFamily `json:"family"`
+}
+
+// LicenseReport represents the licensing of all sources of a verified contract.
+type LicenseReport struct {
+	Contract string         `json:"contract,omitempty"` // License of the compilation target.
+	Licenses []string       `json:"licenses"`           // Distinct normalized licenses, sorted.
+	Files    []FileLicense  `json:"files"`              // Licenses per source, sorted by path.
+	Issues   []LicenseIssue `json:"issues"`
+}
+
+// NormalizeLicense normalizes an SPDX license expression, e.g. `mit or apache2` becomes `MIT OR Apache-2.0`.
+// It reports false when the expression contains identifiers that are not known SPDX licenses; those are kept as is.
+func NormalizeLicense(expression string) (string, bool) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return "", false
+	}
+
+	if canonical, ok := spdxLicenses[strings.ToLower(expression)]; ok {
+		return canonical, true
+	}
+
+	known := true
+	tokens := licenseToken.FindAllString(expression, -1)
+	for i, token := range tokens {
+		upper := strings.ToUpper(token)
+		switch {
+		case token == "(" || token == ")":
+		case upper == "AND" || upper == "OR" || upper == "WITH":
+			tokens[i] = upper
+		case i > 0 && tokens[i-1] == "WITH":
+			// License exceptions are not normalized.
+		default:
+			if canonical, ok := spdxLicenses[strings.ToLower(token)]; ok {
+				tokens[i] = canonical
+			} else {
+				known = false
+			}
+		}
+	}
+
+	toReturn := strings.Join(tokens, " ")
+	toReturn = strings.ReplaceAll(strings.ReplaceAll(toReturn, "( ", "("), " )", ")")
+	return toReturn, known
+}
+
+// ClassifyLicense returns the family of a normalized license expression. Expressions offering a choice with OR
+// are classified by their most permissive alternative, and those combining licenses with AND by the most
+// restrictive one.
+func ClassifyLicense(license string) LicenseFamily {
+	if license == "" {
+		return LicenseFamilyUnknown
+	}
+
+	if alternatives := strings.Split(license, " OR "); len(alternatives) > 1 {
+		toReturn := LicenseFamilyUnknown
+		for _, alternative := range alternatives {
+			if family := ClassifyLicense(strings.Trim(alternative, "()")); licenseFamilyRank(family) < licenseFamilyRank(toReturn) {
+				toReturn = family
+			}
+		}
+		return toReturn
+	}
+
+	if operands := strings.Split(license, " AND "); len(operands) > 1 {
+		toReturn := LicenseFamilyPermissive
+		for _, operand := range operands {
+			if family := ClassifyLicense(strings.Trim(operand, "()")); licenseFamilyRank(family) > licenseFamilyRank(toReturn) {
+				toReturn = family
+			}
+		}
+		return toReturn
+	}
+
+	identifier, _, _ := strings.Cut(license, " WITH ")
+	switch {
+	case strings.HasPrefix(identifier, "LGPL-"), identifier == "MPL-2.0":
+		return LicenseFamilyWeakCopyleft
+	case strings.HasPrefix(identifier, "GPL-"), strings.HasPrefix(identifier, "AGPL-"):
+		return LicenseFamilyCopyleft
+	case identifier == "UNLICENSED", identifier == "BUSL-1.1":
+		return LicenseFamilyProprietary
+	}
+
+	for _, canonical := range spdxLicenses {
+		if canonical == identifier {
+			return LicenseFamilyPermissive
+		}
+	}
+	return LicenseFamilyUnknown
+}
+
+// licenseFamilyRank orders the families from the least to the most restrictive.
+func licenseFamilyRank(family LicenseFamily) int {
+	switch family {
+	case LicenseFamilyPermissive:
+		return 0
+	case LicenseFamilyWeakCopyleft:
+		return 1
+	case LicenseFamilyCopyleft:
+		return 2
+	case LicenseFamilyProprietary:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// ExtractLicense returns the license expression of the first SPDX license identifier comment in the source.
+func ExtractLicense(source string) (string, bool) {
+	comments := findLicenseComments(source)
+	if len(comments) == 0 {
+		return "", false
+	}
+
+	toReturn := comments[0].license
+	return toReturn, toReturn != ""
+}
+
+// licenseComment is an SPDX license identifier comment found in a source.
+type licenseComment struct {
+	license string
+	// start and end delimit the line holding the comment, newline included. They are equal when the line holds
+	// anything besides the comment, in which case it cannot be removed on its own.
+	start, end int
+}
+
+// findLicenseComments returns the SPDX license identifier comments of the source in order of appearance.
+func findLicenseComments(source string) []licenseComment {
+	var toReturn []licenseComment
+	for _, match := range spdxIdentifier.FindAllStringSubmatchIndex(source, -1) {
+		comment := licenseComment{license: strings.TrimSpace(source[match[2]:match[3]])}
+
+		lineStart := strings.LastIndexByte(source[:match[0]], '\n') + 1
+		lineEnd := len(source)
+		if i := strings.IndexByte(source[match[1]:], '\n'); i >= 0 {
+			lineEnd = match[1] + i + 1
+		}
+
+		prefix := strings.TrimSpace(source[lineStart:match[0]])
+		suffix := strings.TrimSpace(source[match[1]:lineEnd])
+		switch {
+		case prefix == "//" && suffix == "",
+			strings.HasPrefix(prefix, "/*") && suffix == "*/",
+			prefix == "*" && suffix == "":
+			comment.start, comment.end = lineStart, lineEnd
+		}
+
+		toReturn = append(toReturn, comment)
+	}
+	return toReturn
+}
+
+// currentLicense replaces the deprecated GNU identifiers of a normalized license expression by their current
+// equivalent, e.g. GPL-2.0 becomes GPL-2.0-only.
+func currentLicense(license string) string {
+	tokens := strings.Fields(strings.NewReplacer("(", "( ", ")", " )").Replace(license))
+	for i, token := range tokens {
+		switch token {
+		case "GPL-2.0", "GPL-3.0", "LGPL-2.1", "LGPL-3.0", "AGPL-3.0":
+			tokens[i] = token + "-only"
+		}
+	}
+
+	toReturn := strings.Join(tokens, " ")
+	return strings.ReplaceAll(strings.ReplaceAll(toReturn, "( ", "("), " )", ")")
+}
+
+// AnalyzeLicenses extracts and normalizes the license of every source, preferring the SPDX comment over the license
+// recorded in the metadata, and reports sources without a license, unknown identifiers, SPDX comments disagreeing
+// with the metadata and combinations of licenses that conflict, such as GPL code in an UNLICENSED contract.
+// The target, when set, is the compilation target whose license is reported as the contract license.
+func AnalyzeLicenses(sources Sources, metadata map[string]MetadataSource, target string) *LicenseReport {
+	toReturn := &LicenseReport{
+		Licenses: []string{},
+		Files:    make([]FileLicense, 0, len(sources)),
+		Issues:   []LicenseIssue{},
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	licenses := make(map[string][]string)
+	var missing []string
+
+	for _, name := range names {
+		file := FileLicense{Path: name, Metadata: metadata[name].License}
+		file.Declared, _ = ExtractLicense(sources[name].Content)
+
+		declared := file.Declared
+		if declared == "" {
+			declared = file.Metadata
+		}
+
+		if declared == "" {
+			missing = append(missing, name)
+		} else {
+			file.License, file.Known = NormalizeLicense(declared)
+			licenses[file.License] = append(licenses[file.License], name)
+
+			if !file.Known {
+				toReturn.Issues = append(toReturn.Issues, LicenseIssue{
+					Kind:    LicenseIssueUnknown,
+					Files:   []string{name},
+					Message: fmt.Sprintf("%q is not a known SPDX license expression", declared),
+				})
+			}
+
+			if file.Declared != "" && file.Metadata != "" {
+				if metadataLicense, _ := NormalizeLicense(file.Metadata); metadataLicense != file.License {
+					toReturn.Issues = append(toReturn.Issues, LicenseIssue{
+						Kind:    LicenseIssueMismatch,
+						Files:   []string{name},
+						Message: fmt.Sprintf("SPDX comment declares %s but the metadata records %s", file.License, metadataLicense),
+					})
+				}
+			}
+		}
+
+		file.Family = ClassifyLicense(file.License)
+		if name == target {
+			toReturn.Contract = file.License
+		}
+		toReturn.Files = append(toReturn.Files, file)
+	}
+
+	if len(missing) > 0 {
+		toReturn.Issues = append(toReturn.Issues, LicenseIssue{
+			Kind:    LicenseIssueMissing,
+			Files:   missing,
+			Message: "no SPDX license identifier",
+		})
+	}
+
+	for license := range licenses {
+		toReturn.Licenses = append(toReturn.Licenses, license)
+	}
+	sort.Strings(toReturn.Licenses)
+
+	toReturn.Issues = append(toReturn.Issues, licenseConflicts(toReturn)...)
+
+	return toReturn
+}
+
+// Licenses analyzes the licenses of the verified sources of the contract.
+func (c *ContractResponse) Licenses() *LicenseReport {
+	target := ""
+	for file := range c.Metadata.Settings.CompilationTarget {
+		target = file
+	}
+	return AnalyzeLicenses(c.Sources, c.Metadata.Sources, target)
+}
+
+// licenseConflicts reports licenses that cannot be combined in a single contract.
+func licenseConflicts(report *LicenseReport) []LicenseIssue {
+	var toReturn []LicenseIssue
+
+	var copyleft, proprietary []string
+	for _, license := range report.Licenses {
+		switch ClassifyLicense(license) {
+		case LicenseFamilyCopyleft:
+			copyleft = append(copyleft, license)
+		case LicenseFamilyProprietary:
+			proprietary = append(proprietary, license)
+		}
+	}
+
+	filesOf := func(group ...[]string) []string {
+		var files []string
+		for _, licenses := range group {
+			for _, license := range licenses {
+				files = append(files, report.filesWith(license)...)
+			}
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	if len(copyleft) > 0 && len(proprietary) > 0 {
+		toReturn = append(toReturn, LicenseIssue{
+			Kind:    LicenseIssueConflict,
+			Files:   filesOf(copyleft, proprietary),
+			Message: fmt.Sprintf("copyleft %s cannot be combined with %s", strings.Join(copyleft, ", "), strings.Join(proprietary, ", ")),
+		})
+	}
+
+	// GPL-2.0 without the "or later" clause is incompatible with the version 3 licenses and Apache-2.0. The
+	// deprecated GPL-2.0 identifier means the same as GPL-2.0-only.
+	var gpl2Only, incompatible []string
+	for _, license := range report.Licenses {
+		switch current := currentLicense(license); {
+		case current == "GPL-2.0-only":
+			gpl2Only = append(gpl2Only, license)
+		case strings.Contains(current, "-3.0") || current == "Apache-2.0":
+			incompatible = append(incompatible, license)
+		}
+	}
+	if len(gpl2Only) > 0 && len(incompatible) > 0 {
+		toReturn = append(toReturn, LicenseIssue{
+			Kind:    LicenseIssueConflict,
+			Files:   filesOf(gpl2Only, incompatible),
+			Message: fmt.Sprintf("%s cannot be combined with %s", strings.Join(gpl2Only, ", "), strings.Join(incompatible, ", ")),
+		})
+	}
+
+	// Copyleft dependencies extend to the combined work, so the contract itself must be copyleft as well.
+	if report.Contract != "" && len(copyleft) > 0 {
+		if family := ClassifyLicense(report.Contract); family == LicenseFamilyPermissive || family == LicenseFamilyWeakCopyleft {
+			toReturn = append(toReturn, LicenseIssue{
+				Kind:    LicenseIssueConflict,
+				Files:   filesOf(copyleft),
+				Message: fmt.Sprintf("contract is licensed %s but includes sources licensed %s", report.Contract, strings.Join(copyleft, ", ")),
+			})
+		}
+	}
+
+	return toReturn
+}
+
+// filesWith returns the sources licensed under the given normalized license.
+func (r *LicenseReport) filesWith(license string) []string {
+	var toReturn []string
+	for _, file := range r.Files {
+		if file.License == license {
+			toReturn = append(toReturn, file.Path)
+		}
+	}
+	return toReturn
+}
@@ -0,0 +1,149 @@
+package sourcify
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLicense(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+		known    bool
+	}{
+		{raw: "MIT", expected: "MIT", known: true},
+		{raw: "mit", expected: "MIT", known: true},
+		{raw: "MIT License", expected: "MIT", known: true},
+		{raw: "GPLv3", expected: "GPL-3.0", known: true},
+		{raw: "gpl-2.0+", expected: "GPL-2.0-or-later", known: true},
+		{raw: "Unlicense", expected: "Unlicense", known: true},
+		{raw: "UNLICENSED", expected: "UNLICENSED", known: true},
+		{raw: "BSL-1.1", expected: "BUSL-1.1", known: true},
+		{raw: "mit or apache2", expected: "MIT OR Apache-2.0", known: true},
+		{raw: "(MIT AND gpl-3.0-only)", expected: "(MIT AND GPL-3.0-only)", known: true},
+		{raw: "GPL-2.0-or-later with Classpath-exception-2.0", expected: "GPL-2.0-or-later WITH Classpath-exception-2.0", known: true},
+		{raw: "Proprietary", expected: "Proprietary", known: false},
+		{raw: "", expected: "", known: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			license, known := NormalizeLicense(tt.raw)
+			assert.Equal(t, tt.expected, license)
+			assert.Equal(t, tt.known, known)
+		})
+	}
+}
+
+func TestClassifyLicense(t *testing.T) {
+	assert.Equal(t, LicenseFamilyPermissive, ClassifyLicense("MIT"))
+	assert.Equal(t, LicenseFamilyCopyleft, ClassifyLicense("AGPL-3.0-only"))
+	assert.Equal(t, LicenseFamilyWeakCopyleft, ClassifyLicense("LGPL-3.0"))
+	assert.Equal(t, LicenseFamilyProprietary, ClassifyLicense("UNLICENSED"))
+	assert.Equal(t, LicenseFamilyPermissive, ClassifyLicense("GPL-3.0 OR MIT"))
+	assert.Equal(t, LicenseFamilyCopyleft, ClassifyLicense("(MIT AND GPL-3.0-only)"))
+	assert.Equal(t, LicenseFamilyUnknown, ClassifyLicense("Proprietary"))
+}
+
+func TestExtractLicense(t *testing.T) {
+	license, ok := ExtractLicense("// SPDX-License-Identifier: MIT OR Apache-2.0\npragma solidity ^0.8.0;")
+	require.True(t, ok)
+	assert.Equal(t, "MIT OR Apache-2.0", license)
+
+	license, ok = ExtractLicense("/* SPDX-License-Identifier: GPL-3.0 */\ncontract A {}")
+	require.True(t, ok)
+	assert.Equal(t, "GPL-3.0", license)
+
+	_, ok = ExtractLicense("contract A {}")
+	assert.False(t, ok)
+}
+
+func TestAnalyzeLicenses(t *testing.T) {
+	sources := Sources{
+		"contracts/Token.sol":    {Content: "// SPDX-License-Identifier: UNLICENSED\ncontract Token {}"},
+		"contracts/Math.sol":     {Content: "// SPDX-License-Identifier: gpl-3.0\nlibrary Math {}"},
+		"contracts/Oracle.sol":   {Content: "// SPDX-License-Identifier: MIT\ncontract Oracle {}"},
+		"contracts/Legacy.sol":   {Content: "contract Legacy {}"},
+		"contracts/External.sol": {Content: "contract External {}"},
+	}
+	metadata := map[string]MetadataSource{
+		"contracts/Oracle.sol":   {License: "Apache-2.0"},
+		"contracts/External.sol": {License: "MIT"},
+	}
+
+	report := AnalyzeLicenses(sources, metadata, "contracts/Token.sol")
+	assert.Equal(t, "UNLICENSED", report.Contract)
+	assert.Equal(t, []string{"GPL-3.0", "MIT", "UNLICENSED"}, report.Licenses)
+	require.Len(t, report.Files, 5)
+	assert.Equal(t, "MIT", report.Files[0].License)
+	assert.Empty(t, report.Files[0].Declared)
+
+	kinds := make(map[LicenseIssueKind][]string)
+	for _, issue := range report.Issues {
+		kinds[issue.Kind] = issue.Files
+	}
+	assert.Equal(t, []string{"contracts/Legacy.sol"}, kinds[LicenseIssueMissing])
+	assert.Equal(t, []string{"contracts/Oracle.sol"}, kinds[LicenseIssueMismatch])
+	assert.Equal(t, []string{"contracts/Math.sol", "contracts/Token.sol"}, kinds[LicenseIssueConflict])
+	assert.NotContains(t, kinds, LicenseIssueUnknown)
+}
+
+func TestAnalyzeLicenses_CopyleftDependency(t *testing.T) {
+	sources := Sources{
+		"Token.sol": {Content: "// SPDX-License-Identifier: MIT\ncontract Token {}"},
+		"Math.sol":  {Content: "// SPDX-License-Identifier: GPL-2.0-only\nlibrary Math {}"},
+		"Pool.sol":  {Content: "// SPDX-License-Identifier: Apache-2.0\ncontract Pool {}"},
+	}
+
+	report := AnalyzeLicenses(sources, nil, "Token.sol")
+	require.Len(t, report.Issues, 2)
+	assert.Equal(t, LicenseIssueConflict, report.Issues[0].Kind)
+	assert.Equal(t, []string{"Math.sol", "Pool.sol"}, report.Issues[0].Files)
+	assert.Contains(t, report.Issues[1].Message, "contract is licensed MIT")
+}
+
+func TestAnalyzeLicenses_DeprecatedIdentifiers(t *testing.T) {
+	sources := Sources{
+		"Token.sol": {Content: "// SPDX-License-Identifier: GPL-2.0\ncontract Token {}"},
+		"Math.sol":  {Content: "// SPDX-License-Identifier: GPL-3.0\nlibrary Math {}"},
+	}
+
+	// GPL-2.0 is the deprecated spelling of GPL-2.0-only and conflicts with GPL-3.0 all the same.
+	report := AnalyzeLicenses(sources, nil, "Token.sol")
+	assert.Equal(t, []string{"GPL-2.0", "GPL-3.0"}, report.Licenses)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, LicenseIssueConflict, report.Issues[0].Kind)
+	assert.Equal(t, []string{"Math.sol", "Token.sol"}, report.Issues[0].Files)
+	assert.Equal(t, "GPL-2.0 cannot be combined with GPL-3.0", report.Issues[0].Message)
+
+	assert.Equal(t, "(MIT AND GPL-2.0-only) OR LGPL-2.1-only", currentLicense("(MIT AND GPL-2.0) OR LGPL-2.1"))
+	assert.Equal(t, "GPL-2.0-or-later", currentLicense("GPL-2.0-or-later"))
+}
+
+func TestFindLicenseComments(t *testing.T) {
+	source := "// SPDX-License-Identifier: MIT\n/* SPDX-License-Identifier: GPL-3.0 */\nuint x; // SPDX-License-Identifier: ISC\n"
+	comments := findLicenseComments(source)
+	require.Len(t, comments, 3)
+
+	assert.Equal(t, "MIT", comments[0].license)
+	assert.Equal(t, "// SPDX-License-Identifier: MIT\n", source[comments[0].start:comments[0].end])
+	assert.Equal(t, "GPL-3.0", comments[1].license)
+	assert.Equal(t, "/* SPDX-License-Identifier: GPL-3.0 */\n", source[comments[1].start:comments[1].end])
+
+	// The comment shares its line with code, so it cannot be removed.
+	assert.Equal(t, "ISC", comments[2].license)
+	assert.Equal(t, comments[2].start, comments[2].end)
+}
+
+func TestContractResponse_Licenses(t *testing.T) {
+	contract, err := LoadContract(1, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	require.NoError(t, err)
+
+	// TetherToken predates SPDX comments.
+	report := contract.Licenses()
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, LicenseIssueMissing, report.Issues[0].Kind)
+}
@@ -0,0 +1,288 @@
+package sourcify
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrUnresolvedLibrary is returned when unlinked bytecode references a library without a known address.
+	ErrUnresolvedLibrary = errors.New("unresolved library placeholder")
+)
+
+// placeholderLength is the length in hex characters of a library placeholder, matching a 20 byte address.
+const placeholderLength = 40
+
+// LinkReference represents a single position in bytecode where a library address has to be linked.
+type LinkReference struct {
+	File    string `json:"file"`    // Source file the library is defined in.
+	Library string `json:"library"` // Name of the library.
+	Start   int    `json:"start"`   // Byte offset of the address placeholder.
+	Length  int    `json:"length"`  // Length of the address placeholder in bytes, always 20.
+}
+
+// FullyQualifiedName returns the library name in the `file:Library` form used by the compiler.
+func (r LinkReference) FullyQualifiedName() string {
+	return fullyQualifiedName(r.File, r.Library)
+}
+
+// LinkReferences maps source files to the libraries they define and the positions those libraries are referenced at,
+// as found in the compiler output.
+type LinkReferences map[string]map[string][]CodeReference
+
+// List returns every link reference ordered by position.
+func (l LinkReferences) List() []LinkReference {
+	var toReturn []LinkReference
+	for file, libraries := range l {
+		for library, references := range libraries {
+			for _, reference := range references {
+				toReturn = append(toReturn, LinkReference{
+					File:    file,
+					Library: library,
+					Start:   reference.Start,
+					Length:  reference.Length,
+				})
+			}
+		}
+	}
+
+	sort.Slice(toReturn, func(i, j int) bool {
+		return toReturn[i].Start < toReturn[j].Start
+	})
+
+	return toReturn
+}
+
+// UnmarshalJSON decodes the libraries from either the flat metadata form (`{"file:Library": "0x..."}`)
+// or the nested standard JSON form (`{"file": {"Library": "0x..."}}`) into the flat form.
+func (l *Libraries) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	toReturn := make(Libraries, len(raw))
+	for key, value := range raw {
+		var address string
+		if err := json.Unmarshal(value, &address); err == nil {
+			toReturn[key] = address
+			continue
+		}
+
+		var nested map[string]string
+		if err := json.Unmarshal(value, &nested); err != nil {
+			return fmt.Errorf("invalid library entry %q: %w", key, err)
+		}
+
+		for library, address := range nested {
+			toReturn[fullyQualifiedName(key, library)] = address
+		}
+	}
+
+	*l = toReturn
+	return nil
+}
+
+// Address returns the address of the library defined in the given file.
+// Libraries declared without a file, as done by older compilers, are matched by name.
+func (l Libraries) Address(file string, library string) (common.Address, bool) {
+	for _, key := range []string{fullyQualifiedName(file, library), library} {
+		if address, ok := l[key]; ok && common.IsHexAddress(address) {
+			return common.HexToAddress(address), true
+		}
+	}
+	return common.Address{}, false
+}
+
+// LinkBytecode links the library addresses into the hex encoded unlinked bytecode.
+// Placeholders are first replaced at the positions described by references and any remaining
+// placeholders in the `__$hash$__` or legacy `__Name__` formats are resolved by name.
+// It returns ErrUnresolvedLibrary when a placeholder cannot be resolved.
+func LinkBytecode(bytecode string, references LinkReferences, libraries Libraries) (string, error) {
+	hasPrefix := strings.HasPrefix(bytecode, "0x")
+	code := []byte(strings.TrimPrefix(bytecode, "0x"))
+
+	for _, reference := range references.List() {
+		address, ok := libraries.Address(reference.File, reference.Library)
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnresolvedLibrary, reference.FullyQualifiedName())
+		}
+
+		start := reference.Start * 2
+		if start < 0 || start+placeholderLength > len(code) {
+			return "", fmt.Errorf("link reference for %s at %d is out of bounds", reference.FullyQualifiedName(), reference.Start)
+		}
+		copy(code[start:], hex.EncodeToString(address.Bytes()))
+	}
+
+	for pos := 0; pos+1 < len(code); pos++ {
+		if code[pos] != '_' || code[pos+1] != '_' {
+			continue
+		}
+
+		if pos+placeholderLength > len(code) {
+			return "", fmt.Errorf("%w: truncated placeholder at %d", ErrUnresolvedLibrary, pos/2)
+		}
+
+		placeholder := string(code[pos : pos+placeholderLength])
+		address, ok := resolvePlaceholder(placeholder, libraries)
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnresolvedLibrary, placeholder)
+		}
+
+		copy(code[pos:], hex.EncodeToString(address.Bytes()))
+		pos += placeholderLength - 1
+	}
+
+	if hasPrefix {
+		return "0x" + string(code), nil
+	}
+	return string(code), nil
+}
+
+// zeroPlaceholders replaces every library placeholder in the hex encoded bytecode with the zero address,
+// so unlinked bytecode can be decoded.
+func zeroPlaceholders(bytecode string) string {
+	if !strings.Contains(bytecode, "__") {
+		return bytecode
+	}
+
+	code := []byte(bytecode)
+	for pos := 0; pos+1 < len(code); pos++ {
+		if code[pos] == '_' && code[pos+1] == '_' {
+			end := min(pos+placeholderLength, len(code))
+			copy(code[pos:end], strings.Repeat("0", end-pos))
+			pos = end - 1
+		}
+	}
+	return string(code)
+}
+
+// LibraryPlaceholder returns the `__$hash$__` placeholder solc 0.5.0 and newer emit for the fully qualified library name.
+func LibraryPlaceholder(fullyQualifiedName string) string {
+	return "__$" + hex.EncodeToString(keccak256([]byte(fullyQualifiedName)))[:34] + "$__"
+}
+
+// LegacyLibraryPlaceholder returns the `__Name__` placeholder emitted by solc versions prior to 0.5.0.
+func LegacyLibraryPlaceholder(name string) string {
+	placeholder := "__" + name
+	if len(placeholder) > placeholderLength-2 {
+		placeholder = placeholder[:placeholderLength-2]
+	}
+	return placeholder + strings.Repeat("_", placeholderLength-len(placeholder))
+}
+
+// resolvePlaceholder finds the address of the library the placeholder stands for.
+func resolvePlaceholder(placeholder string, libraries Libraries) (common.Address, bool) {
+	for name, address := range libraries {
+		if !common.IsHexAddress(address) {
+			continue
+		}
+
+		if placeholder == LibraryPlaceholder(name) || placeholder == LegacyLibraryPlaceholder(name) {
+			return common.HexToAddress(address), true
+		}
+
+		// Legacy compilers may reference a library by its bare name while it is declared fully qualified.
+		if idx := strings.LastIndex(name, ":"); idx >= 0 && placeholder == LegacyLibraryPlaceholder(name[idx+1:]) {
+			return common.HexToAddress(address), true
+		}
+	}
+	return common.Address{}, false
+}
+
+// LibraryDependency represents a deployed library a verified contract is linked against.
+type LibraryDependency struct {
+	File    string         `json:"file"`    // Source file the library is defined in, empty when unknown.
+	Library string         `json:"library"` // Name of the library.
+	Address common.Address `json:"address"` // Deployed address of the library.
+}
+
+// FullyQualifiedName returns the library name in the `file:Library` form used by the compiler.
+func (d LibraryDependency) FullyQualifiedName() string {
+	return fullyQualifiedName(d.File, d.Library)
+}
+
+// LibraryDependencies lists the deployed libraries the contract depends on, so they can be fetched in turn.
+// Addresses are taken from the compiler settings and, for libraries linked only on chain, read from the
+// onchain bytecode at the link reference positions. The result is ordered by fully qualified name.
+func (c *ContractResponse) LibraryDependencies() []LibraryDependency {
+	found := make(map[string]LibraryDependency)
+
+	add := func(file string, library string, address common.Address) {
+		if address == (common.Address{}) {
+			return
+		}
+		dependency := LibraryDependency{File: file, Library: library, Address: address}
+		if _, ok := found[dependency.FullyQualifiedName()]; !ok {
+			found[dependency.FullyQualifiedName()] = dependency
+		}
+	}
+
+	for _, libraries := range []Libraries{
+		c.Metadata.Settings.Libraries,
+		c.StdJSONInput.Settings.Libraries,
+		c.Compilation.CompilerSettings.Libraries,
+		c.RuntimeBytecode.TransformationValues.Libraries,
+		c.CreationBytecode.TransformationValues.Libraries,
+	} {
+		for name, address := range libraries {
+			if !common.IsHexAddress(address) {
+				continue
+			}
+			file, library := splitFullyQualifiedName(name)
+			add(file, library, common.HexToAddress(address))
+		}
+	}
+
+	for _, bytecode := range []Bytecode{c.RuntimeBytecode, c.CreationBytecode} {
+		code, err := decodeHex(bytecode.OnchainBytecode)
+		if err != nil {
+			continue
+		}
+
+		for _, reference := range bytecode.LinkReferences.List() {
+			if reference.Start < 0 || reference.Start+common.AddressLength > len(code) {
+				continue
+			}
+			add(reference.File, reference.Library, common.BytesToAddress(code[reference.Start:reference.Start+common.AddressLength]))
+		}
+	}
+
+	toReturn := make([]LibraryDependency, 0, len(found))
+	for _, dependency := range found {
+		toReturn = append(toReturn, dependency)
+	}
+
+	sort.Slice(toReturn, func(i, j int) bool {
+		return toReturn[i].FullyQualifiedName() < toReturn[j].FullyQualifiedName()
+	})
+
+	return toReturn
+}
+
+// fullyQualifiedName joins a file and a name into the `file:Name` form, omitting an empty file.
+func fullyQualifiedName(file string, name string) string {
+	if file == "" {
+		return name
+	}
+	return file + ":" + name
+}
+
+// splitFullyQualifiedName splits a `file:Name` into its file and name, the file being empty when absent.
+func splitFullyQualifiedName(name string) (string, string) {
+	if idx := strings.LastIndex(name, ":"); idx >= 0 {
+		return name[:idx], name[idx+1:]
+	}
+	return "", name
+}
@@ -0,0 +1,136 @@
+package sourcify
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraries_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Libraries
+	}{
+		{
+			name: "Flat metadata form",
+			data: `{"lib/Math.sol:Math": "0x1111111111111111111111111111111111111111"}`,
+			want: Libraries{"lib/Math.sol:Math": "0x1111111111111111111111111111111111111111"},
+		},
+		{
+			name: "Nested standard JSON form",
+			data: `{"lib/Math.sol": {"Math": "0x1111111111111111111111111111111111111111"}}`,
+			want: Libraries{"lib/Math.sol:Math": "0x1111111111111111111111111111111111111111"},
+		},
+		{
+			name: "Empty",
+			data: `{}`,
+			want: Libraries{},
+		},
+		{
+			name: "Null",
+			data: `null`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Libraries
+			require.NoError(t, json.Unmarshal([]byte(tt.data), &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLinkReferences_List(t *testing.T) {
+	references := LinkReferences{
+		"lib/Math.sol": {"Math": {{Start: 40, Length: 20}, {Start: 2, Length: 20}}},
+		"lib/Set.sol":  {"Set": {{Start: 10, Length: 20}}},
+	}
+
+	assert.Equal(t, []LinkReference{
+		{File: "lib/Math.sol", Library: "Math", Start: 2, Length: 20},
+		{File: "lib/Set.sol", Library: "Set", Start: 10, Length: 20},
+		{File: "lib/Math.sol", Library: "Math", Start: 40, Length: 20},
+	}, references.List())
+}
+
+func TestLibraryPlaceholder(t *testing.T) {
+	assert.Equal(t, "__$c5d2460186f7233c927e7db2dcc703c0e5$__", LibraryPlaceholder(""))
+	assert.Len(t, LibraryPlaceholder("lib/Math.sol:Math"), 40)
+	assert.Equal(t, "__Math__________________________________", LegacyLibraryPlaceholder("Math"))
+	assert.Equal(t, "__a/very/long/path/to/Library.sol:Libr__", LegacyLibraryPlaceholder("a/very/long/path/to/Library.sol:Library"))
+}
+
+func TestLinkBytecode(t *testing.T) {
+	math := "0x1111111111111111111111111111111111111111"
+	set := "0x2222222222222222222222222222222222222222"
+	libraries := Libraries{"lib/Math.sol:Math": math, "Set": set}
+
+	t.Run("Link references", func(t *testing.T) {
+		bytecode := "0x73" + LibraryPlaceholder("lib/Math.sol:Math") + "00"
+		references := LinkReferences{"lib/Math.sol": {"Math": {{Start: 1, Length: 20}}}}
+
+		linked, err := LinkBytecode(bytecode, references, libraries)
+		require.NoError(t, err)
+		assert.Equal(t, "0x73"+strings.TrimPrefix(math, "0x")+"00", linked)
+	})
+
+	t.Run("Placeholders", func(t *testing.T) {
+		bytecode := "73" + LibraryPlaceholder("lib/Math.sol:Math") + "73" + LegacyLibraryPlaceholder("Set")
+
+		linked, err := LinkBytecode(bytecode, nil, libraries)
+		require.NoError(t, err)
+		assert.Equal(t, "73"+strings.TrimPrefix(math, "0x")+"73"+strings.TrimPrefix(set, "0x"), linked)
+	})
+
+	t.Run("Unresolved", func(t *testing.T) {
+		_, err := LinkBytecode("73"+LibraryPlaceholder("lib/Other.sol:Other"), nil, libraries)
+		assert.ErrorIs(t, err, ErrUnresolvedLibrary)
+
+		references := LinkReferences{"lib/Other.sol": {"Other": {{Start: 1, Length: 20}}}}
+		_, err = LinkBytecode("73"+LibraryPlaceholder("lib/Other.sol:Other"), references, libraries)
+		assert.ErrorIs(t, err, ErrUnresolvedLibrary)
+	})
+}
+
+func TestContractResponse_LibraryDependencies(t *testing.T) {
+	onchain := "0x73" + "3333333333333333333333333333333333333333" + "00"
+
+	contract := &ContractResponse{
+		Metadata: Metadata{
+			Settings: Settings{
+				Libraries: Libraries{"lib/Math.sol:Math": "0x1111111111111111111111111111111111111111"},
+			},
+		},
+		RuntimeBytecode: Bytecode{
+			OnchainBytecode: onchain,
+			LinkReferences: LinkReferences{
+				"lib/Math.sol": {"Math": {{Start: 1, Length: 20}}},
+				"lib/Set.sol":  {"Set": {{Start: 1, Length: 20}}},
+			},
+		},
+	}
+
+	assert.Equal(t, []LibraryDependency{
+		{File: "lib/Math.sol", Library: "Math", Address: common.HexToAddress("0x1111111111111111111111111111111111111111")},
+		{File: "lib/Set.sol", Library: "Set", Address: common.HexToAddress("0x3333333333333333333333333333333333333333")},
+	}, contract.LibraryDependencies())
+}
+
+func TestBytecode_DisassembleUnlinked(t *testing.T) {
+	bytecode := Bytecode{
+		RecompiledBytecode: "0x73" + LibraryPlaceholder("lib/Math.sol:Math") + "00",
+		LinkReferences:     LinkReferences{"lib/Math.sol": {"Math": {{Start: 1, Length: 20}}}},
+	}
+
+	disassembly, err := bytecode.DisassembleRecompiled()
+	require.NoError(t, err)
+	require.Len(t, disassembly.Instructions, 2)
+	assert.Equal(t, "lib/Math.sol:Math", disassembly.Instructions[0].Library)
+}
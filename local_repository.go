@@ -0,0 +1,446 @@
+package sourcify
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+)
+
+var (
+	// ErrContractNotFound is returned by LocalRepository when a contract is not in the repository.
+	ErrContractNotFound = errors.New("contract not found")
+
+	// ErrFileNotFound is returned by LocalRepository when a contract file is not in the repository.
+	ErrFileNotFound = errors.New("file not found")
+)
+
+const (
+	// repositoryFullMatchDir is the repository directory holding the full (perfect) matches.
+	repositoryFullMatchDir = "full_match"
+
+	// repositoryPartialMatchDir is the repository directory holding the partial matches.
+	repositoryPartialMatchDir = "partial_match"
+)
+
+// Backend represents the repository endpoints of the Sourcify API, a subset of API that can be served either
+// remotely by Client or offline by LocalRepository.
+type Backend interface {
+	GetAvailableContractAddresses(chainId int) (*VerifiedContractAddresses, error)
+	GetContractFiles(chainId int, contract common.Address, matchType MethodMatchType) (*FileTree, error)
+	GetContractSourceCode(chainId int, contract common.Address, matchType MethodMatchType) (*SourceCodes, error)
+	GetContractMetadata(chainId int, contract common.Address, matchType MethodMatchType) (*Metadata, error)
+	GetContractMetadataAsBytes(chainId int, contract common.Address, matchType MethodMatchType) ([]byte, error)
+	CheckContractByAddresses(addresses []string, chainIds []int, matchType MethodMatchType) ([]*CheckContractAddress, error)
+}
+
+// Ensure Client and LocalRepository implement Backend.
+var (
+	_ Backend = (*Client)(nil)
+	_ Backend = (*LocalRepository)(nil)
+)
+
+// LocalRepository serves the repository endpoints from a copy of the Sourcify repository, laid out as
+// `contracts/full_match/<chain>/<address>/...` and `contracts/partial_match/<chain>/<address>/...`, such as the
+// bulk downloads published by Sourcify. Unlike the API, the any match type is supported by every method, and
+// looks up full matches before partial ones.
+type LocalRepository struct {
+	// BaseURL prefixes the file paths returned by GetContractFiles, e.g. `https://repo.sourcify.dev`.
+	// When empty, the paths are relative to the repository root, e.g. `contracts/full_match/1/0x.../metadata.json`.
+	BaseURL string
+
+	fsys   fs.FS
+	root   string    // Directory of fsys holding the match directories.
+	closer io.Closer // Archive to close with the repository, if any.
+}
+
+// NewLocalRepository creates a repository reading from fsys. The root of fsys may either contain the `contracts`
+// directory or be the `contracts` directory itself.
+func NewLocalRepository(fsys fs.FS) *LocalRepository {
+	toReturn := &LocalRepository{fsys: fsys, root: "contracts"}
+	for _, candidate := range []string{"contracts", "."} {
+		for _, match := range []string{repositoryFullMatchDir, repositoryPartialMatchDir} {
+			if info, err := fs.Stat(fsys, path.Join(candidate, match)); err == nil && info.IsDir() {
+				toReturn.root = candidate
+				return toReturn
+			}
+		}
+	}
+	return toReturn
+}
+
+// OpenLocalRepository opens a repository from a directory, a zip archive or a gzip compressed tar archive. Zip
+// archives are read in place through their central directory, while tar.gz archives, which cannot be read at random,
+// are extracted to a temporary directory removed by Close.
+func OpenLocalRepository(name string) (*LocalRepository, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return NewLocalRepository(os.DirFS(name)), nil
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		reader, err := zip.OpenReader(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open zip repository: %w", err)
+		}
+		toReturn := NewLocalRepository(reader)
+		toReturn.closer = reader
+		return toReturn, nil
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		dir, err := os.MkdirTemp("", "sourcify-repository-*")
+		if err != nil {
+			return nil, err
+		}
+		if err := extractTarGz(file, dir); err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to extract tar.gz repository: %w", err)
+		}
+
+		toReturn := NewLocalRepository(os.DirFS(dir))
+		toReturn.closer = removeDirCloser(dir)
+		return toReturn, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedArchiveFormat, name)
+	}
+}
+
+// Close releases the archive the repository was opened from, if any, and removes its extracted copy.
+func (r *LocalRepository) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// Chains returns the IDs of the chains with at least one contract, sorted.
+func (r *LocalRepository) Chains() ([]int, error) {
+	set := make(map[int]bool)
+	for _, match := range []string{repositoryFullMatchDir, repositoryPartialMatchDir} {
+		entries, err := fs.ReadDir(r.fsys, path.Join(r.root, match))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if chainId, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+				set[chainId] = true
+			}
+		}
+	}
+
+	toReturn := make([]int, 0, len(set))
+	for chainId := range set {
+		toReturn = append(toReturn, chainId)
+	}
+	sort.Ints(toReturn)
+	return toReturn, nil
+}
+
+// GetAvailableContractAddresses lists the full and partial matches of the chain.
+func (r *LocalRepository) GetAvailableContractAddresses(chainId int) (*VerifiedContractAddresses, error) {
+	toReturn := &VerifiedContractAddresses{Full: []common.Address{}, Partial: []common.Address{}}
+	for _, match := range []string{repositoryFullMatchDir, repositoryPartialMatchDir} {
+		entries, err := fs.ReadDir(r.fsys, path.Join(r.root, match, strconv.Itoa(chainId)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || !common.IsHexAddress(entry.Name()) {
+				continue
+			}
+			if match == repositoryFullMatchDir {
+				toReturn.Full = append(toReturn.Full, common.HexToAddress(entry.Name()))
+			} else {
+				toReturn.Partial = append(toReturn.Partial, common.HexToAddress(entry.Name()))
+			}
+		}
+	}
+	return toReturn, nil
+}
+
+// GetContractFiles lists the files of the contract, see LocalRepository.BaseURL for the form of the paths.
+func (r *LocalRepository) GetContractFiles(chainId int, contract common.Address, matchType MethodMatchType) (*FileTree, error) {
+	dir, match, err := r.contractDir(chainId, contract, matchType)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := r.contractFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := &FileTree{Status: matchStatus(match), Files: make([]string, 0, len(files))}
+	for _, file := range files {
+		relative := r.repositoryPath(dir, file)
+		if r.BaseURL != "" {
+			relative = strings.TrimSuffix(r.BaseURL, "/") + "/" + relative
+		}
+		toReturn.Files = append(toReturn.Files, relative)
+	}
+	return toReturn, nil
+}
+
+// GetContractSourceCode returns every file of the contract, including metadata.json.
+func (r *LocalRepository) GetContractSourceCode(chainId int, contract common.Address, matchType MethodMatchType) (*SourceCodes, error) {
+	dir, match, err := r.contractDir(chainId, contract, matchType)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := r.contractFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := &SourceCodes{Status: matchStatus(match), Code: make([]SourceCode, 0, len(files))}
+	for _, file := range files {
+		content, err := fs.ReadFile(r.fsys, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+		toReturn.Code = append(toReturn.Code, SourceCode{
+			Name:    path.Base(file),
+			Path:    r.repositoryPath(dir, file),
+			Content: string(content),
+		})
+	}
+	return toReturn, nil
+}
+
+// GetContractMetadata returns the decoded metadata.json of the contract.
+func (r *LocalRepository) GetContractMetadata(chainId int, contract common.Address, matchType MethodMatchType) (*Metadata, error) {
+	data, err := r.GetContractMetadataAsBytes(chainId, contract, matchType)
+	if err != nil {
+		return nil, err
+	}
+
+	var toReturn Metadata
+	if err := json.Unmarshal(data, &toReturn); err != nil {
+		return nil, err
+	}
+	return &toReturn, nil
+}
+
+// GetContractMetadataAsBytes returns the raw metadata.json of the contract.
+func (r *LocalRepository) GetContractMetadataAsBytes(chainId int, contract common.Address, matchType MethodMatchType) ([]byte, error) {
+	return r.ReadFile(chainId, contract, matchType, "metadata.json")
+}
+
+// ReadFile returns a file of the contract by its path relative to the contract directory, e.g. `metadata.json` or
+// `sources/contracts/Token.sol`.
+func (r *LocalRepository) ReadFile(chainId int, contract common.Address, matchType MethodMatchType, name string) ([]byte, error) {
+	dir, _, err := r.contractDir(chainId, contract, matchType)
+	if err != nil {
+		return nil, err
+	}
+
+	sanitized, err := SanitizeSourcePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn, err := fs.ReadFile(r.fsys, path.Join(dir, sanitized))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, name)
+	}
+	return toReturn, err
+}
+
+// CheckContractByAddresses checks whether the addresses are verified on the chains. The results follow those of
+// the client: with the full match type, there is one result per address holding the chains with a full match
+// and the `perfect` status; otherwise, there is one result per address and chain with either the `perfect` or
+// the `partial` status. Addresses verified on none of the chains have a single result with the `false` status.
+func (r *LocalRepository) CheckContractByAddresses(addresses []string, chainIds []int, matchType MethodMatchType) ([]*CheckContractAddress, error) {
+	switch matchType {
+	case MethodMatchTypeFull, MethodMatchTypePartial, MethodMatchTypeAny:
+	default:
+		return nil, fmt.Errorf("invalid match type: %s", matchType)
+	}
+
+	var toReturn []*CheckContractAddress
+	for _, raw := range addresses {
+		if !common.IsHexAddress(raw) {
+			return nil, fmt.Errorf("invalid address: %s", raw)
+		}
+		address := common.HexToAddress(raw)
+
+		found := false
+		var fullChains []string
+		for _, chainId := range chainIds {
+			_, match, err := r.contractDir(chainId, address, MethodMatchTypeAny)
+			if errors.Is(err, ErrContractNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if matchType == MethodMatchTypeFull {
+				if match == repositoryFullMatchDir {
+					fullChains = append(fullChains, strconv.Itoa(chainId))
+				}
+				continue
+			}
+
+			found = true
+			status := "partial"
+			if match == repositoryFullMatchDir {
+				status = "perfect"
+			}
+			toReturn = append(toReturn, &CheckContractAddress{Address: address, Status: status, ChainIDs: []string{strconv.Itoa(chainId)}})
+		}
+
+		switch {
+		case len(fullChains) > 0:
+			toReturn = append(toReturn, &CheckContractAddress{Address: address, Status: "perfect", ChainIDs: fullChains})
+		case !found:
+			toReturn = append(toReturn, &CheckContractAddress{Address: address, Status: "false"})
+		}
+	}
+	return toReturn, nil
+}
+
+// contractDir returns the directory of the contract and the match directory it was found in.
+func (r *LocalRepository) contractDir(chainId int, contract common.Address, matchType MethodMatchType) (string, string, error) {
+	var matches []string
+	switch matchType {
+	case MethodMatchTypeFull:
+		matches = []string{repositoryFullMatchDir}
+	case MethodMatchTypePartial:
+		matches = []string{repositoryPartialMatchDir}
+	case MethodMatchTypeAny:
+		matches = []string{repositoryFullMatchDir, repositoryPartialMatchDir}
+	default:
+		return "", "", fmt.Errorf("invalid match type: %s", matchType)
+	}
+
+	// The repository uses checksummed addresses, lower case ones are accepted for hand made copies.
+	for _, match := range matches {
+		for _, address := range []string{contract.Hex(), strings.ToLower(contract.Hex())} {
+			dir := path.Join(r.root, match, strconv.Itoa(chainId), address)
+			if info, err := fs.Stat(r.fsys, dir); err == nil && info.IsDir() {
+				return dir, match, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("%w: %s on chain %d", ErrContractNotFound, contract.Hex(), chainId)
+}
+
+// contractFiles returns the paths of every file under the contract directory, relative to it and sorted with
+// metadata.json first.
+func (r *LocalRepository) contractFiles(dir string) ([]string, error) {
+	var toReturn []string
+	err := fs.WalkDir(r.fsys, dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			toReturn = append(toReturn, strings.TrimPrefix(name, dir+"/"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(toReturn, func(i, j int) bool {
+		if (toReturn[i] == "metadata.json") != (toReturn[j] == "metadata.json") {
+			return toReturn[i] == "metadata.json"
+		}
+		return toReturn[i] < toReturn[j]
+	})
+	return toReturn, nil
+}
+
+// repositoryPath returns the path of a contract file relative to the repository root, e.g.
+// `contracts/full_match/1/0x.../metadata.json`.
+func (r *LocalRepository) repositoryPath(dir string, file string) string {
+	if r.root == "." {
+		return path.Join("contracts", dir, file)
+	}
+	return path.Join(dir, file)
+}
+
+// matchStatus returns the status reported by the files endpoints for a match directory.
+func matchStatus(match string) string {
+	if match == repositoryFullMatchDir {
+		return "full"
+	}
+	return "partial"
+}
+
+// extractTarGz extracts a gzip compressed tar archive into dir, streaming each regular file to disk. Entry names
+// are sanitized so that no file is written outside of dir.
+func extractTarGz(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name, err := SanitizeSourcePath(header.Name)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+
+		file, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(file, reader)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// removeDirCloser removes a directory when closed.
+type removeDirCloser string
+
+func (d removeDirCloser) Close() error {
+	return os.RemoveAll(string(d))
+}
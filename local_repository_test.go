@@ -0,0 +1,182 @@
+package sourcify
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	localRepositoryUSDT  = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	localRepositoryToken = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+)
+
+// localRepositoryFiles returns the files of a small repository: USDT as a partial match on chain 1 and a token
+// as a full match on chains 1 and 10, stored under a lower case address on chain 10.
+func localRepositoryFiles(t *testing.T) map[string]string {
+	contract, err := LoadContract(1, localRepositoryUSDT)
+	require.NoError(t, err)
+
+	metadata, err := json.Marshal(contract.Metadata)
+	require.NoError(t, err)
+
+	usdt := "contracts/partial_match/1/" + localRepositoryUSDT.Hex() + "/"
+	token := "contracts/full_match/1/" + localRepositoryToken.Hex() + "/"
+	tokenL2 := "contracts/full_match/10/" + strings.ToLower(localRepositoryToken.Hex()) + "/"
+
+	return map[string]string{
+		usdt + "metadata.json":              string(metadata),
+		usdt + "sources/TetherToken.sol":    contract.Sources["TetherToken.sol"].Content,
+		usdt + "creator-tx-hash.txt":        "0x2f1c5c2b44f771e942a8506148e256f94f1a464babc938ae0690c6e34cd79190",
+		token + "metadata.json":             `{"language":"Solidity","compiler":{"version":"0.8.19+commit.7dd6d404"}}`,
+		token + "sources/contracts/T.sol":   "contract T {}",
+		tokenL2 + "metadata.json":           `{"language":"Solidity","compiler":{"version":"0.8.19+commit.7dd6d404"}}`,
+		tokenL2 + "sources/contracts/T.sol": "contract T {}",
+	}
+}
+
+func writeLocalRepository(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestLocalRepository(t *testing.T) {
+	repository, err := OpenLocalRepository(writeLocalRepository(t, localRepositoryFiles(t)))
+	require.NoError(t, err)
+	defer repository.Close()
+
+	testLocalRepository(t, repository)
+}
+
+func TestLocalRepositoryRootedAtContracts(t *testing.T) {
+	dir := writeLocalRepository(t, localRepositoryFiles(t))
+	repository := NewLocalRepository(os.DirFS(filepath.Join(dir, "contracts")))
+
+	tree, err := repository.GetContractFiles(1, localRepositoryToken, MethodMatchTypeFull)
+	require.NoError(t, err)
+	assert.Equal(t, "contracts/full_match/1/"+localRepositoryToken.Hex()+"/metadata.json", tree.Files[0])
+}
+
+func TestLocalRepositoryArchives(t *testing.T) {
+	files := localRepositoryFiles(t)
+	dir := t.TempDir()
+
+	zipPath := filepath.Join(dir, "repository.zip")
+	zipFile, err := os.Create(zipPath)
+	require.NoError(t, err)
+	zipWriter := zip.NewWriter(zipFile)
+	for name, content := range files {
+		w, err := zipWriter.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	require.NoError(t, zipFile.Close())
+
+	tarPath := filepath.Join(dir, "repository.tar.gz")
+	tarFile, err := os.Create(tarPath)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(tarFile)
+	tarWriter := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "./" + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err = tarWriter.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, tarFile.Close())
+
+	for _, name := range []string{zipPath, tarPath} {
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			repository, err := OpenLocalRepository(name)
+			require.NoError(t, err)
+			defer repository.Close()
+
+			testLocalRepository(t, repository)
+		})
+	}
+
+	// The tar.gz archive is extracted to a temporary directory, removed on close.
+	repository, err := OpenLocalRepository(tarPath)
+	require.NoError(t, err)
+	extracted, ok := repository.closer.(removeDirCloser)
+	require.True(t, ok)
+	assert.DirExists(t, string(extracted))
+	require.NoError(t, repository.Close())
+	assert.NoDirExists(t, string(extracted))
+
+	_, err = OpenLocalRepository(zipPath + ".unknown")
+	assert.Error(t, err)
+}
+
+// testLocalRepository runs the same checks against every kind of repository.
+func testLocalRepository(t *testing.T, repository *LocalRepository) {
+	chains, err := repository.Chains()
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 10}, chains)
+
+	addresses, err := repository.GetAvailableContractAddresses(1)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{localRepositoryToken}, addresses.Full)
+	assert.Equal(t, []common.Address{localRepositoryUSDT}, addresses.Partial)
+
+	tree, err := repository.GetContractFiles(1, localRepositoryUSDT, MethodMatchTypeAny)
+	require.NoError(t, err)
+	assert.Equal(t, "partial", tree.Status)
+	prefix := "contracts/partial_match/1/" + localRepositoryUSDT.Hex() + "/"
+	assert.Equal(t, []string{prefix + "metadata.json", prefix + "creator-tx-hash.txt", prefix + "sources/TetherToken.sol"}, tree.Files)
+
+	_, err = repository.GetContractFiles(1, localRepositoryUSDT, MethodMatchTypeFull)
+	assert.ErrorIs(t, err, ErrContractNotFound)
+
+	sources, err := repository.GetContractSourceCode(10, localRepositoryToken, MethodMatchTypeFull)
+	require.NoError(t, err)
+	assert.Equal(t, "full", sources.Status)
+	require.Len(t, sources.Code, 2)
+	assert.Equal(t, "T.sol", sources.Code[1].Name)
+	assert.Equal(t, "contract T {}", sources.Code[1].Content)
+
+	metadata, err := repository.GetContractMetadata(1, localRepositoryUSDT, MethodMatchTypePartial)
+	require.NoError(t, err)
+	assert.Equal(t, "0.4.18+commit.9cf6e910", metadata.Compiler.Version)
+
+	_, err = repository.ReadFile(1, localRepositoryUSDT, MethodMatchTypeAny, "sources/Missing.sol")
+	assert.ErrorIs(t, err, ErrFileNotFound)
+
+	missing := common.HexToAddress("0xdead").Hex()
+	checks, err := repository.CheckContractByAddresses([]string{localRepositoryToken.Hex(), localRepositoryUSDT.Hex(), missing}, []int{1, 10}, MethodMatchTypeFull)
+	require.NoError(t, err)
+	require.Len(t, checks, 3)
+	assert.Equal(t, "perfect", checks[0].Status)
+	assert.Equal(t, []string{"1", "10"}, checks[0].ChainIDs)
+	assert.Equal(t, "false", checks[1].Status)
+	assert.Equal(t, "false", checks[2].Status)
+
+	checks, err = repository.CheckContractByAddresses([]string{localRepositoryUSDT.Hex(), missing}, []int{1, 10}, MethodMatchTypeAny)
+	require.NoError(t, err)
+	require.Len(t, checks, 2)
+	assert.Equal(t, "partial", checks[0].Status)
+	assert.Equal(t, []string{"1"}, checks[0].ChainIDs)
+	assert.Equal(t, "false", checks[1].Status)
+
+	repository.BaseURL = "https://repo.sourcify.dev/"
+	tree, err = repository.GetContractFiles(1, localRepositoryToken, MethodMatchTypeAny)
+	require.NoError(t, err)
+	assert.Equal(t, "https://repo.sourcify.dev/contracts/full_match/1/"+localRepositoryToken.Hex()+"/metadata.json", tree.Files[0])
+	repository.BaseURL = ""
+}
@@ -2,7 +2,6 @@ package sourcify
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -56,7 +55,7 @@ func GetAvailableContractAddresses(client *Client, chainId int) (*VerifiedContra
 			return nil, rErr
 		}
 
-		return nil, fmt.Errorf("unexpected status code: %d", statusCode)
+		return nil, &StatusError{StatusCode: statusCode}
 	}
 
 	var toReturn VerifiedContractAddresses
@@ -2,7 +2,6 @@ package sourcify
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 )
 
@@ -99,7 +98,7 @@ func GetChains(client *Client) ([]Chain, error) {
 			return nil, rErr
 		}
 
-		return nil, fmt.Errorf("unexpected status code: %d", statusCode)
+		return nil, &StatusError{StatusCode: statusCode}
 	}
 
 	var chains []Chain
@@ -2,6 +2,7 @@ package sourcify
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -112,7 +113,7 @@ func CheckContractByAddresses(client *Client, addresses []string, chainIds []int
 	defer response.Close()
 
 	if statusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", statusCode)
+		return nil, &StatusError{StatusCode: statusCode}
 	}
 
 	body, err := io.ReadAll(response)
@@ -122,12 +123,16 @@ func CheckContractByAddresses(client *Client, addresses []string, chainIds []int
 
 	var toReturn []*CheckContractAddress
 	if err := json.Unmarshal(body, &toReturn); err != nil {
-		if strings.Contains(err.Error(), "cannot unmarshal object into Go struct field CheckContractAddress.chainIds") {
+		// The check-all endpoint returns the chain IDs as objects holding the status of each chain.
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && strings.Contains(typeErr.Field, "chainIds") {
 			var toReturnMore []*CheckContractAddressMore
 			if err := json.Unmarshal(body, &toReturnMore); err != nil {
 				return nil, err
 			}
 
+			// Discard whatever the failed decoding managed to fill in.
+			toReturn = nil
 			for _, v := range toReturnMore {
 				for _, info := range v.Info {
 					toReturn = append(toReturn, &CheckContractAddress{
@@ -57,6 +57,27 @@ func TestCheckContractByAddresses(t *testing.T) {
 	assert.Equal(t, expectedContractAddresses, contractAddresses, "CheckContractByAddresses returned unexpected contract addresses")
 }
 
+func TestCheckContractByAddresses_ChainStatuses(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+			{"address":"0x0000000000000000000000000000000000000001","chainIds":[{"chainId":"1","status":"perfect"},{"chainId":"10","status":"partial"}]},
+			{"address":"0x0000000000000000000000000000000000000002","chainIds":[{"chainId":"56","status":"partial"}]}
+		]`))
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(WithBaseURL(mockServer.URL))
+	contractAddresses, err := CheckContractByAddresses(client, []string{"0x01", "0x02"}, []int{1, 10, 56}, MethodMatchTypeAny)
+	assert.NoError(t, err)
+
+	// Each chain becomes an entry of its own, without the entries partially decoded by the first attempt.
+	assert.Equal(t, []*CheckContractAddress{
+		{Address: common.HexToAddress("0x01"), Status: "perfect", ChainIDs: []string{"1"}},
+		{Address: common.HexToAddress("0x01"), Status: "partial", ChainIDs: []string{"10"}},
+		{Address: common.HexToAddress("0x02"), Status: "partial", ChainIDs: []string{"56"}},
+	}, contractAddresses)
+}
+
 func TestCheckContractByAddresses_Error(t *testing.T) {
 	// Create a mock HTTP server that returns an error
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -2,7 +2,6 @@ package sourcify
 
 import (
 	"encoding/json"
-	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"net/http"
 	"strings"
@@ -135,7 +134,7 @@ func GetContractsByChainId(client *Client, chainId int, sort string, afterMatchI
 			return nil, rErr
 		}
 
-		return nil, fmt.Errorf("unexpected status code: %d", statusCode)
+		return nil, &StatusError{StatusCode: statusCode}
 	}
 
 	var toReturn *ContractsResponse
@@ -192,7 +191,7 @@ func GetContractByChainIdAndAddress(client *Client, chainId int, address common.
 			return nil, rErr
 		}
 
-		return nil, fmt.Errorf("unexpected status code: %d", statusCode)
+		return nil, &StatusError{StatusCode: statusCode}
 	}
 
 	var toReturn ContractResponse
@@ -52,7 +52,7 @@ func GetContractMetadata(client *Client, chainId int, contract common.Address, m
 			return nil, rErr
 		}
 
-		return nil, fmt.Errorf("unexpected status code: %d", statusCode)
+		return nil, &StatusError{StatusCode: statusCode}
 	}
 
 	var toReturn Metadata
@@ -119,7 +119,7 @@ func GetContractMetadataAsBytes(client *Client, chainId int, contract common.Add
 			return nil, rErr
 		}
 
-		return nil, fmt.Errorf("unexpected status code: %d", statusCode)
+		return nil, &StatusError{StatusCode: statusCode}
 	}
 
 	body, err := io.ReadAll(response)
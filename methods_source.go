@@ -116,7 +116,7 @@ func GetContractSourceCode(client *Client, chainId int, contract common.Address,
 			return nil, rErr
 		}
 
-		return nil, fmt.Errorf("unexpected status code: %d", statusCode)
+		return nil, &StatusError{StatusCode: statusCode}
 	}
 
 	toReturn := &SourceCodes{}
@@ -110,7 +110,7 @@ func GetContractFiles(client *Client, chainId int, contract common.Address, matc
 			return nil, rErr
 		}
 
-		return nil, fmt.Errorf("unexpected status code: %d", statusCode)
+		return nil, &StatusError{StatusCode: statusCode}
 	}
 
 	toReturn := &FileTree{}
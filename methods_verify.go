@@ -0,0 +1,210 @@
+package sourcify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+)
+
+var (
+	// MethodVerify represents the API endpoint for submitting a contract for verification from its standard JSON input.
+	// HTTP Method: POST
+	// URI: /v2/verify/:chain/:address
+	// Documentation: https://docs.sourcify.dev/docs/api/#/Contract%20Verification/post-v2-verify-chainId-address
+	MethodVerify = Method{
+		Name:           "Verify contract from standard JSON input",
+		URI:            "/v2/verify/:chain/:address",
+		MoreInfo:       "https://docs.sourcify.dev/docs/api/#/Contract%20Verification/post-v2-verify-chainId-address",
+		Method:         "POST",
+		ParamType:      MethodParamTypeUri,
+		RequiredParams: []string{":chain", ":address"},
+		Params:         []MethodParam{},
+	}
+
+	// MethodVerifyFromMetadata represents the API endpoint for submitting a contract for verification from its metadata.
+	// HTTP Method: POST
+	// URI: /v2/verify/metadata/:chain/:address
+	// Documentation: https://docs.sourcify.dev/docs/api/#/Contract%20Verification/post-v2-verify-metadata-chainId-address
+	MethodVerifyFromMetadata = Method{
+		Name:           "Verify contract from metadata",
+		URI:            "/v2/verify/metadata/:chain/:address",
+		MoreInfo:       "https://docs.sourcify.dev/docs/api/#/Contract%20Verification/post-v2-verify-metadata-chainId-address",
+		Method:         "POST",
+		ParamType:      MethodParamTypeUri,
+		RequiredParams: []string{":chain", ":address"},
+		Params:         []MethodParam{},
+	}
+
+	// MethodGetVerificationJob represents the API endpoint for retrieving the status of a verification job.
+	// HTTP Method: GET
+	// URI: /v2/verify/:verificationId
+	// Documentation: https://docs.sourcify.dev/docs/api/#/Contract%20Verification/get-v2-verify-verificationId
+	MethodGetVerificationJob = Method{
+		Name:           "Get verification job status",
+		URI:            "/v2/verify/:verificationId",
+		MoreInfo:       "https://docs.sourcify.dev/docs/api/#/Contract%20Verification/get-v2-verify-verificationId",
+		Method:         "GET",
+		ParamType:      MethodParamTypeUri,
+		RequiredParams: []string{":verificationId"},
+		Params:         []MethodParam{},
+	}
+)
+
+var (
+	// ErrVerificationFailed is returned when a verification job completes with an error.
+	ErrVerificationFailed = errors.New("verification failed")
+)
+
+// VerifyRequest represents a request to verify a contract from its standard JSON input.
+type VerifyRequest struct {
+	StdJSONInput            *StdJSONInput `json:"stdJsonInput"`
+	CompilerVersion         string        `json:"compilerVersion"`                   // Long compiler version, e.g. `0.8.19+commit.7dd6d404`.
+	ContractIdentifier      string        `json:"contractIdentifier"`                // Fully qualified name, e.g. `contracts/Token.sol:Token`.
+	CreationTransactionHash string        `json:"creationTransactionHash,omitempty"` // Speeds up finding the creation bytecode, if known.
+}
+
+// VerifyMetadataRequest represents a request to verify a contract from its metadata and sources.
+type VerifyMetadataRequest struct {
+	Sources                 map[string]string `json:"sources"` // Source contents keyed by the paths used in the metadata.
+	Metadata                *Metadata         `json:"metadata"`
+	CreationTransactionHash string            `json:"creationTransactionHash,omitempty"`
+}
+
+// VerificationJob represents the status of an asynchronous verification job.
+type VerificationJob struct {
+	IsJobCompleted  bool                 `json:"isJobCompleted"`
+	VerificationID  string               `json:"verificationId"`
+	JobStartTime    time.Time            `json:"jobStartTime"`
+	JobFinishTime   *time.Time           `json:"jobFinishTime,omitempty"`
+	CompilationTime string               `json:"compilationTime,omitempty"`
+	Contract        ContractBaseResponse `json:"contract"`
+	Error           *ErrorResponse       `json:"error,omitempty"`
+}
+
+// Err returns an error wrapping ErrVerificationFailed when the job completed with an error, nil otherwise.
+func (j *VerificationJob) Err() error {
+	if !j.IsJobCompleted || j.Error == nil {
+		return nil
+	}
+	return fmt.Errorf("%w (%s): %s", ErrVerificationFailed, j.Error.CustomCode, j.Error.Message)
+}
+
+// verificationAccepted represents the response of the verification endpoints.
+type verificationAccepted struct {
+	VerificationID string `json:"verificationId"`
+}
+
+// VerifyContract submits a contract for verification from its standard JSON input.
+// Returns the ID of the verification job, whose status can be followed with GetVerificationJob or WaitForVerification.
+func VerifyContract(client *Client, chainId int, address common.Address, request *VerifyRequest) (string, error) {
+	return submitVerification(client, MethodVerify, chainId, address, request)
+}
+
+// VerifyContractFromMetadata submits a contract for verification from its metadata and sources.
+// Returns the ID of the verification job, whose status can be followed with GetVerificationJob or WaitForVerification.
+func VerifyContractFromMetadata(client *Client, chainId int, address common.Address, request *VerifyMetadataRequest) (string, error) {
+	return submitVerification(client, MethodVerifyFromMetadata, chainId, address, request)
+}
+
+// GetVerificationJob retrieves the status of a verification job.
+func GetVerificationJob(client *Client, verificationId string) (*VerificationJob, error) {
+	method := MethodGetVerificationJob
+	method.SetParams(
+		MethodParam{Key: ":verificationId", Value: verificationId},
+	)
+
+	if err := method.Verify(); err != nil {
+		return nil, err
+	}
+
+	response, statusCode, err := client.CallMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	// Close the io.ReadCloser interface.
+	// This is important as CallMethod is NOT closing the response body!
+	// You'll have memory leaks if you don't do this!
+	defer response.Close()
+
+	if statusCode != http.StatusOK {
+		if rErr := ToErrorResponse(response); rErr != nil {
+			return nil, rErr
+		}
+
+		return nil, &StatusError{StatusCode: statusCode}
+	}
+
+	var toReturn VerificationJob
+	if err := json.NewDecoder(response).Decode(&toReturn); err != nil {
+		return nil, err
+	}
+
+	return &toReturn, nil
+}
+
+// WaitForVerification polls the verification job every interval until it completes or the context is done.
+// A job completing with an error is returned together with an error wrapping ErrVerificationFailed.
+func WaitForVerification(ctx context.Context, client *Client, verificationId string, interval time.Duration) (*VerificationJob, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job, err := GetVerificationJob(client, verificationId)
+		if err != nil {
+			return nil, err
+		}
+
+		if job.IsJobCompleted {
+			return job, job.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// submitVerification posts a verification request and returns the ID of the created job.
+func submitVerification(client *Client, method Method, chainId int, address common.Address, request any) (string, error) {
+	method.SetParams(
+		MethodParam{Key: ":chain", Value: chainId},
+		MethodParam{Key: ":address", Value: address.Hex()},
+	)
+
+	if err := method.Verify(); err != nil {
+		return "", err
+	}
+
+	response, statusCode, err := client.CallMethodWithBody(method, request)
+	if err != nil {
+		return "", err
+	}
+
+	// Close the io.ReadCloser interface.
+	// This is important as CallMethodWithBody is NOT closing the response body!
+	// You'll have memory leaks if you don't do this!
+	defer response.Close()
+
+	if statusCode != http.StatusAccepted && statusCode != http.StatusOK {
+		if rErr := ToErrorResponse(response); rErr != nil {
+			return "", rErr
+		}
+
+		return "", &StatusError{StatusCode: statusCode}
+	}
+
+	var toReturn verificationAccepted
+	if err := json.NewDecoder(response).Decode(&toReturn); err != nil {
+		return "", err
+	}
+
+	return toReturn.VerificationID, nil
+}
@@ -0,0 +1,129 @@
+package sourcify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyContract(t *testing.T) {
+	chainID := 1
+	contractAddress := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+
+	localResponse, err := LoadContract(chainID, contractAddress)
+	require.NoError(t, err)
+
+	var attempts atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != fmt.Sprintf("/v2/verify/%d/%s", chainID, contractAddress.Hex()) {
+			http.NotFound(w, r)
+			return
+		}
+
+		attempts.Add(1)
+
+		var request VerifyRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "TetherToken.sol:TetherToken", request.ContractIdentifier)
+		assert.Equal(t, localResponse.StdJSONInput.Language, request.StdJSONInput.Language)
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"verificationId":"72d0b3d5-4b7c-4d4a-9f0e-3f1d2c3b4a5e"}`))
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(WithBaseURL(mockServer.URL), WithRetryOptions(WithMaxRetries(1), WithDelay(time.Millisecond)))
+
+	verificationId, err := VerifyContract(client, chainID, contractAddress, &VerifyRequest{
+		StdJSONInput:       &localResponse.StdJSONInput,
+		CompilerVersion:    localResponse.Compilation.CompilerVersion,
+		ContractIdentifier: "TetherToken.sol:TetherToken",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "72d0b3d5-4b7c-4d4a-9f0e-3f1d2c3b4a5e", verificationId)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestVerifyContract_NotRetried(t *testing.T) {
+	var attempts atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mockServer.Close()
+
+	// The submission may have been accepted before failing, so it is not sent again despite the retry options.
+	client := NewClient(WithBaseURL(mockServer.URL), WithRetryOptions(WithMaxRetries(3), WithDelay(time.Millisecond)))
+	_, err := VerifyContract(client, 1, common.HexToAddress("0x01"), &VerifyRequest{
+		StdJSONInput:       &StdJSONInput{Language: "Solidity"},
+		CompilerVersion:    "0.8.19+commit.7dd6d404",
+		ContractIdentifier: "A.sol:A",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestVerifyContractFromMetadata_Error(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"customCode":"already_verified","message":"Contract already verified","errorId":"a7d1f1a4-5b6c-4d2e-8f9a-0b1c2d3e4f5a"}`))
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(WithBaseURL(mockServer.URL))
+	_, err := VerifyContractFromMetadata(client, 1, common.HexToAddress("0x01"), &VerifyMetadataRequest{
+		Sources:  map[string]string{"A.sol": "contract A {}"},
+		Metadata: &Metadata{Language: "Solidity"},
+	})
+	assert.ErrorContains(t, err, "already_verified")
+}
+
+func TestWaitForVerification(t *testing.T) {
+	var polls atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/verify/done":
+			if polls.Add(1) < 3 {
+				_, _ = w.Write([]byte(`{"isJobCompleted":false,"verificationId":"done","jobStartTime":"2025-01-01T00:00:00Z"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"isJobCompleted":true,"verificationId":"done","jobStartTime":"2025-01-01T00:00:00Z","jobFinishTime":"2025-01-01T00:00:02Z","compilationTime":"1333","contract":{"match":"exact_match","chainId":"1","address":"0x0000000000000000000000000000000000000001","matchId":"42"}}`))
+		case "/v2/verify/failed":
+			_, _ = w.Write([]byte(`{"isJobCompleted":true,"verificationId":"failed","jobStartTime":"2025-01-01T00:00:00Z","contract":{"match":null,"chainId":"1","address":"0x0000000000000000000000000000000000000001"},"error":{"customCode":"no_match","message":"The onchain and recompiled bytecodes don't match.","errorId":"a7d1f1a4-5b6c-4d2e-8f9a-0b1c2d3e4f5a"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"customCode":"not_found","message":"No verification job found","errorId":"a7d1f1a4-5b6c-4d2e-8f9a-0b1c2d3e4f5a"}`))
+		}
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(WithBaseURL(mockServer.URL))
+
+	job, err := WaitForVerification(context.Background(), client, "done", time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, job.IsJobCompleted)
+	assert.Equal(t, "exact_match", job.Contract.Match)
+	assert.Equal(t, "42", job.Contract.MatchID)
+	require.NotNil(t, job.JobFinishTime)
+	assert.Equal(t, int32(3), polls.Load())
+
+	job, err = WaitForVerification(context.Background(), client, "failed", time.Millisecond)
+	assert.ErrorIs(t, err, ErrVerificationFailed)
+	require.NotNil(t, job)
+	assert.Equal(t, "no_match", job.Error.CustomCode)
+
+	_, err = GetVerificationJob(client, "unknown")
+	assert.ErrorContains(t, err, "not_found")
+}
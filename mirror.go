@@ -0,0 +1,375 @@
+package sourcify
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+)
+
+const (
+	// MirrorStateFile is the name of the file, at the root of the mirror directory, holding the MirrorState.
+	MirrorStateFile = "mirror-state.json"
+
+	// defaultMirrorPageSize is the number of contracts listed per request, the highest limit accepted by Sourcify.
+	defaultMirrorPageSize = 200
+
+	// defaultMirrorMaxAttempts is the number of attempts after which a failed contract is no longer retried.
+	defaultMirrorMaxAttempts = 5
+)
+
+// MirrorOption configures a Mirror.
+type MirrorOption func(*Mirror)
+
+// WithMirrorPageSize sets the number of contracts listed per GetContractsByChainId request.
+func WithMirrorPageSize(pageSize int) MirrorOption {
+	return func(m *Mirror) {
+		m.pageSize = pageSize
+	}
+}
+
+// WithMirrorMaxAttempts sets the number of attempts after which a contract of the dead-letter list is no longer
+// retried. It stays in the list so it can be inspected.
+func WithMirrorMaxAttempts(maxAttempts int) MirrorOption {
+	return func(m *Mirror) {
+		m.maxAttempts = maxAttempts
+	}
+}
+
+// MirrorFailure represents a contract that could not be mirrored and is retried on the next syncs.
+type MirrorFailure struct {
+	Address     common.Address `json:"address"`
+	MatchID     string         `json:"matchId"`
+	Error       string         `json:"error"`
+	Attempts    int            `json:"attempts"`
+	LastAttempt time.Time      `json:"lastAttempt"`
+}
+
+// MirrorChainState represents the progress of the mirror of a chain.
+type MirrorChainState struct {
+	LastMatchID string          `json:"lastMatchId"` // Match ID of the last listed contract, the cursor of the next sync.
+	SyncedAt    time.Time       `json:"syncedAt"`
+	DeadLetters []MirrorFailure `json:"deadLetters,omitempty"`
+}
+
+// MirrorState represents the progress of a mirror, persisted as MirrorStateFile.
+type MirrorState struct {
+	Chains map[int]*MirrorChainState `json:"chains"`
+}
+
+// MirrorChainReport summarizes the sync of a chain.
+type MirrorChainReport struct {
+	ChainID     int
+	Mirrored    int // Contracts listed since the previous sync and mirrored.
+	Recovered   int // Contracts of the dead-letter list mirrored on retry.
+	Failed      int // Contracts added to or kept in the dead-letter list.
+	LastMatchID string
+}
+
+// Mirror incrementally mirrors the verified contracts of chains to a local directory, laid out as the Sourcify
+// repository so it can be read back with OpenLocalRepository. Contracts are listed with GetContractsByChainId in
+// ascending match ID order from the last match ID of the previous sync, and their repository files are downloaded
+// and checked against the keccak256 hashes of the metadata before being moved into place. Contracts that fail are
+// kept in a per chain dead-letter list and retried on the next syncs.
+type Mirror struct {
+	client      *Client
+	dir         string
+	pageSize    int
+	maxAttempts int
+}
+
+// NewMirror creates a mirror of the contracts served by the client into dir.
+func NewMirror(client *Client, dir string, options ...MirrorOption) *Mirror {
+	toReturn := &Mirror{
+		client:      client,
+		dir:         dir,
+		pageSize:    defaultMirrorPageSize,
+		maxAttempts: defaultMirrorMaxAttempts,
+	}
+	for _, option := range options {
+		option(toReturn)
+	}
+	return toReturn
+}
+
+// State returns the persisted state of the mirror, empty when the mirror was never synced.
+func (m *Mirror) State() (*MirrorState, error) {
+	toReturn := &MirrorState{Chains: make(map[int]*MirrorChainState)}
+
+	data, err := os.ReadFile(filepath.Join(m.dir, MirrorStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return toReturn, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, toReturn); err != nil {
+		return nil, fmt.Errorf("failed to decode mirror state: %w", err)
+	}
+	if toReturn.Chains == nil {
+		toReturn.Chains = make(map[int]*MirrorChainState)
+	}
+	return toReturn, nil
+}
+
+// Sync mirrors the contracts verified on the chains since the previous sync, after retrying the dead-letter list
+// of each chain. The state is saved after every page, so an interrupted sync resumes where it stopped. Failures to
+// mirror a contract are recorded in the dead-letter list rather than returned.
+func (m *Mirror) Sync(ctx context.Context, chainIds ...int) ([]MirrorChainReport, error) {
+	state, err := m.State()
+	if err != nil {
+		return nil, err
+	}
+
+	toReturn := make([]MirrorChainReport, 0, len(chainIds))
+	for _, chainId := range chainIds {
+		chainState, ok := state.Chains[chainId]
+		if !ok {
+			chainState = &MirrorChainState{}
+			state.Chains[chainId] = chainState
+		}
+
+		report, err := m.syncChain(ctx, state, chainId, chainState)
+		toReturn = append(toReturn, report)
+		if err != nil {
+			return toReturn, err
+		}
+	}
+	return toReturn, nil
+}
+
+// syncChain retries the dead-letter list of the chain, then mirrors the contracts listed after its cursor.
+func (m *Mirror) syncChain(ctx context.Context, state *MirrorState, chainId int, chainState *MirrorChainState) (MirrorChainReport, error) {
+	toReturn := MirrorChainReport{ChainID: chainId, LastMatchID: chainState.LastMatchID}
+
+	var deadLetters []MirrorFailure
+	for _, failure := range chainState.DeadLetters {
+		if failure.Attempts >= m.maxAttempts {
+			deadLetters = append(deadLetters, failure)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return toReturn, err
+		}
+
+		if err := m.mirrorContract(ctx, chainId, failure.Address); err != nil {
+			failure.Error = err.Error()
+			failure.Attempts++
+			failure.LastAttempt = time.Now().UTC()
+			deadLetters = append(deadLetters, failure)
+			continue
+		}
+		toReturn.Recovered++
+	}
+	chainState.DeadLetters = deadLetters
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return m.finishChain(toReturn, state, chainState, err)
+		}
+
+		page, err := GetContractsByChainId(m.client, chainId, "asc", chainState.LastMatchID, m.pageSize)
+		if err != nil {
+			return m.finishChain(toReturn, state, chainState, fmt.Errorf("failed to list contracts of chain %d: %w", chainId, err))
+		}
+
+		for _, contract := range page.Results {
+			if err := m.mirrorContract(ctx, chainId, common.HexToAddress(contract.Address)); err != nil {
+				if ctx.Err() != nil {
+					return m.finishChain(toReturn, state, chainState, ctx.Err())
+				}
+				chainState.DeadLetters = append(chainState.DeadLetters, MirrorFailure{
+					Address:     common.HexToAddress(contract.Address),
+					MatchID:     contract.MatchID,
+					Error:       err.Error(),
+					Attempts:    1,
+					LastAttempt: time.Now().UTC(),
+				})
+			} else {
+				toReturn.Mirrored++
+			}
+			chainState.LastMatchID = contract.MatchID
+		}
+
+		if err := m.saveState(state); err != nil {
+			return toReturn, err
+		}
+
+		if len(page.Results) < m.pageSize {
+			break
+		}
+	}
+
+	chainState.SyncedAt = time.Now().UTC()
+	return m.finishChain(toReturn, state, chainState, nil)
+}
+
+// finishChain completes the report of the chain and saves the state, returning err or the error saving it.
+func (m *Mirror) finishChain(report MirrorChainReport, state *MirrorState, chainState *MirrorChainState, err error) (MirrorChainReport, error) {
+	report.LastMatchID = chainState.LastMatchID
+	report.Failed = len(chainState.DeadLetters)
+
+	if saveErr := m.saveState(state); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return report, err
+}
+
+// mirrorContract downloads the repository files of the contract into a staging directory, verifies them and moves
+// them into place, replacing any earlier copy of the contract.
+func (m *Mirror) mirrorContract(ctx context.Context, chainId int, address common.Address) error {
+	tree, err := GetContractFiles(m.client, chainId, address, MethodMatchTypeAny)
+	if err != nil {
+		return err
+	}
+
+	match := repositoryPartialMatchDir
+	if tree.Status == "full" || tree.Status == "perfect" {
+		match = repositoryFullMatchDir
+	}
+
+	// Files are staged inside the mirror so they can be renamed into place on the same file system.
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(m.dir, ".staging-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	for _, fileUrl := range tree.Files {
+		if err := m.downloadFile(ctx, staging, fileUrl, chainId, address); err != nil {
+			return err
+		}
+	}
+
+	if err := verifyMirroredSources(staging); err != nil {
+		return err
+	}
+
+	for _, dir := range []string{repositoryFullMatchDir, repositoryPartialMatchDir} {
+		if err := os.RemoveAll(filepath.Join(m.dir, "contracts", dir, strconv.Itoa(chainId), address.Hex())); err != nil {
+			return err
+		}
+	}
+
+	target := filepath.Join(m.dir, "contracts", match, strconv.Itoa(chainId), address.Hex())
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.Rename(staging, target)
+}
+
+// downloadFile downloads a repository file of the contract into its path under dir.
+func (m *Mirror) downloadFile(ctx context.Context, dir string, fileUrl string, chainId int, address common.Address) error {
+	name, err := archiveFileName(fileUrl, chainId, address)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(name, archiveFilesDir+"/")))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileUrl, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	response, statusCode, err := m.client.doRequestWithRetry(req)
+	if err != nil {
+		return err
+	}
+
+	// Close the io.ReadCloser interface.
+	// This is important as doRequestWithRetry is NOT closing the response body!
+	defer response.Close()
+
+	if statusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %w", fileUrl, &StatusError{StatusCode: statusCode})
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, response); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to download %s: %w", fileUrl, err)
+	}
+	return file.Close()
+}
+
+// verifyMirroredSources checks the sources of a downloaded contract directory against the keccak256 hashes of its
+// metadata. Sources whose content is embedded in the metadata are not stored as files and are skipped.
+func verifyMirroredSources(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: metadata.json", ErrFileNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	var metadata Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return fmt.Errorf("failed to decode metadata: %w", err)
+	}
+
+	for name, source := range metadata.Sources {
+		if source.Keccak256 == "" || source.Content != "" {
+			continue
+		}
+
+		sanitized, err := SanitizeSourcePath(name)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, "sources", filepath.FromSlash(sanitized)))
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrFileNotFound, path.Join("sources", sanitized))
+		}
+		if err != nil {
+			return err
+		}
+
+		hash := "0x" + hex.EncodeToString(keccak256(content))
+		if !strings.EqualFold(hash, source.Keccak256) {
+			return fmt.Errorf("%w: %s has %s, expected %s", ErrSourceHashMismatch, name, hash, source.Keccak256)
+		}
+	}
+	return nil
+}
+
+// saveState writes the state of the mirror, replacing the previous one atomically.
+func (m *Mirror) saveState(state *MirrorState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode mirror state: %w", err)
+	}
+
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return err
+	}
+
+	temp := filepath.Join(m.dir, MirrorStateFile+".tmp")
+	if err := os.WriteFile(temp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write mirror state: %w", err)
+	}
+	return os.Rename(temp, filepath.Join(m.dir, MirrorStateFile))
+}
@@ -0,0 +1,249 @@
+package sourcify
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mirrorTestContract is a contract served by mirrorTestServer.
+type mirrorTestContract struct {
+	address common.Address
+	matchId int
+	full    bool
+	files   map[string]string
+}
+
+// mirrorTestServer serves the listing, file tree and repository endpoints used by Mirror and Watcher.
+type mirrorTestServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	contracts []*mirrorTestContract
+	listings  []string // afterMatchId of every listing request.
+}
+
+func newMirrorTestServer(t *testing.T) *mirrorTestServer {
+	toReturn := &mirrorTestServer{}
+	toReturn.Server = httptest.NewServer(http.HandlerFunc(toReturn.serve))
+	t.Cleanup(toReturn.Close)
+	return toReturn
+}
+
+func (s *mirrorTestServer) add(contract *mirrorTestContract) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contracts = append(s.contracts, contract)
+}
+
+func (s *mirrorTestServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/v2/contracts/1" {
+		after, _ := strconv.Atoi(r.URL.Query().Get("afterMatchId"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		s.listings = append(s.listings, r.URL.Query().Get("afterMatchId"))
+
+		contracts := s.contracts
+		if r.URL.Query().Get("sort") == "desc" {
+			contracts = make([]*mirrorTestContract, 0, len(s.contracts))
+			for i := len(s.contracts) - 1; i >= 0; i-- {
+				if after == 0 || s.contracts[i].matchId < after {
+					contracts = append(contracts, s.contracts[i])
+				}
+			}
+			after = 0
+		}
+
+		response := ContractsResponse{Results: []ContractBaseResponse{}}
+		for _, contract := range contracts {
+			if contract.matchId > after && len(response.Results) < limit {
+				response.Results = append(response.Results, ContractBaseResponse{
+					Address: contract.address.Hex(),
+					ChainID: "1",
+					MatchID: strconv.Itoa(contract.matchId),
+				})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	for _, contract := range s.contracts {
+		match := "partial_match"
+		if contract.full {
+			match = "full_match"
+		}
+		repository := fmt.Sprintf("/repository/contracts/%s/1/%s/", match, contract.address.Hex())
+
+		switch {
+		case r.URL.Path == "/files/tree/any/1/"+contract.address.Hex():
+			tree := FileTree{Status: "partial"}
+			if contract.full {
+				tree.Status = "full"
+			}
+			for name := range contract.files {
+				tree.Files = append(tree.Files, s.URL+repository+name)
+			}
+			_ = json.NewEncoder(w).Encode(tree)
+			return
+		case strings.HasPrefix(r.URL.Path, repository):
+			if content, ok := contract.files[strings.TrimPrefix(r.URL.Path, repository)]; ok {
+				_, _ = w.Write([]byte(content))
+				return
+			}
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// newMirrorTestContract returns a contract with a single source whose hash is recorded in its metadata.
+func newMirrorTestContract(t *testing.T, matchId int, source string) *mirrorTestContract {
+	metadata, err := json.Marshal(Metadata{
+		Language: "Solidity",
+		Compiler: Compiler{Version: "0.8.19+commit.7dd6d404"},
+		Sources: map[string]MetadataSource{
+			"contracts/C.sol": {Keccak256: "0x" + hex.EncodeToString(keccak256([]byte(source)))},
+		},
+	})
+	require.NoError(t, err)
+
+	return &mirrorTestContract{
+		address: common.BigToAddress(common.Big1.Lsh(common.Big1, uint(matchId))),
+		matchId: matchId,
+		full:    matchId%2 == 0,
+		files: map[string]string{
+			"metadata.json":           string(metadata),
+			"sources/contracts/C.sol": source,
+		},
+	}
+}
+
+func TestMirrorSync(t *testing.T) {
+	server := newMirrorTestServer(t)
+	for matchId := 1; matchId <= 3; matchId++ {
+		server.add(newMirrorTestContract(t, matchId, fmt.Sprintf("contract C%d {}", matchId)))
+	}
+
+	dir := t.TempDir()
+	mirror := NewMirror(NewClient(WithBaseURL(server.URL)), dir, WithMirrorPageSize(2))
+
+	reports, err := mirror.Sync(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, MirrorChainReport{ChainID: 1, Mirrored: 3, LastMatchID: "3"}, reports[0])
+	assert.Equal(t, []string{"", "2"}, server.listings)
+
+	state, err := mirror.State()
+	require.NoError(t, err)
+	assert.Equal(t, "3", state.Chains[1].LastMatchID)
+	assert.False(t, state.Chains[1].SyncedAt.IsZero())
+
+	repository, err := OpenLocalRepository(dir)
+	require.NoError(t, err)
+
+	addresses, err := repository.GetAvailableContractAddresses(1)
+	require.NoError(t, err)
+	assert.Len(t, addresses.Full, 1)
+	assert.Len(t, addresses.Partial, 2)
+
+	content, err := repository.ReadFile(1, server.contracts[1].address, MethodMatchTypeFull, "sources/contracts/C.sol")
+	require.NoError(t, err)
+	assert.Equal(t, "contract C2 {}", string(content))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, strings.HasPrefix(entry.Name(), ".staging-"), "staging directory %s left behind", entry.Name())
+	}
+
+	// The next sync only lists the contracts verified since.
+	server.add(newMirrorTestContract(t, 4, "contract C4 {}"))
+	server.listings = nil
+
+	reports, err = mirror.Sync(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, MirrorChainReport{ChainID: 1, Mirrored: 1, LastMatchID: "4"}, reports[0])
+	assert.Equal(t, []string{"3"}, server.listings)
+}
+
+func TestMirrorDeadLetters(t *testing.T) {
+	server := newMirrorTestServer(t)
+	valid := newMirrorTestContract(t, 1, "contract C1 {}")
+	tampered := newMirrorTestContract(t, 2, "contract C2 {}")
+	tampered.files["sources/contracts/C.sol"] = "contract Tampered {}"
+	server.add(valid)
+	server.add(tampered)
+
+	dir := t.TempDir()
+	mirror := NewMirror(NewClient(WithBaseURL(server.URL)), dir, WithMirrorMaxAttempts(2))
+
+	reports, err := mirror.Sync(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, MirrorChainReport{ChainID: 1, Mirrored: 1, Failed: 1, LastMatchID: "2"}, reports[0])
+
+	state, err := mirror.State()
+	require.NoError(t, err)
+	require.Len(t, state.Chains[1].DeadLetters, 1)
+	failure := state.Chains[1].DeadLetters[0]
+	assert.Equal(t, tampered.address, failure.Address)
+	assert.Equal(t, "2", failure.MatchID)
+	assert.Equal(t, 1, failure.Attempts)
+	assert.Contains(t, failure.Error, ErrSourceHashMismatch.Error())
+	assert.NoDirExists(t, filepath.Join(dir, "contracts", "full_match", "1", tampered.address.Hex()))
+
+	// A failed retry counts as an attempt, after which the contract is no longer retried.
+	reports, err = mirror.Sync(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, MirrorChainReport{ChainID: 1, Failed: 1, LastMatchID: "2"}, reports[0])
+
+	state, err = mirror.State()
+	require.NoError(t, err)
+	assert.Equal(t, 2, state.Chains[1].DeadLetters[0].Attempts)
+
+	tampered.files["sources/contracts/C.sol"] = "contract C2 {}"
+	reports, err = mirror.Sync(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, reports[0].Recovered)
+
+	// Contracts are retried as long as they have attempts left.
+	mirror = NewMirror(NewClient(WithBaseURL(server.URL)), dir, WithMirrorMaxAttempts(3))
+	reports, err = mirror.Sync(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, MirrorChainReport{ChainID: 1, Recovered: 1, LastMatchID: "2"}, reports[0])
+	assert.DirExists(t, filepath.Join(dir, "contracts", "full_match", "1", tampered.address.Hex()))
+
+	state, err = mirror.State()
+	require.NoError(t, err)
+	assert.Empty(t, state.Chains[1].DeadLetters)
+}
+
+func TestMirrorSyncCanceled(t *testing.T) {
+	server := newMirrorTestServer(t)
+	server.add(newMirrorTestContract(t, 1, "contract C1 {}"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mirror := NewMirror(NewClient(WithBaseURL(server.URL)), t.TempDir())
+	_, err := mirror.Sync(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	state, err := mirror.State()
+	require.NoError(t, err)
+	assert.Empty(t, state.Chains[1].LastMatchID)
+}
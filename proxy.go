@@ -0,0 +1,178 @@
+package sourcify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxProxyDepth bounds how many proxy levels ResolveContract follows, e.g. proxy -> beacon -> implementation.
+const maxProxyDepth = 8
+
+// ProxyImplementation represents an implementation contract detected behind a proxy.
+type ProxyImplementation struct {
+	Address string `json:"address"`
+	Name    string `json:"name,omitempty"`
+}
+
+// UnmarshalJSON decodes an implementation from either a plain address string or an object with address and name.
+func (p *ProxyImplementation) UnmarshalJSON(data []byte) error {
+	var address string
+	if err := json.Unmarshal(data, &address); err == nil {
+		*p = ProxyImplementation{Address: address}
+		return nil
+	}
+
+	type plain ProxyImplementation
+	var toReturn plain
+	if err := json.Unmarshal(data, &toReturn); err != nil {
+		return fmt.Errorf("invalid proxy implementation: %w", err)
+	}
+
+	*p = ProxyImplementation(toReturn)
+	return nil
+}
+
+// ResolvedContract represents a contract together with the verified implementations it delegates to.
+type ResolvedContract struct {
+	Address         common.Address      // Address of the contract.
+	Contract        *ContractResponse   // Verified contract, nil if it could not be fetched.
+	Err             error               // Error returned while fetching the contract, if any.
+	Implementations []*ResolvedContract // Implementations when the contract is a proxy, resolved recursively.
+	Abi             []ABIEntry          // Effective ABI of the proxy merged with all of its implementations.
+}
+
+// IsProxy reports whether the contract was detected as a proxy.
+func (r *ResolvedContract) IsProxy() bool {
+	return r.Contract != nil && r.Contract.ProxyResolution.IsProxy
+}
+
+// Contracts returns the contract and all of its resolved implementations in depth first order.
+func (r *ResolvedContract) Contracts() []*ContractResponse {
+	var toReturn []*ContractResponse
+	if r.Contract != nil {
+		toReturn = append(toReturn, r.Contract)
+	}
+	for _, implementation := range r.Implementations {
+		toReturn = append(toReturn, implementation.Contracts()...)
+	}
+	return toReturn
+}
+
+// ResolveContract retrieves a verified contract and, if Sourcify detected it as a proxy, fetches every
+// implementation as well. Implementations that are proxies themselves, as with beacons or diamonds whose facets
+// delegate further, are followed recursively. Implementations that are not verified do not fail the resolution;
+// their error is recorded on the corresponding ResolvedContract instead.
+// The returned Abi merges the proxy ABI with the ABIs of its implementations, so it describes the effective
+// interface callable at the proxy address.
+func ResolveContract(ctx context.Context, client *Client, chainId int, address common.Address) (*ResolvedContract, error) {
+	toReturn, err := resolveContract(ctx, client, chainId, address, map[common.Address]bool{}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if toReturn.Err != nil {
+		return nil, toReturn.Err
+	}
+
+	return toReturn, nil
+}
+
+// resolveContract fetches the contract at address and follows its implementations until maxProxyDepth.
+func resolveContract(ctx context.Context, client *Client, chainId int, address common.Address, visited map[common.Address]bool, depth int) (*ResolvedContract, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	toReturn := &ResolvedContract{Address: address}
+	visited[address] = true
+
+	contract, err := GetContractByChainIdAndAddress(client, chainId, address, []string{"all"}, nil)
+	if err != nil {
+		toReturn.Err = err
+		return toReturn, nil
+	}
+	toReturn.Contract = contract
+
+	if contract.ProxyResolution.IsProxy && depth < maxProxyDepth {
+		for _, implementation := range contract.ProxyResolution.Implementations {
+			if !common.IsHexAddress(implementation.Address) {
+				continue
+			}
+
+			implementationAddress := common.HexToAddress(implementation.Address)
+			if visited[implementationAddress] {
+				continue
+			}
+
+			resolved, err := resolveContract(ctx, client, chainId, implementationAddress, visited, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			toReturn.Implementations = append(toReturn.Implementations, resolved)
+		}
+	}
+
+	abis := [][]ABIEntry{contract.Abi}
+	for _, implementation := range toReturn.Implementations {
+		abis = append(abis, implementation.Abi)
+	}
+	toReturn.Abi = MergeABIs(abis...)
+
+	return toReturn, nil
+}
+
+// MergeABIs merges the given ABIs into a single ABI without duplicates. Entries are identified by their type,
+// name and input types, and the first occurrence wins, so the proxy ABI should be passed first.
+// Only the constructor of the first ABI that has one is kept, as implementation constructors are never
+// callable through a proxy.
+func MergeABIs(abis ...[]ABIEntry) []ABIEntry {
+	toReturn := make([]ABIEntry, 0)
+	seen := make(map[string]bool)
+
+	for i, abi := range abis {
+		for _, entry := range abi {
+			if entry.Type == "constructor" && i > 0 {
+				continue
+			}
+
+			key := abiEntryKey(entry)
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			toReturn = append(toReturn, entry)
+		}
+	}
+
+	return toReturn
+}
+
+// abiEntryKey returns the identity of an ABI entry, e.g. `function:transfer(address,uint256)` or
+// `function:submit((uint256,address))`.
+func abiEntryKey(entry ABIEntry) string {
+	switch entry.Type {
+	case "constructor", "fallback", "receive":
+		return entry.Type
+	}
+
+	return fmt.Sprintf("%s:%s%s", entry.Type, entry.Name, canonicalABITypes(entry.Inputs))
+}
+
+// canonicalABITypes returns the canonical type list of the parameters, with tuples expanded into their
+// components, e.g. `(address,(uint256,bytes32)[])`.
+func canonicalABITypes(parameters []ABIParameter) string {
+	types := make([]string, 0, len(parameters))
+	for _, parameter := range parameters {
+		if suffix, ok := strings.CutPrefix(parameter.Type, "tuple"); ok {
+			types = append(types, canonicalABITypes(parameter.Components)+suffix)
+			continue
+		}
+		types = append(types, parameter.Type)
+	}
+	return "(" + strings.Join(types, ",") + ")"
+}
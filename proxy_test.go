@@ -0,0 +1,141 @@
+package sourcify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyImplementation_UnmarshalJSON(t *testing.T) {
+	var resolution ProxyResolution
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"isProxy": true,
+		"proxyType": "EIP1967Proxy",
+		"implementations": [
+			"0x0000000000000000000000000000000000000001",
+			{"address": "0x0000000000000000000000000000000000000002", "name": "TokenV2"}
+		]
+	}`), &resolution))
+
+	assert.Equal(t, []ProxyImplementation{
+		{Address: "0x0000000000000000000000000000000000000001"},
+		{Address: "0x0000000000000000000000000000000000000002", Name: "TokenV2"},
+	}, resolution.Implementations)
+}
+
+func TestResolveContract(t *testing.T) {
+	chainID := 1
+	proxy := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	beacon := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	implementation := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	unverified := common.HexToAddress("0x00000000000000000000000000000000000000dd")
+
+	function := func(name string, inputs ...string) ABIEntry {
+		entry := ABIEntry{Type: "function", Name: name}
+		for _, input := range inputs {
+			entry.Inputs = append(entry.Inputs, ABIParameter{Type: input})
+		}
+		return entry
+	}
+
+	contracts := map[common.Address]ContractResponse{
+		proxy: {
+			Address: proxy.Hex(),
+			Abi:     []ABIEntry{{Type: "constructor"}, {Type: "fallback"}, function("upgradeTo", "address")},
+			ProxyResolution: ProxyResolution{
+				IsProxy:         true,
+				ProxyType:       "BeaconProxy",
+				Implementations: []ProxyImplementation{{Address: beacon.Hex()}, {Address: unverified.Hex()}},
+			},
+		},
+		beacon: {
+			Address: beacon.Hex(),
+			Abi:     []ABIEntry{function("implementation")},
+			ProxyResolution: ProxyResolution{
+				IsProxy:         true,
+				Implementations: []ProxyImplementation{{Address: implementation.Hex()}, {Address: proxy.Hex()}},
+			},
+		},
+		implementation: {
+			Address: implementation.Hex(),
+			Abi:     []ABIEntry{{Type: "constructor"}, function("transfer", "address", "uint256"), function("upgradeTo", "address")},
+		},
+	}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for address, contract := range contracts {
+			if r.URL.Path == fmt.Sprintf("/v2/contract/%d/%s", chainID, address.Hex()) {
+				require.NoError(t, json.NewEncoder(w).Encode(contract))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"customCode": "not_found", "message": "Contract not found"}`))
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(WithBaseURL(mockServer.URL))
+
+	resolved, err := ResolveContract(context.Background(), client, chainID, proxy)
+	require.NoError(t, err)
+	assert.True(t, resolved.IsProxy())
+	require.Len(t, resolved.Implementations, 2)
+
+	beaconResolved := resolved.Implementations[0]
+	require.Len(t, beaconResolved.Implementations, 1)
+	assert.Equal(t, implementation, beaconResolved.Implementations[0].Address)
+
+	assert.Nil(t, resolved.Implementations[1].Contract)
+	assert.ErrorContains(t, resolved.Implementations[1].Err, "Contract not found")
+
+	assert.Len(t, resolved.Contracts(), 3)
+
+	keys := make([]string, 0, len(resolved.Abi))
+	for _, entry := range resolved.Abi {
+		keys = append(keys, abiEntryKey(entry))
+	}
+	assert.Equal(t, []string{
+		"constructor",
+		"fallback",
+		"function:upgradeTo(address)",
+		"function:implementation()",
+		"function:transfer(address,uint256)",
+	}, keys)
+
+	_, err = ResolveContract(context.Background(), client, chainID, unverified)
+	assert.ErrorContains(t, err, "Contract not found")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ResolveContract(ctx, client, chainID, proxy)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMergeABIs_TupleOverloads(t *testing.T) {
+	tuple := func(types ...string) ABIParameter {
+		parameter := ABIParameter{Type: "tuple"}
+		for _, componentType := range types {
+			parameter.Components = append(parameter.Components, ABIParameter{Type: componentType})
+		}
+		return parameter
+	}
+
+	proxy := []ABIEntry{{Type: "function", Name: "f", Inputs: []ABIParameter{tuple("uint256", "address")}}}
+	implementation := []ABIEntry{
+		{Type: "function", Name: "f", Inputs: []ABIParameter{tuple("bytes32")}},
+		{Type: "function", Name: "f", Inputs: []ABIParameter{tuple("uint256", "address")}},
+		{Type: "function", Name: "g", Inputs: []ABIParameter{{Type: "tuple[]", Components: []ABIParameter{tuple("uint8")}}}},
+	}
+
+	merged := MergeABIs(proxy, implementation)
+	require.Len(t, merged, 3)
+	assert.Equal(t, "bytes32", merged[1].Inputs[0].Components[0].Type)
+	assert.Equal(t, "function:g(((uint8))[])", abiEntryKey(merged[2]))
+}
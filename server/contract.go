@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/unpackdev/sourcify-go"
+)
+
+// contract builds the `/v2/contract` response of a contract from its repository files. The ABI, documentation,
+// compilation and standard JSON input are derived from the metadata, and the sources are read from the
+// `sources` directory unless the metadata holds their literal content. Bytecode, deployment, match ID and
+// verification time are not part of the repository and are left empty.
+func (s *Server) contract(chainId int, address common.Address) (*sourcify.ContractResponse, error) {
+	codes, err := s.backend.GetContractSourceCode(chainId, address, sourcify.MethodMatchTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string, len(codes.Code))
+	for _, code := range codes.Code {
+		files[contractFileName(code.Path, address)] = code.Content
+	}
+
+	raw, ok := files["metadata.json"]
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata.json", sourcify.ErrFileNotFound)
+	}
+
+	var metadata sourcify.Metadata
+	if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+
+	sources := make(sourcify.Sources, len(metadata.Sources))
+	for _, name := range sortedKeys(metadata.Sources) {
+		if content := metadata.Sources[name].Content; content != "" {
+			sources[name] = sourcify.SourceContent{Content: content}
+			continue
+		}
+
+		sanitized, err := sourcify.SanitizeSourcePath(name)
+		if err != nil {
+			continue
+		}
+		if content, ok := files["sources/"+sanitized]; ok {
+			sources[name] = sourcify.SourceContent{Content: content}
+		}
+	}
+
+	match := "match"
+	if codes.Status == "full" {
+		match = "exact_match"
+	}
+
+	toReturn := &sourcify.ContractResponse{
+		Abi:          metadata.Output.Abi,
+		Address:      address.Hex(),
+		ChainID:      strconv.Itoa(chainId),
+		Compilation:  compilation(&metadata),
+		DevDoc:       metadata.Output.Devdoc,
+		Match:        match,
+		Metadata:     metadata,
+		RuntimeMatch: match,
+		Sources:      sources,
+		UserDoc:      metadata.Output.Userdoc,
+	}
+	if input, err := sourcify.BuildStdJSONInput(&metadata, sources); err == nil {
+		toReturn.StdJSONInput = *input
+	}
+	return toReturn, nil
+}
+
+// compilation returns the compilation details recorded in the metadata.
+func compilation(metadata *sourcify.Metadata) sourcify.Compilation {
+	toReturn := sourcify.Compilation{
+		Compiler:        "solc",
+		CompilerVersion: metadata.Compiler.Version,
+		Language:        metadata.Language,
+		CompilerSettings: sourcify.EVMVersion{
+			EvmVersion: metadata.Settings.EvmVersion,
+			Libraries:  metadata.Settings.Libraries,
+			Optimizer:  metadata.Settings.Optimizer,
+			Remappings: make([]interface{}, 0, len(metadata.Settings.Remappings)),
+		},
+	}
+	if strings.EqualFold(metadata.Language, "vyper") {
+		toReturn.Compiler = "vyper"
+	}
+	for _, remapping := range metadata.Settings.Remappings {
+		toReturn.CompilerSettings.Remappings = append(toReturn.CompilerSettings.Remappings, remapping)
+	}
+	for file, name := range metadata.Settings.CompilationTarget {
+		toReturn.FullyQualifiedName = file + ":" + name
+		toReturn.Name = name
+	}
+	return toReturn
+}
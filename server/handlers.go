@@ -0,0 +1,369 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/unpackdev/sourcify-go"
+)
+
+// chainGetter is implemented by backends able to list the chains known to Sourcify, such as sourcify.Client.
+type chainGetter interface {
+	GetChains() ([]sourcify.Chain, error)
+}
+
+// routes registers the endpoints of the server.
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("GET /chains", s.handleChains)
+	s.mux.HandleFunc("GET /v2/contract/{chain}/{address}", s.handleContract)
+	s.mux.HandleFunc("GET /files/contracts/{chain}", s.handleAddresses)
+	s.mux.HandleFunc("GET /files/tree/any/{chain}/{address}", s.handleFileTree(sourcify.MethodMatchTypeAny))
+	s.mux.HandleFunc("GET /files/tree/{chain}/{address}", s.handleFileTree(sourcify.MethodMatchTypeFull))
+	s.mux.HandleFunc("GET /files/any/{chain}/{address}", s.handleSourceFiles(sourcify.MethodMatchTypeAny))
+	s.mux.HandleFunc("GET /files/{chain}/{address}", s.handleSourceFiles(sourcify.MethodMatchTypeFull))
+	s.mux.HandleFunc("GET /repository/contracts/{match}/{chain}/{address}/{path...}", s.handleRepositoryFile)
+	s.mux.HandleFunc("GET /check-by-addresses", s.handleCheckByAddresses(sourcify.MethodMatchTypeFull))
+	s.mux.HandleFunc("GET /check-all-by-addresses", s.handleCheckByAddresses(sourcify.MethodMatchTypeAny))
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("Alive and kicking!"))
+}
+
+func (s *Server) handleChains(w http.ResponseWriter, r *http.Request) {
+	if s.chains != nil {
+		writeJSON(w, http.StatusOK, s.chains)
+		return
+	}
+
+	switch backend := s.backend.(type) {
+	case ChainLister:
+		ids, err := backend.Chains()
+		if err != nil {
+			writeBackendError(w, err)
+			return
+		}
+
+		toReturn := make([]sourcify.Chain, 0, len(ids))
+		for _, id := range ids {
+			toReturn = append(toReturn, sourcify.Chain{
+				Name:      fmt.Sprintf("Chain %d", id),
+				ChainID:   id,
+				NetworkID: id,
+				Supported: true,
+				RPC:       []string{},
+				Faucets:   []any{},
+			})
+		}
+		writeJSON(w, http.StatusOK, toReturn)
+	case chainGetter:
+		toReturn, err := backend.GetChains()
+		if err != nil {
+			writeBackendError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toReturn)
+	default:
+		writeJSON(w, http.StatusOK, []sourcify.Chain{})
+	}
+}
+
+func (s *Server) handleContract(w http.ResponseWriter, r *http.Request) {
+	chainId, address, ok := contractParams(w, r)
+	if !ok {
+		return
+	}
+
+	contract, err := s.contract(chainId, address)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	toReturn, err := sourcify.FilterContractFields(contract, splitList(query.Get("fields")), splitList(query.Get("omit")))
+	switch {
+	case errors.Is(err, sourcify.ErrFieldsAndOmit), errors.Is(err, sourcify.ErrUnknownContractField):
+		writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	default:
+		writeJSON(w, http.StatusOK, toReturn)
+	}
+}
+
+func (s *Server) handleAddresses(w http.ResponseWriter, r *http.Request) {
+	chainId, err := strconv.Atoi(r.PathValue("chain"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid chain id: %s", r.PathValue("chain")))
+		return
+	}
+
+	toReturn, err := s.backend.GetAvailableContractAddresses(chainId)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toReturn)
+}
+
+func (s *Server) handleFileTree(matchType sourcify.MethodMatchType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chainId, address, ok := contractParams(w, r)
+		if !ok {
+			return
+		}
+
+		tree, err := s.backend.GetContractFiles(chainId, address, matchType)
+		if err != nil {
+			writeBackendError(w, err)
+			return
+		}
+
+		toReturn := sourcify.FileTree{Status: tree.Status, Files: make([]string, 0, len(tree.Files))}
+		for _, file := range tree.Files {
+			toReturn.Files = append(toReturn.Files, s.fileURL(r, file))
+		}
+		writeJSON(w, http.StatusOK, toReturn)
+	}
+}
+
+func (s *Server) handleSourceFiles(matchType sourcify.MethodMatchType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chainId, address, ok := contractParams(w, r)
+		if !ok {
+			return
+		}
+
+		toReturn, err := s.backend.GetContractSourceCode(chainId, address, matchType)
+		if err != nil {
+			writeBackendError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toReturn)
+	}
+}
+
+func (s *Server) handleRepositoryFile(w http.ResponseWriter, r *http.Request) {
+	var matchType sourcify.MethodMatchType
+	switch r.PathValue("match") {
+	case "full_match":
+		matchType = sourcify.MethodMatchTypeFull
+	case "partial_match":
+		matchType = sourcify.MethodMatchTypePartial
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	chainId, address, ok := contractParams(w, r)
+	if !ok {
+		return
+	}
+
+	name := r.PathValue("path")
+	content, err := s.readFile(chainId, address, matchType, name)
+	if err != nil {
+		writeBackendError(w, err)
+		return
+	}
+
+	if strings.HasSuffix(name, ".json") {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	_, _ = w.Write(content)
+}
+
+func (s *Server) handleCheckByAddresses(matchType sourcify.MethodMatchType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		addresses, chains := splitList(query.Get("addresses")), splitList(query.Get("chainIds"))
+		if len(addresses) == 0 || len(chains) == 0 {
+			writeError(w, http.StatusBadRequest, "invalid_parameter", "addresses and chainIds are required")
+			return
+		}
+
+		for _, address := range addresses {
+			if !common.IsHexAddress(address) {
+				writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid address: %s", address))
+				return
+			}
+		}
+
+		chainIds := make([]int, 0, len(chains))
+		for _, chain := range chains {
+			chainId, err := strconv.Atoi(chain)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid chain id: %s", chain))
+				return
+			}
+			chainIds = append(chainIds, chainId)
+		}
+
+		results, err := s.backend.CheckContractByAddresses(addresses, chainIds, matchType)
+		if err != nil {
+			writeBackendError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, checkResponse(results, matchType))
+	}
+}
+
+// checkResponse converts the results of Backend.CheckContractByAddresses, which hold one result per address and
+// chain for the any match type, back into the responses of Sourcify: one result per address, listing its chains
+// and, for `/check-all-by-addresses`, the status on each of them.
+func checkResponse(results []*sourcify.CheckContractAddress, matchType sourcify.MethodMatchType) []map[string]any {
+	toReturn := make([]map[string]any, 0, len(results))
+	byAddress := make(map[common.Address]map[string]any)
+	for _, result := range results {
+		if result.Status == "false" || len(result.ChainIDs) == 0 {
+			toReturn = append(toReturn, map[string]any{"address": result.Address.Hex(), "status": "false"})
+			continue
+		}
+
+		if matchType == sourcify.MethodMatchTypeFull {
+			toReturn = append(toReturn, map[string]any{"address": result.Address.Hex(), "status": result.Status, "chainIds": result.ChainIDs})
+			continue
+		}
+
+		entry, ok := byAddress[result.Address]
+		if !ok {
+			entry = map[string]any{"address": result.Address.Hex(), "chainIds": []sourcify.CheckContractAddressMoreInfo{}}
+			byAddress[result.Address] = entry
+			toReturn = append(toReturn, entry)
+		}
+		for _, chainId := range result.ChainIDs {
+			entry["chainIds"] = append(entry["chainIds"].([]sourcify.CheckContractAddressMoreInfo), sourcify.CheckContractAddressMoreInfo{
+				Status:  result.Status,
+				ChainID: chainId,
+			})
+		}
+	}
+	return toReturn
+}
+
+// readFile returns a file of the contract by its path relative to the contract directory.
+func (s *Server) readFile(chainId int, address common.Address, matchType sourcify.MethodMatchType, name string) ([]byte, error) {
+	if reader, ok := s.backend.(FileReader); ok {
+		return reader.ReadFile(chainId, address, matchType, name)
+	}
+
+	codes, err := s.backend.GetContractSourceCode(chainId, address, matchType)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range codes.Code {
+		if contractFileName(code.Path, address) == name {
+			return []byte(code.Content), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", sourcify.ErrFileNotFound, name)
+}
+
+// fileURL rewrites a repository file path or URL returned by the backend into a URL of the server.
+func (s *Server) fileURL(r *http.Request, file string) string {
+	index := strings.Index(file, "contracts/full_match/")
+	if index < 0 {
+		index = strings.Index(file, "contracts/partial_match/")
+	}
+	if index < 0 {
+		return file
+	}
+
+	base := s.baseURL
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+	return base + "/repository/" + file[index:]
+}
+
+// contractFileName returns the path of a contract file relative to the contract directory, given its repository
+// path, e.g. `sources/contracts/Token.sol` for `contracts/full_match/1/0x.../sources/contracts/Token.sol`.
+func contractFileName(filePath string, address common.Address) string {
+	marker := strings.ToLower(address.Hex()) + "/"
+	index := strings.LastIndex(strings.ToLower(filePath), marker)
+	if index < 0 {
+		return filePath
+	}
+	return filePath[index+len(marker):]
+}
+
+// contractParams parses the chain and address path values, answering with an error when they are invalid.
+func contractParams(w http.ResponseWriter, r *http.Request) (int, common.Address, bool) {
+	chainId, err := strconv.Atoi(r.PathValue("chain"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid chain id: %s", r.PathValue("chain")))
+		return 0, common.Address{}, false
+	}
+
+	if !common.IsHexAddress(r.PathValue("address")) {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid address: %s", r.PathValue("address")))
+		return 0, common.Address{}, false
+	}
+	return chainId, common.HexToAddress(r.PathValue("address")), true
+}
+
+// splitList splits a comma separated query value, dropping empty elements.
+func splitList(value string) []string {
+	toReturn := []string{}
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			toReturn = append(toReturn, element)
+		}
+	}
+	return toReturn
+}
+
+// sortedKeys returns the keys of m, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	toReturn := make([]string, 0, len(m))
+	for key := range m {
+		toReturn = append(toReturn, key)
+	}
+	sort.Strings(toReturn)
+	return toReturn
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeBackendError writes the error returned by the backend, answering with not found for missing contracts and
+// files.
+func writeBackendError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sourcify.ErrContractNotFound), errors.Is(err, sourcify.ErrFileNotFound):
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, sourcify.ErrUnsafePath):
+		writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
+// writeError writes an error response in the format used by Sourcify.
+func writeError(w http.ResponseWriter, statusCode int, customCode string, message string) {
+	writeJSON(w, statusCode, sourcify.ErrorResponse{
+		ErrorId:    uuid.New(),
+		CustomCode: customCode,
+		Message:    message,
+	})
+}
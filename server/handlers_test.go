@@ -0,0 +1,127 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unpackdev/sourcify-go"
+)
+
+// backendOnly hides the optional interfaces of a backend.
+type backendOnly struct {
+	sourcify.Backend
+}
+
+func get(t *testing.T, handler http.Handler, uri string) (*http.Response, []byte) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, uri, nil))
+
+	response := recorder.Result()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return response, body
+}
+
+func TestHandleRepositoryFile(t *testing.T) {
+	repository := newRepository(t)
+
+	for name, handler := range map[string]http.Handler{
+		"file reader": New(repository),
+		"source code": New(backendOnly{repository}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			response, body := get(t, handler, "/repository/contracts/full_match/1/"+token.Hex()+"/sources/contracts/T.sol")
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+			assert.Equal(t, "contract T {}", string(body))
+			assert.Equal(t, "text/plain; charset=utf-8", response.Header.Get("Content-Type"))
+
+			response, _ = get(t, handler, "/repository/contracts/full_match/1/"+token.Hex()+"/metadata.json")
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+			assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+
+			response, _ = get(t, handler, "/repository/contracts/full_match/1/"+token.Hex()+"/sources/missing.sol")
+			assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+			response, _ = get(t, handler, "/repository/contracts/full_match/1/"+usdt.Hex()+"/metadata.json")
+			assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+			response, _ = get(t, handler, "/repository/contracts/other_match/1/"+token.Hex()+"/metadata.json")
+			assert.Equal(t, http.StatusNotFound, response.StatusCode)
+		})
+	}
+}
+
+func TestHandleContract(t *testing.T) {
+	handler := New(newRepository(t))
+
+	response, body := get(t, handler, "/v2/contract/1/"+token.Hex())
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var minimal map[string]any
+	require.NoError(t, json.Unmarshal(body, &minimal))
+	assert.Equal(t, "exact_match", minimal["match"])
+	assert.Equal(t, "exact_match", minimal["runtimeMatch"])
+	assert.NotContains(t, minimal, "sources")
+
+	response, body = get(t, handler, "/v2/contract/1/"+token.Hex()+"?fields=compilation,sources")
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var selected sourcify.ContractResponse
+	require.NoError(t, json.Unmarshal(body, &selected))
+	assert.Equal(t, "contract T {}", selected.Sources["contracts/T.sol"].Content)
+	assert.Equal(t, "solc", selected.Compilation.Compiler)
+	assert.Equal(t, "contracts/T.sol:T", selected.Compilation.FullyQualifiedName)
+
+	response, _ = get(t, handler, "/v2/contract/1/"+token.Hex()+"?fields=abi&omit=sources")
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, _ = get(t, handler, "/v2/contract/1/"+token.Hex()+"?fields=unknown")
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, body = get(t, handler, "/v2/contract/10/"+token.Hex())
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	var errorResponse sourcify.ErrorResponse
+	require.NoError(t, json.Unmarshal(body, &errorResponse))
+	assert.Equal(t, "not_found", errorResponse.CustomCode)
+}
+
+func TestHandleInvalidParameters(t *testing.T) {
+	handler := New(newRepository(t))
+
+	for _, uri := range []string{
+		"/files/contracts/mainnet",
+		"/files/tree/1/0x1234",
+		"/files/any/one/" + token.Hex(),
+		"/check-by-addresses?addresses=" + token.Hex(),
+		"/check-by-addresses?addresses=0x1234&chainIds=1",
+		"/check-all-by-addresses?addresses=" + token.Hex() + "&chainIds=one",
+	} {
+		response, _ := get(t, handler, uri)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, uri)
+	}
+}
+
+func TestHandleCheckAllByAddresses(t *testing.T) {
+	handler := New(newRepository(t))
+
+	response, body := get(t, handler, "/check-all-by-addresses?addresses="+token.Hex()+","+usdt.Hex()+"&chainIds=1,10")
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var results []sourcify.CheckContractAddressMore
+	require.NoError(t, json.Unmarshal(body, &results))
+	require.Len(t, results, 2)
+	assert.Equal(t, []sourcify.CheckContractAddressMoreInfo{{Status: "perfect", ChainID: "1"}}, results[0].Info)
+	assert.Equal(t, []sourcify.CheckContractAddressMoreInfo{{Status: "partial", ChainID: "1"}}, results[1].Info)
+}
+
+func TestHandleChainsWithoutLister(t *testing.T) {
+	response, body := get(t, New(backendOnly{newRepository(t)}), "/chains")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.JSONEq(t, "[]", string(body))
+}
@@ -0,0 +1,71 @@
+// Package server serves the Sourcify API from a sourcify.Backend, typically a sourcify.LocalRepository holding a
+// mirror of the Sourcify repository, so that tools speaking the Sourcify API can point at a self hosted instance.
+//
+// The repository endpoints are served as by Sourcify: `/files/contracts/...`, `/files/tree/...`, `/files/...`,
+// `/repository/contracts/...`, `/check-by-addresses` and `/check-all-by-addresses`, along with `/health`,
+// `/chains` and `/v2/contract/...`. As a repository holds neither bytecode nor deployment information, contracts
+// served by `/v2/contract` are built from their metadata and sources only.
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/unpackdev/sourcify-go"
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithBaseURL sets the URL the server is reachable at, used for the file URLs of `/files/tree`.
+// By default, the URLs are built from the host of each request.
+func WithBaseURL(baseURL string) Option {
+	return func(s *Server) {
+		s.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithChains sets the chains returned by `/chains`. By default, a supported chain is listed for every chain of
+// the backend when it can list them, see ChainLister.
+func WithChains(chains ...sourcify.Chain) Option {
+	return func(s *Server) {
+		s.chains = chains
+	}
+}
+
+// ChainLister is implemented by backends able to list the chains they hold, such as sourcify.LocalRepository.
+type ChainLister interface {
+	Chains() ([]int, error)
+}
+
+// FileReader is implemented by backends able to read a single contract file, such as sourcify.LocalRepository.
+// Other backends have every file of the contract retrieved to serve one.
+type FileReader interface {
+	ReadFile(chainId int, contract common.Address, matchType sourcify.MethodMatchType, name string) ([]byte, error)
+}
+
+// Server represents a Sourcify compatible HTTP handler over a backend.
+type Server struct {
+	backend sourcify.Backend
+	baseURL string
+	chains  []sourcify.Chain
+	mux     *http.ServeMux
+}
+
+// New creates a server answering from the backend.
+func New(backend sourcify.Backend, options ...Option) *Server {
+	toReturn := &Server{backend: backend}
+	for _, option := range options {
+		option(toReturn)
+	}
+
+	toReturn.mux = http.NewServeMux()
+	toReturn.routes()
+	return toReturn
+}
+
+// ServeHTTP answers a request to the Sourcify API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
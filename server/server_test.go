@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unpackdev/sourcify-go"
+)
+
+var (
+	usdt  = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	token = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+)
+
+// newRepository writes a small repository, USDT as a partial match and a token as a full match on chain 1, and
+// opens it.
+func newRepository(t *testing.T) *sourcify.LocalRepository {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", "1:"+usdt.Hex()+".json"))
+	require.NoError(t, err)
+
+	var contract sourcify.ContractResponse
+	require.NoError(t, json.Unmarshal(data, &contract))
+	metadata, err := json.Marshal(contract.Metadata)
+	require.NoError(t, err)
+
+	files := map[string]string{
+		"contracts/partial_match/1/" + usdt.Hex() + "/metadata.json":           string(metadata),
+		"contracts/partial_match/1/" + usdt.Hex() + "/sources/TetherToken.sol": contract.Sources["TetherToken.sol"].Content,
+		"contracts/full_match/1/" + token.Hex() + "/metadata.json":             `{"language":"Solidity","compiler":{"version":"0.8.19+commit.7dd6d404"},"settings":{"compilationTarget":{"contracts/T.sol":"T"}},"sources":{"contracts/T.sol":{"keccak256":"0x00"}}}`,
+		"contracts/full_match/1/" + token.Hex() + "/sources/contracts/T.sol":   "contract T {}",
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	repository, err := sourcify.OpenLocalRepository(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repository.Close() })
+	return repository
+}
+
+// newClient starts the server over the backend and returns a client using it.
+func newClient(t *testing.T, backend sourcify.Backend, options ...Option) (*sourcify.Client, *httptest.Server) {
+	ts := httptest.NewServer(New(backend, options...))
+	t.Cleanup(ts.Close)
+	return sourcify.NewClient(sourcify.WithBaseURL(ts.URL), sourcify.WithRetryOptions(sourcify.WithMaxRetries(1))), ts
+}
+
+func TestServerWithClient(t *testing.T) {
+	client, ts := newClient(t, newRepository(t))
+
+	healthy, err := sourcify.GetHealth(client)
+	require.NoError(t, err)
+	assert.True(t, healthy)
+
+	chains, err := sourcify.GetChains(client)
+	require.NoError(t, err)
+	require.Len(t, chains, 1)
+	assert.Equal(t, 1, chains[0].ChainID)
+
+	addresses, err := sourcify.GetAvailableContractAddresses(client, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{token}, addresses.Full)
+	assert.Equal(t, []common.Address{usdt}, addresses.Partial)
+
+	tree, err := sourcify.GetContractFiles(client, 1, usdt, sourcify.MethodMatchTypeAny)
+	require.NoError(t, err)
+	assert.Equal(t, "partial", tree.Status)
+	assert.Equal(t, ts.URL+"/repository/contracts/partial_match/1/"+usdt.Hex()+"/metadata.json", tree.Files[0])
+
+	sources, err := sourcify.GetContractSourceCode(client, 1, token, sourcify.MethodMatchTypeFull)
+	require.NoError(t, err)
+	assert.Equal(t, "full", sources.Status)
+	assert.Len(t, sources.Code, 2)
+
+	metadata, err := sourcify.GetContractMetadata(client, 1, usdt, sourcify.MethodMatchTypePartial)
+	require.NoError(t, err)
+	assert.Equal(t, "0.4.18+commit.9cf6e910", metadata.Compiler.Version)
+
+	contract, err := sourcify.GetContractByChainIdAndAddress(client, 1, usdt, []string{"all"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "match", contract.Match)
+	assert.Equal(t, "TetherToken", contract.Compilation.Name)
+	assert.Equal(t, "TetherToken.sol:TetherToken", contract.Compilation.FullyQualifiedName)
+	assert.NotEmpty(t, contract.Abi)
+	assert.Contains(t, contract.Sources["TetherToken.sol"].Content, "contract TetherToken")
+	assert.Contains(t, contract.StdJSONInput.Sources, "TetherToken.sol")
+
+	checks, err := sourcify.CheckContractByAddresses(client, []string{token.Hex(), usdt.Hex()}, []int{1}, sourcify.MethodMatchTypeFull)
+	require.NoError(t, err)
+	require.Len(t, checks, 2)
+	assert.Equal(t, "perfect", checks[0].Status)
+	assert.Equal(t, "false", checks[1].Status)
+
+	checks, err = sourcify.CheckContractByAddresses(client, []string{usdt.Hex()}, []int{1, 10}, sourcify.MethodMatchTypeAny)
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	assert.Equal(t, "partial", checks[0].Status)
+	assert.Equal(t, []string{"1"}, checks[0].ChainIDs)
+}
+
+func TestServerWithBaseURL(t *testing.T) {
+	client, _ := newClient(t, newRepository(t), WithBaseURL("https://sourcify.example.com/"))
+
+	tree, err := sourcify.GetContractFiles(client, 1, token, sourcify.MethodMatchTypeFull)
+	require.NoError(t, err)
+	for _, file := range tree.Files {
+		assert.True(t, strings.HasPrefix(file, "https://sourcify.example.com/repository/contracts/full_match/1/"), file)
+	}
+}
+
+func TestServerWithChains(t *testing.T) {
+	client, _ := newClient(t, newRepository(t), WithChains(sourcify.Chain{Name: "Ethereum Mainnet", ChainID: 1}))
+
+	chains, err := sourcify.GetChains(client)
+	require.NoError(t, err)
+	require.Len(t, chains, 1)
+	assert.Equal(t, "Ethereum Mainnet", chains[0].Name)
+}
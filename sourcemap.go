@@ -0,0 +1,288 @@
+package sourcify
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrSourceMapInvalid is returned when a compressed source map cannot be parsed.
+	ErrSourceMapInvalid = errors.New("invalid source map")
+
+	// ErrProgramCounterNotFound is returned when a program counter does not point at the start of an instruction.
+	ErrProgramCounterNotFound = errors.New("program counter does not match any instruction")
+
+	// ErrSourceNotFound is returned when a source map entry references a source that is not available.
+	ErrSourceNotFound = errors.New("source not found for source map entry")
+)
+
+// JumpType describes how a source map entry relates to a jump instruction.
+type JumpType string
+
+const (
+	// JumpTypeIn denotes a jump into a function.
+	JumpTypeIn JumpType = "i"
+
+	// JumpTypeOut denotes a jump returning from a function.
+	JumpTypeOut JumpType = "o"
+
+	// JumpTypeRegular denotes a regular jump (e.g. a loop) or a non-jump instruction.
+	JumpTypeRegular JumpType = "-"
+)
+
+// SourceMapEntry represents a single decompressed entry of a Solidity source map.
+// Each entry corresponds to one instruction of the bytecode the source map was produced for.
+type SourceMapEntry struct {
+	Start         int      // Byte offset of the range start in the source file.
+	Length        int      // Length of the source range in bytes.
+	File          int      // Source index as found in SourceIds, -1 when the instruction has no source.
+	Jump          JumpType // Jump type of the instruction.
+	ModifierDepth int      // Modifier depth of the instruction.
+}
+
+// HasSource reports whether the entry points at a source file.
+func (e SourceMapEntry) HasSource() bool {
+	return e.File >= 0
+}
+
+// SourceMap represents a decompressed Solidity source map, indexed by instruction.
+type SourceMap []SourceMapEntry
+
+// ParseSourceMap parses a compressed Solidity source map in the `s:l:f:j:m;...` format.
+// Empty fields inherit the value of the previous entry as described in the Solidity documentation:
+// https://docs.soliditylang.org/en/latest/internals/source_mappings.html
+func ParseSourceMap(sourceMap string) (SourceMap, error) {
+	if sourceMap == "" {
+		return SourceMap{}, nil
+	}
+
+	entries := strings.Split(sourceMap, ";")
+	toReturn := make(SourceMap, 0, len(entries))
+
+	current := SourceMapEntry{File: -1, Jump: JumpTypeRegular}
+	for i, raw := range entries {
+		fields := strings.Split(raw, ":")
+		if len(fields) > 5 {
+			return nil, fmt.Errorf("%w: entry %d has %d fields", ErrSourceMapInvalid, i, len(fields))
+		}
+
+		for idx, field := range fields {
+			if field == "" {
+				continue
+			}
+
+			switch idx {
+			case 0, 1, 2, 4:
+				value, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, fmt.Errorf("%w: entry %d: %s", ErrSourceMapInvalid, i, err)
+				}
+
+				switch idx {
+				case 0:
+					current.Start = value
+				case 1:
+					current.Length = value
+				case 2:
+					current.File = value
+				case 4:
+					current.ModifierDepth = value
+				}
+			case 3:
+				switch JumpType(field) {
+				case JumpTypeIn, JumpTypeOut, JumpTypeRegular:
+					current.Jump = JumpType(field)
+				default:
+					return nil, fmt.Errorf("%w: entry %d has unknown jump type %q", ErrSourceMapInvalid, i, field)
+				}
+			}
+		}
+
+		toReturn = append(toReturn, current)
+	}
+
+	return toReturn, nil
+}
+
+// InstructionOffsets returns the program counter of every instruction in the given bytecode.
+// The n-th element of the returned slice is the program counter of the n-th instruction, which
+// is how source map entries are indexed. PUSH immediates are skipped.
+func InstructionOffsets(bytecode []byte) []int {
+	toReturn := make([]int, 0, len(bytecode))
+	for pc := 0; pc < len(bytecode); pc++ {
+		toReturn = append(toReturn, pc)
+
+		// PUSH1 to PUSH32 carry 1 to 32 bytes of immediate data.
+		pc += OpCode(bytecode[pc]).PushSize()
+	}
+	return toReturn
+}
+
+// SourceLocation represents a resolved position in a verified source file.
+// Lines and columns are 1-based, columns are counted in bytes.
+type SourceLocation struct {
+	Path          string         // Path of the source file as found in Sources.
+	Start         int            // Byte offset of the range start.
+	Length        int            // Length of the range in bytes.
+	Line          int            // Line of the range start.
+	Column        int            // Column of the range start.
+	EndLine       int            // Line of the range end.
+	EndColumn     int            // Column of the range end.
+	Jump          JumpType       // Jump type of the instruction.
+	ModifierDepth int            // Modifier depth of the instruction.
+	Entry         SourceMapEntry // Raw source map entry the location was resolved from.
+}
+
+// Snippet returns the source code covered by the location, or an empty string when the source is unavailable.
+func (l SourceLocation) Snippet(sources Sources) string {
+	source, ok := sources[l.Path]
+	if !ok || l.Start < 0 || l.Start+l.Length > len(source.Content) {
+		return ""
+	}
+	return source.Content[l.Start : l.Start+l.Length]
+}
+
+// SourceResolver resolves program counters to locations in verified source files.
+// It is safe for concurrent use once created.
+type SourceResolver struct {
+	sourceMap   SourceMap
+	pcToIndex   map[int]int
+	paths       map[int]string
+	sources     Sources
+	lineOffsets map[string][]int
+}
+
+// NewSourceResolver creates a SourceResolver for the given hex encoded bytecode and its compressed source map.
+// The sourceIds are used to map source indexes to file paths in sources.
+func NewSourceResolver(bytecode string, sourceMap string, sourceIds SourceIds, sources Sources) (*SourceResolver, error) {
+	entries, err := ParseSourceMap(sourceMap)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := InstructionOffsets(common.FromHex(bytecode))
+	pcToIndex := make(map[int]int, len(offsets))
+	for idx, pc := range offsets {
+		pcToIndex[pc] = idx
+	}
+
+	paths := make(map[int]string, len(sourceIds))
+	for path, ref := range sourceIds {
+		paths[ref.ID] = path
+	}
+
+	lineOffsets := make(map[string][]int, len(sources))
+	for path, source := range sources {
+		lineOffsets[path] = lineStarts(source.Content)
+	}
+
+	return &SourceResolver{
+		sourceMap:   entries,
+		pcToIndex:   pcToIndex,
+		paths:       paths,
+		sources:     sources,
+		lineOffsets: lineOffsets,
+	}, nil
+}
+
+// NewRuntimeSourceResolver creates a SourceResolver for the runtime (deployed) bytecode of the contract.
+func (c *ContractResponse) NewRuntimeSourceResolver() (*SourceResolver, error) {
+	return NewSourceResolver(c.RuntimeBytecode.code(), c.RuntimeBytecode.SourceMap, c.SourceIds, c.Sources)
+}
+
+// NewCreationSourceResolver creates a SourceResolver for the creation bytecode of the contract.
+func (c *ContractResponse) NewCreationSourceResolver() (*SourceResolver, error) {
+	return NewSourceResolver(c.CreationBytecode.code(), c.CreationBytecode.SourceMap, c.SourceIds, c.Sources)
+}
+
+// code returns the bytecode the source map was produced for, preferring the recompiled bytecode.
+func (b Bytecode) code() string {
+	if b.RecompiledBytecode != "" {
+		return b.RecompiledBytecode
+	}
+	return b.OnchainBytecode
+}
+
+// SourceMap returns the decompressed source map used by the resolver.
+func (r *SourceResolver) SourceMap() SourceMap {
+	return r.sourceMap
+}
+
+// Entry returns the source map entry for the instruction at the given program counter.
+func (r *SourceResolver) Entry(pc int) (SourceMapEntry, error) {
+	idx, ok := r.pcToIndex[pc]
+	if !ok || idx >= len(r.sourceMap) {
+		return SourceMapEntry{}, fmt.Errorf("%w: %d", ErrProgramCounterNotFound, pc)
+	}
+	return r.sourceMap[idx], nil
+}
+
+// Resolve returns the source location of the instruction at the given program counter.
+// It returns ErrSourceNotFound when the instruction has no source or references a source
+// that is not part of the verified sources, such as compiler generated sources.
+func (r *SourceResolver) Resolve(pc int) (*SourceLocation, error) {
+	entry, err := r.Entry(pc)
+	if err != nil {
+		return nil, err
+	}
+
+	if !entry.HasSource() {
+		return nil, fmt.Errorf("%w: pc %d has no source", ErrSourceNotFound, pc)
+	}
+
+	path, ok := r.paths[entry.File]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown source index %d", ErrSourceNotFound, entry.File)
+	}
+
+	source, ok := r.sources[path]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing source %s", ErrSourceNotFound, path)
+	}
+
+	end := entry.Start + entry.Length
+	if entry.Start < 0 || end > len(source.Content) {
+		return nil, fmt.Errorf("%w: range %d:%d out of bounds for %s", ErrSourceNotFound, entry.Start, entry.Length, path)
+	}
+
+	offsets := r.lineOffsets[path]
+	line, column := position(offsets, entry.Start)
+	endLine, endColumn := position(offsets, end)
+
+	return &SourceLocation{
+		Path:          path,
+		Start:         entry.Start,
+		Length:        entry.Length,
+		Line:          line,
+		Column:        column,
+		EndLine:       endLine,
+		EndColumn:     endColumn,
+		Jump:          entry.Jump,
+		ModifierDepth: entry.ModifierDepth,
+		Entry:         entry,
+	}, nil
+}
+
+// lineStarts returns the byte offsets at which each line of the content starts.
+func lineStarts(content string) []int {
+	offsets := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// position converts a byte offset into a 1-based line and column using the line start offsets.
+func position(lineOffsets []int, offset int) (int, int) {
+	line := sort.Search(len(lineOffsets), func(i int) bool {
+		return lineOffsets[i] > offset
+	}) - 1
+	return line + 1, offset - lineOffsets[line] + 1
+}
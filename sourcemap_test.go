@@ -0,0 +1,143 @@
+package sourcify
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSourceMap(t *testing.T) {
+	tests := []struct {
+		name      string
+		sourceMap string
+		want      SourceMap
+		wantErr   bool
+	}{
+		{
+			name:      "Empty",
+			sourceMap: "",
+			want:      SourceMap{},
+		},
+		{
+			name:      "Compressed",
+			sourceMap: "1:2:1;1:9:1;2:1:2;2:1:2;2:1:2",
+			want: SourceMap{
+				{Start: 1, Length: 2, File: 1, Jump: JumpTypeRegular},
+				{Start: 1, Length: 9, File: 1, Jump: JumpTypeRegular},
+				{Start: 2, Length: 1, File: 2, Jump: JumpTypeRegular},
+				{Start: 2, Length: 1, File: 2, Jump: JumpTypeRegular},
+				{Start: 2, Length: 1, File: 2, Jump: JumpTypeRegular},
+			},
+		},
+		{
+			name:      "Inherited fields",
+			sourceMap: "1:2:1:i:3;:9;2::2;;:::o",
+			want: SourceMap{
+				{Start: 1, Length: 2, File: 1, Jump: JumpTypeIn, ModifierDepth: 3},
+				{Start: 1, Length: 9, File: 1, Jump: JumpTypeIn, ModifierDepth: 3},
+				{Start: 2, Length: 9, File: 2, Jump: JumpTypeIn, ModifierDepth: 3},
+				{Start: 2, Length: 9, File: 2, Jump: JumpTypeIn, ModifierDepth: 3},
+				{Start: 2, Length: 9, File: 2, Jump: JumpTypeOut, ModifierDepth: 3},
+			},
+		},
+		{
+			name:      "No source",
+			sourceMap: "-1:-1:-1:-",
+			want: SourceMap{
+				{Start: -1, Length: -1, File: -1, Jump: JumpTypeRegular},
+			},
+		},
+		{
+			name:      "Invalid number",
+			sourceMap: "1:x:0",
+			wantErr:   true,
+		},
+		{
+			name:      "Invalid jump",
+			sourceMap: "1:2:0:x",
+			wantErr:   true,
+		},
+		{
+			name:      "Too many fields",
+			sourceMap: "1:2:0:-:0:1",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSourceMap(tt.sourceMap)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrSourceMapInvalid)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInstructionOffsets(t *testing.T) {
+	// PUSH1 0x80, PUSH1 0x40, MSTORE, PUSH0, PUSH32 <32 bytes>, STOP
+	code := append([]byte{0x60, 0x80, 0x60, 0x40, 0x52, 0x5f, 0x7f}, make([]byte, 32)...)
+	code = append(code, 0x00)
+
+	assert.Equal(t, []int{0, 2, 4, 5, 6, 39}, InstructionOffsets(code))
+}
+
+func TestSourceResolver_Resolve(t *testing.T) {
+	contract, err := LoadContract(1, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	require.NoError(t, err)
+
+	resolver, err := contract.NewRuntimeSourceResolver()
+	require.NoError(t, err)
+
+	// The very first instruction maps to the whole TetherToken contract definition.
+	location, err := resolver.Resolve(0)
+	require.NoError(t, err)
+	assert.Equal(t, "TetherToken.sol", location.Path)
+	assert.Equal(t, 9971, location.Start)
+	assert.Equal(t, 4917, location.Length)
+	assert.Equal(t, 311, location.Line)
+	assert.Equal(t, 1, location.Column)
+	assert.Contains(t, location.Snippet(contract.Sources), "contract TetherToken is Pausable")
+
+	// Find the first instruction that maps to the `name` state variable declaration.
+	offsets := InstructionOffsets(common.FromHex(contract.RuntimeBytecode.RecompiledBytecode))
+	pc := -1
+	for idx, entry := range resolver.SourceMap() {
+		if entry.Start == 10039 && entry.Length == 18 {
+			pc = offsets[idx]
+			break
+		}
+	}
+	require.NotEqual(t, -1, pc)
+
+	location, err = resolver.Resolve(pc)
+	require.NoError(t, err)
+	assert.Equal(t, 313, location.Line)
+	assert.Equal(t, 5, location.Column)
+	assert.Equal(t, "string public name", location.Snippet(contract.Sources))
+
+	// Program counter pointing into PUSH immediate data.
+	_, err = resolver.Resolve(1)
+	assert.ErrorIs(t, err, ErrProgramCounterNotFound)
+}
+
+func TestSourceResolver_MissingSource(t *testing.T) {
+	resolver, err := NewSourceResolver("0x600060006000", "0:1:0;-1:-1:-1;0:1:3", SourceIds{"a.sol": {ID: 0}}, Sources{"a.sol": {Content: "a\nb"}})
+	require.NoError(t, err)
+
+	location, err := resolver.Resolve(0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, location.Line)
+
+	_, err = resolver.Resolve(2)
+	assert.ErrorIs(t, err, ErrSourceNotFound)
+
+	_, err = resolver.Resolve(4)
+	assert.ErrorIs(t, err, ErrSourceNotFound)
+}
@@ -0,0 +1,48 @@
+package sourcifymock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unpackdev/sourcify-go"
+)
+
+// contractName is an example consumer depending on the API interface rather than the client.
+func contractName(api sourcify.API, chainId int, address common.Address) (string, error) {
+	contract, err := api.GetContractByChainIdAndAddress(chainId, address, []string{"compilation"}, nil)
+	if err != nil {
+		return "", err
+	}
+	return contract.Compilation.Name, nil
+}
+
+func TestAPIMock(t *testing.T) {
+	address := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+
+	mock := &APIMock{
+		GetContractByChainIdAndAddressFunc: func(chainId int, address common.Address, fields []string, omit []string) (*sourcify.ContractResponse, error) {
+			if chainId != 1 {
+				return nil, errors.New("not found")
+			}
+			return &sourcify.ContractResponse{Compilation: sourcify.Compilation{Name: "TetherToken"}}, nil
+		},
+	}
+
+	name, err := contractName(mock, 1, address)
+	require.NoError(t, err)
+	assert.Equal(t, "TetherToken", name)
+
+	_, err = contractName(mock, 5, address)
+	assert.Error(t, err)
+
+	calls := mock.GetContractByChainIdAndAddressCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, address, calls[0].Address)
+	assert.Equal(t, []string{"compilation"}, calls[0].Fields)
+	assert.Equal(t, 5, calls[1].ChainId)
+
+	assert.Panics(t, func() { _, _ = mock.GetHealth() })
+}
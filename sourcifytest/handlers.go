@@ -0,0 +1,390 @@
+package sourcifytest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/unpackdev/sourcify-go"
+)
+
+const (
+	// defaultPageLimit is the number of contracts listed by `/v2/contracts` when no limit is given.
+	defaultPageLimit = 200
+
+	// maxPageLimit is the highest limit accepted by `/v2/contracts`.
+	maxPageLimit = 200
+)
+
+// handler routes the endpoints of the fake server.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /chains", s.handleChains)
+	mux.HandleFunc("GET /v2/contract/{chain}/{address}", s.handleContract)
+	mux.HandleFunc("GET /v2/contracts/{chain}", s.handleContracts)
+	mux.HandleFunc("GET /files/contracts/{chain}", s.handleAddresses)
+	mux.HandleFunc("GET /files/tree/any/{chain}/{address}", s.handleFileTree(sourcify.MethodMatchTypeAny))
+	mux.HandleFunc("GET /files/tree/{chain}/{address}", s.handleFileTree(sourcify.MethodMatchTypeFull))
+	mux.HandleFunc("GET /files/any/{chain}/{address}", s.handleSourceFiles(sourcify.MethodMatchTypeAny))
+	mux.HandleFunc("GET /files/{chain}/{address}", s.handleSourceFiles(sourcify.MethodMatchTypeFull))
+	mux.HandleFunc("GET /repository/contracts/{match}/{chain}/{address}/{path...}", s.handleRepositoryFile)
+	mux.HandleFunc("GET /check-by-addresses", s.handleCheckByAddresses(sourcify.MethodMatchTypeFull))
+	mux.HandleFunc("GET /check-all-by-addresses", s.handleCheckByAddresses(sourcify.MethodMatchTypeAny))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.intercept(w, r) {
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("Alive and kicking!"))
+}
+
+func (s *Server) handleChains(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.chainList())
+}
+
+func (s *Server) handleContract(w http.ResponseWriter, r *http.Request) {
+	contract, ok := s.lookup(w, r, sourcify.MethodMatchTypeAny)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	toReturn, err := sourcify.FilterContractFields(contract, splitList(query.Get("fields")), splitList(query.Get("omit")))
+	switch {
+	case errors.Is(err, sourcify.ErrFieldsAndOmit), errors.Is(err, sourcify.ErrUnknownContractField):
+		writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	default:
+		writeJSON(w, http.StatusOK, toReturn)
+	}
+}
+
+func (s *Server) handleContracts(w http.ResponseWriter, r *http.Request) {
+	chainId, err := strconv.Atoi(r.PathValue("chain"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid chain id: %s", r.PathValue("chain")))
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit := defaultPageLimit
+	if raw := query.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("limit must be between 1 and %d", maxPageLimit))
+			return
+		}
+	}
+
+	descending := true
+	switch query.Get("sort") {
+	case "", "desc":
+	case "asc":
+		descending = false
+	default:
+		writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid sort: %s", query.Get("sort")))
+		return
+	}
+
+	var after int64
+	if raw := query.Get("afterMatchId"); raw != "" {
+		after, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid afterMatchId: %s", raw))
+			return
+		}
+	}
+
+	contracts := s.sortedContracts(chainId)
+	if descending {
+		for i, j := 0, len(contracts)-1; i < j; i, j = i+1, j-1 {
+			contracts[i], contracts[j] = contracts[j], contracts[i]
+		}
+	}
+
+	toReturn := sourcify.ContractsResponse{Results: []sourcify.ContractBaseResponse{}}
+	for _, contract := range contracts {
+		matchId, _ := strconv.ParseInt(contract.MatchID, 10, 64)
+		if after != 0 && ((descending && matchId >= after) || (!descending && matchId <= after)) {
+			continue
+		}
+		if len(toReturn.Results) == limit {
+			break
+		}
+		toReturn.Results = append(toReturn.Results, sourcify.ContractBaseResponse{
+			Address:       contract.Address,
+			ChainID:       contract.ChainID,
+			CreationMatch: contract.CreationMatch,
+			Match:         contract.Match,
+			MatchID:       contract.MatchID,
+			RuntimeMatch:  contract.RuntimeMatch,
+			VerifiedAt:    contract.VerifiedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, toReturn)
+}
+
+func (s *Server) handleAddresses(w http.ResponseWriter, r *http.Request) {
+	chainId, err := strconv.Atoi(r.PathValue("chain"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid chain id: %s", r.PathValue("chain")))
+		return
+	}
+
+	toReturn := sourcify.VerifiedContractAddresses{Full: []common.Address{}, Partial: []common.Address{}}
+	for _, contract := range s.sortedContracts(chainId) {
+		if isFullMatch(contract) {
+			toReturn.Full = append(toReturn.Full, common.HexToAddress(contract.Address))
+		} else {
+			toReturn.Partial = append(toReturn.Partial, common.HexToAddress(contract.Address))
+		}
+	}
+	writeJSON(w, http.StatusOK, toReturn)
+}
+
+func (s *Server) handleFileTree(matchType sourcify.MethodMatchType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contract, ok := s.lookup(w, r, matchType)
+		if !ok {
+			return
+		}
+
+		base := fmt.Sprintf("http://%s%s", r.Host, repositoryPath(contract))
+		toReturn := sourcify.FileTree{Status: matchStatus(contract), Files: []string{}}
+		for _, name := range repositoryFiles(contract) {
+			toReturn.Files = append(toReturn.Files, base+name)
+		}
+		writeJSON(w, http.StatusOK, toReturn)
+	}
+}
+
+func (s *Server) handleSourceFiles(matchType sourcify.MethodMatchType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contract, ok := s.lookup(w, r, matchType)
+		if !ok {
+			return
+		}
+
+		toReturn := sourcify.SourceCodes{Status: matchStatus(contract), Code: []sourcify.SourceCode{}}
+		for _, name := range repositoryFiles(contract) {
+			content, _ := repositoryFile(contract, name)
+			toReturn.Code = append(toReturn.Code, sourcify.SourceCode{
+				Name:    path.Base(name),
+				Path:    repositoryPath(contract) + name,
+				Content: string(content),
+			})
+		}
+		writeJSON(w, http.StatusOK, toReturn)
+	}
+}
+
+func (s *Server) handleRepositoryFile(w http.ResponseWriter, r *http.Request) {
+	var matchType sourcify.MethodMatchType
+	switch r.PathValue("match") {
+	case "full_match":
+		matchType = sourcify.MethodMatchTypeFull
+	case "partial_match":
+		matchType = sourcify.MethodMatchTypePartial
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	contract, ok := s.lookup(w, r, matchType)
+	if !ok {
+		return
+	}
+
+	content, ok := repositoryFile(contract, r.PathValue("path"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("file not found: %s", r.PathValue("path")))
+		return
+	}
+
+	if strings.HasSuffix(r.PathValue("path"), ".json") {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	_, _ = w.Write(content)
+}
+
+func (s *Server) handleCheckByAddresses(matchType sourcify.MethodMatchType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		addresses, chains := splitList(query.Get("addresses")), splitList(query.Get("chainIds"))
+		if len(addresses) == 0 || len(chains) == 0 {
+			writeError(w, http.StatusBadRequest, "invalid_parameter", "addresses and chainIds are required")
+			return
+		}
+
+		chainIds := make([]int, 0, len(chains))
+		for _, chain := range chains {
+			chainId, err := strconv.Atoi(chain)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid chain id: %s", chain))
+				return
+			}
+			chainIds = append(chainIds, chainId)
+		}
+
+		toReturn := make([]map[string]any, 0, len(addresses))
+		for _, raw := range addresses {
+			if !common.IsHexAddress(raw) {
+				writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid address: %s", raw))
+				return
+			}
+			address := common.HexToAddress(raw)
+
+			var full []string
+			var all []map[string]string
+			for _, chainId := range chainIds {
+				contract, ok := s.Contract(chainId, address)
+				if !ok {
+					continue
+				}
+				status := "partial"
+				if isFullMatch(contract) {
+					status = "perfect"
+					full = append(full, strconv.Itoa(chainId))
+				}
+				all = append(all, map[string]string{"chainId": strconv.Itoa(chainId), "status": status})
+			}
+
+			switch {
+			case matchType == sourcify.MethodMatchTypeFull && len(full) > 0:
+				toReturn = append(toReturn, map[string]any{"address": address.Hex(), "status": "perfect", "chainIds": full})
+			case matchType != sourcify.MethodMatchTypeFull && len(all) > 0:
+				toReturn = append(toReturn, map[string]any{"address": address.Hex(), "chainIds": all})
+			default:
+				toReturn = append(toReturn, map[string]any{"address": address.Hex(), "status": "false"})
+			}
+		}
+		writeJSON(w, http.StatusOK, toReturn)
+	}
+}
+
+// lookup resolves the contract addressed by the chain and address path values, answering with an error when it
+// is invalid, unknown or does not have the requested match type.
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request, matchType sourcify.MethodMatchType) (*sourcify.ContractResponse, bool) {
+	chainId, err := strconv.Atoi(r.PathValue("chain"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid chain id: %s", r.PathValue("chain")))
+		return nil, false
+	}
+
+	if !common.IsHexAddress(r.PathValue("address")) {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid address: %s", r.PathValue("address")))
+		return nil, false
+	}
+
+	contract, ok := s.Contract(chainId, common.HexToAddress(r.PathValue("address")))
+	if ok {
+		switch matchType {
+		case sourcify.MethodMatchTypeFull:
+			ok = isFullMatch(contract)
+		case sourcify.MethodMatchTypePartial:
+			ok = !isFullMatch(contract)
+		}
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("contract %s on chain %d not found", r.PathValue("address"), chainId))
+		return nil, false
+	}
+	return contract, true
+}
+
+// isFullMatch reports whether the contract is an exact (full) match.
+func isFullMatch(contract *sourcify.ContractResponse) bool {
+	return contract.Match == "exact_match" || contract.Match == "perfect"
+}
+
+// matchStatus returns the status of the contract as reported by the `/files` endpoints.
+func matchStatus(contract *sourcify.ContractResponse) string {
+	if isFullMatch(contract) {
+		return "full"
+	}
+	return "partial"
+}
+
+// repositoryPath returns the repository path of the contract, ending with a slash.
+func repositoryPath(contract *sourcify.ContractResponse) string {
+	match := "partial_match"
+	if isFullMatch(contract) {
+		match = "full_match"
+	}
+	return fmt.Sprintf("/repository/contracts/%s/%s/%s/", match, contract.ChainID, common.HexToAddress(contract.Address).Hex())
+}
+
+// repositoryFiles returns the repository file names of the contract: the metadata followed by the sorted sources.
+func repositoryFiles(contract *sourcify.ContractResponse) []string {
+	toReturn := []string{"metadata.json"}
+
+	names := make([]string, 0, len(contract.Sources))
+	for name := range contract.Sources {
+		if sanitized, err := sourcify.SanitizeSourcePath(name); err == nil {
+			names = append(names, "sources/"+sanitized)
+		}
+	}
+	sort.Strings(names)
+
+	return append(toReturn, names...)
+}
+
+// repositoryFile returns the content of a repository file of the contract.
+func repositoryFile(contract *sourcify.ContractResponse, name string) ([]byte, bool) {
+	if name == "metadata.json" {
+		data, err := json.Marshal(contract.Metadata)
+		return data, err == nil
+	}
+
+	for source, content := range contract.Sources {
+		if sanitized, err := sourcify.SanitizeSourcePath(source); err == nil && "sources/"+sanitized == name {
+			return []byte(content.Content), true
+		}
+	}
+	return nil, false
+}
+
+// splitList splits a comma separated query value, dropping empty elements.
+func splitList(value string) []string {
+	toReturn := []string{}
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			toReturn = append(toReturn, element)
+		}
+	}
+	return toReturn
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response in the format used by Sourcify.
+func writeError(w http.ResponseWriter, statusCode int, customCode string, message string) {
+	writeJSON(w, statusCode, sourcify.ErrorResponse{
+		ErrorId:    uuid.New(),
+		CustomCode: customCode,
+		Message:    message,
+	})
+}
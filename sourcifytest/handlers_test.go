@@ -0,0 +1,155 @@
+package sourcifytest
+
+import (
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unpackdev/sourcify-go"
+)
+
+// newPagedServer serves the USDT fixture along with copies of it under other addresses and match IDs.
+func newPagedServer(t *testing.T) *Server {
+	server := NewServer(t, WithFixtures("../testdata"))
+
+	fixture, _ := server.Contract(1, usdt)
+	for i, matchId := range []string{"10", "20", "30"} {
+		contract := *fixture
+		contract.Address = common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+		contract.MatchID = matchId
+		if i == 0 {
+			contract.Match = "exact_match"
+		}
+		server.AddContract(&contract)
+	}
+	return server
+}
+
+func TestHandleContract(t *testing.T) {
+	server := NewServer(t, WithFixtures("../testdata"))
+	client := server.Client()
+	fixture, _ := server.Contract(1, usdt)
+
+	contract, err := sourcify.GetContractByChainIdAndAddress(client, 1, usdt, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, fixture, contract)
+
+	contract, err = sourcify.GetContractByChainIdAndAddress(client, 1, usdt, []string{"abi", "compilation.name"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, fixture.MatchID, contract.MatchID)
+	assert.Equal(t, fixture.Abi, contract.Abi)
+	assert.Equal(t, "TetherToken", contract.Compilation.Name)
+	assert.Empty(t, contract.Compilation.CompilerVersion)
+	assert.Empty(t, contract.Sources)
+
+	contract, err = sourcify.GetContractByChainIdAndAddress(client, 1, usdt, nil, []string{"sources", "compilation.name"})
+	require.NoError(t, err)
+	assert.Empty(t, contract.Sources)
+	assert.Empty(t, contract.Compilation.Name)
+	assert.Equal(t, fixture.Compilation.CompilerVersion, contract.Compilation.CompilerVersion)
+
+	_, err = sourcify.GetContractByChainIdAndAddress(client, 1, usdt, []string{"unknown"}, nil)
+	assert.ErrorContains(t, err, "invalid_parameter")
+
+	_, err = sourcify.GetContractByChainIdAndAddress(client, 1, common.HexToAddress("0x01"), nil, nil)
+	assert.ErrorContains(t, err, "not_found")
+}
+
+func TestHandleContracts(t *testing.T) {
+	client := newPagedServer(t).Client()
+
+	page, err := sourcify.GetContractsByChainId(client, 1, "", "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Results, 2)
+	assert.Equal(t, "1817159", page.Results[0].MatchID)
+	assert.Equal(t, "30", page.Results[1].MatchID)
+
+	page, err = sourcify.GetContractsByChainId(client, 1, "desc", page.Results[1].MatchID, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Results, 2)
+	assert.Equal(t, "20", page.Results[0].MatchID)
+	assert.Equal(t, "10", page.Results[1].MatchID)
+
+	page, err = sourcify.GetContractsByChainId(client, 1, "asc", "20", 10)
+	require.NoError(t, err)
+	require.Len(t, page.Results, 2)
+	assert.Equal(t, "30", page.Results[0].MatchID)
+
+	_, err = sourcify.GetContractsByChainId(client, 1, "", "", 500)
+	assert.ErrorContains(t, err, "invalid_parameter")
+}
+
+func TestHandleAddressesAndCheck(t *testing.T) {
+	server := newPagedServer(t)
+	client := server.Client()
+
+	addresses, err := sourcify.GetAvailableContractAddresses(client, 1)
+	require.NoError(t, err)
+	assert.Len(t, addresses.Full, 1)
+	assert.Len(t, addresses.Partial, 3)
+
+	full := addresses.Full[0].Hex()
+	missing := common.HexToAddress("0xdead").Hex()
+
+	checks, err := sourcify.CheckContractByAddresses(client, []string{full, usdt.Hex(), missing}, []int{1}, sourcify.MethodMatchTypeFull)
+	require.NoError(t, err)
+	require.Len(t, checks, 3)
+	assert.Equal(t, "perfect", checks[0].Status)
+	assert.Equal(t, "false", checks[1].Status)
+	assert.Equal(t, "false", checks[2].Status)
+
+	checks, err = sourcify.CheckContractByAddresses(client, []string{full, usdt.Hex()}, []int{1, 5}, sourcify.MethodMatchTypeAny)
+	require.NoError(t, err)
+	require.Len(t, checks, 2)
+	assert.Equal(t, "perfect", checks[0].Status)
+	assert.Equal(t, "partial", checks[1].Status)
+	assert.Equal(t, []string{"1"}, checks[1].ChainIDs)
+
+	_, err = sourcify.CheckContractByAddresses(client, []string{"nope"}, []int{1}, sourcify.MethodMatchTypeAny)
+	assert.Error(t, err)
+}
+
+func TestHandleFiles(t *testing.T) {
+	server := NewServer(t, WithFixtures("../testdata"))
+	client := server.Client()
+	fixture, _ := server.Contract(1, usdt)
+
+	tree, err := sourcify.GetContractFiles(client, 1, usdt, sourcify.MethodMatchTypeAny)
+	require.NoError(t, err)
+	assert.Equal(t, "partial", tree.Status)
+	require.Len(t, tree.Files, 2)
+	assert.True(t, strings.HasSuffix(tree.Files[1], "/partial_match/1/"+usdt.Hex()+"/sources/TetherToken.sol"))
+
+	response, err := http.Get(tree.Files[1])
+	require.NoError(t, err)
+	defer response.Body.Close()
+	content, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, fixture.Sources["TetherToken.sol"].Content, string(content))
+
+	_, err = sourcify.GetContractFiles(client, 1, usdt, sourcify.MethodMatchTypeFull)
+	assert.ErrorContains(t, err, "404")
+
+	sources, err := sourcify.GetContractSourceCode(client, 1, usdt, sourcify.MethodMatchTypeAny)
+	require.NoError(t, err)
+	require.Len(t, sources.Code, 2)
+	assert.Equal(t, "metadata.json", sources.Code[0].Name)
+	assert.Equal(t, "TetherToken.sol", sources.Code[1].Name)
+
+	metadata, err := sourcify.GetContractMetadata(client, 1, usdt, sourcify.MethodMatchTypePartial)
+	require.NoError(t, err)
+	assert.Equal(t, fixture.Metadata, *metadata)
+
+	raw, err := sourcify.GetContractMetadataAsBytes(client, 1, usdt, sourcify.MethodMatchTypePartial)
+	require.NoError(t, err)
+	assert.True(t, json.Valid(raw))
+
+	_, err = sourcify.GetContractMetadata(client, 1, usdt, sourcify.MethodMatchTypeFull)
+	assert.ErrorContains(t, err, "not_found")
+}
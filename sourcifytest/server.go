@@ -0,0 +1,268 @@
+// Package sourcifytest provides an in-process fake Sourcify server for tests.
+//
+// The server is backed by contract fixtures in the format of the repository testdata, i.e. files named
+// `chainId:address.json` holding a full `/v2/contract` response, and serves the endpoints used by the client:
+// `/v2/contract`, `/v2/contracts`, `/files/...`, `/repository/...`, `/check-by-addresses`,
+// `/check-all-by-addresses`, `/chains` and `/health`. Latency and failures can be injected to exercise retries
+// and timeouts.
+package sourcifytest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/unpackdev/sourcify-go"
+)
+
+// FailureFunc decides whether a request fails. It returns the status code to respond with, or 0 to serve the
+// request normally.
+type FailureFunc func(r *http.Request) int
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithFixtures loads every `chainId:address.json` contract fixture found in dir.
+func WithFixtures(dir string) Option {
+	return func(s *Server) {
+		s.fixtureDirs = append(s.fixtureDirs, dir)
+	}
+}
+
+// WithContracts serves the given contracts in addition to the fixtures.
+func WithContracts(contracts ...*sourcify.ContractResponse) Option {
+	return func(s *Server) {
+		for _, contract := range contracts {
+			s.AddContract(contract)
+		}
+	}
+}
+
+// WithChains sets the chains returned by `/chains`. By default, a supported chain is listed for every chain
+// that has at least one contract.
+func WithChains(chains ...sourcify.Chain) Option {
+	return func(s *Server) {
+		s.chains = chains
+	}
+}
+
+// WithLatency delays every response by the given duration.
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) {
+		s.latency = latency
+	}
+}
+
+// WithFailures injects failures decided by fn for every request.
+func WithFailures(fn FailureFunc) Option {
+	return func(s *Server) {
+		s.failures = fn
+	}
+}
+
+// contractKey identifies a contract by chain and address.
+type contractKey struct {
+	chainId int
+	address common.Address
+}
+
+// Server represents a fake Sourcify server listening on a local address.
+type Server struct {
+	*httptest.Server
+
+	fixtureDirs []string
+	chains      []sourcify.Chain
+	latency     time.Duration
+	failures    FailureFunc
+
+	mu        sync.RWMutex
+	contracts map[contractKey]*sourcify.ContractResponse
+	failNext  []int
+	requests  []string
+}
+
+// NewServer starts a fake Sourcify server configured with the given options. Fixture errors fail the test,
+// and the server is closed when the test completes.
+func NewServer(tb testing.TB, options ...Option) *Server {
+	tb.Helper()
+
+	s := &Server{
+		contracts: make(map[contractKey]*sourcify.ContractResponse),
+	}
+	for _, option := range options {
+		option(s)
+	}
+
+	for _, dir := range s.fixtureDirs {
+		if err := s.loadFixtures(dir); err != nil {
+			tb.Fatalf("sourcifytest: %v", err)
+		}
+	}
+
+	s.Server = httptest.NewServer(s.handler())
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a Sourcify client pointed at the server. The options are applied after the base URL.
+func (s *Server) Client(options ...sourcify.ClientOption) *sourcify.Client {
+	return sourcify.NewClient(append([]sourcify.ClientOption{sourcify.WithBaseURL(s.URL)}, options...)...)
+}
+
+// AddContract serves the contract, replacing any contract with the same chain and address.
+func (s *Server) AddContract(contract *sourcify.ContractResponse) {
+	chainId, _ := strconv.Atoi(contract.ChainID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.contracts == nil {
+		s.contracts = make(map[contractKey]*sourcify.ContractResponse)
+	}
+	s.contracts[contractKey{chainId: chainId, address: common.HexToAddress(contract.Address)}] = contract
+}
+
+// Contract returns the contract served for the chain and address.
+func (s *Server) Contract(chainId int, address common.Address) (*sourcify.ContractResponse, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	contract, ok := s.contracts[contractKey{chainId: chainId, address: address}]
+	return contract, ok
+}
+
+// FailNext makes the next n requests fail with the given status code.
+func (s *Server) FailNext(n int, statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failNext = append(s.failNext, statusCode)
+	}
+}
+
+// Requests returns the request URIs received so far, in order.
+func (s *Server) Requests() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.requests...)
+}
+
+// loadFixtures adds the contracts of every `chainId:address.json` file in dir.
+func (s *Server) loadFixtures(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*:*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read fixture: %w", err)
+		}
+
+		var contract sourcify.ContractResponse
+		if err := json.Unmarshal(data, &contract); err != nil {
+			return fmt.Errorf("failed to decode fixture %s: %w", filepath.Base(path), err)
+		}
+
+		// The file name is authoritative for fixtures saved with partial responses.
+		chain, address, _ := strings.Cut(strings.TrimSuffix(filepath.Base(path), ".json"), ":")
+		if contract.ChainID == "" {
+			contract.ChainID = chain
+		}
+		if contract.Address == "" {
+			contract.Address = address
+		}
+		s.AddContract(&contract)
+	}
+	return nil
+}
+
+// sortedContracts returns the contracts of the chain sorted by ascending match ID.
+func (s *Server) sortedContracts(chainId int) []*sourcify.ContractResponse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	toReturn := []*sourcify.ContractResponse{}
+	for key, contract := range s.contracts {
+		if key.chainId == chainId {
+			toReturn = append(toReturn, contract)
+		}
+	}
+	sort.Slice(toReturn, func(i, j int) bool {
+		a, _ := strconv.ParseInt(toReturn[i].MatchID, 10, 64)
+		b, _ := strconv.ParseInt(toReturn[j].MatchID, 10, 64)
+		if a != b {
+			return a < b
+		}
+		return toReturn[i].Address < toReturn[j].Address
+	})
+	return toReturn
+}
+
+// chainList returns the configured chains, or a supported chain for every chain with contracts.
+func (s *Server) chainList() []sourcify.Chain {
+	if s.chains != nil {
+		return s.chains
+	}
+
+	s.mu.RLock()
+	ids := make(map[int]bool)
+	for key := range s.contracts {
+		ids[key.chainId] = true
+	}
+	s.mu.RUnlock()
+
+	toReturn := make([]sourcify.Chain, 0, len(ids))
+	for id := range ids {
+		toReturn = append(toReturn, sourcify.Chain{
+			Name:      fmt.Sprintf("Chain %d", id),
+			ChainID:   id,
+			NetworkID: id,
+			Supported: true,
+			RPC:       []string{},
+			Faucets:   []any{},
+		})
+	}
+	sort.Slice(toReturn, func(i, j int) bool {
+		return toReturn[i].ChainID < toReturn[j].ChainID
+	})
+	return toReturn
+}
+
+// intercept records the request and applies the injected latency and failures. It reports whether the
+// request was answered with a failure.
+func (s *Server) intercept(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.RequestURI())
+	statusCode := 0
+	if len(s.failNext) > 0 {
+		statusCode, s.failNext = s.failNext[0], s.failNext[1:]
+	}
+	s.mu.Unlock()
+
+	if s.latency > 0 {
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	if statusCode == 0 && s.failures != nil {
+		statusCode = s.failures(r)
+	}
+	if statusCode != 0 {
+		writeError(w, statusCode, "injected_failure", "injected failure")
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package sourcifytest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unpackdev/sourcify-go"
+)
+
+var usdt = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+
+func TestNewServerFixtures(t *testing.T) {
+	server := NewServer(t, WithFixtures("../testdata"))
+
+	contract, ok := server.Contract(1, usdt)
+	require.True(t, ok)
+	assert.Equal(t, "TetherToken", contract.Compilation.Name)
+
+	_, ok = server.Contract(5, usdt)
+	assert.False(t, ok)
+}
+
+func TestServerHealthAndChains(t *testing.T) {
+	server := NewServer(t, WithFixtures("../testdata"))
+	client := server.Client()
+
+	healthy, err := sourcify.GetHealth(client)
+	require.NoError(t, err)
+	assert.True(t, healthy)
+
+	chains, err := sourcify.GetChains(client)
+	require.NoError(t, err)
+	require.Len(t, chains, 1)
+	assert.Equal(t, 1, chains[0].ChainID)
+	assert.True(t, chains[0].Supported)
+
+	custom := NewServer(t, WithChains(sourcify.Chain{Name: "Ethereum Mainnet", ChainID: 1}, sourcify.Chain{Name: "Sepolia", ChainID: 11155111}))
+	chains, err = sourcify.GetChains(custom.Client())
+	require.NoError(t, err)
+	assert.Len(t, chains, 2)
+}
+
+func TestServerFailNext(t *testing.T) {
+	server := NewServer(t, WithFixtures("../testdata"))
+	server.FailNext(2, http.StatusServiceUnavailable)
+
+	client := server.Client(sourcify.WithRetryOptions(sourcify.WithMaxRetries(2), sourcify.WithDelay(time.Millisecond)))
+	contract, err := sourcify.GetContractByChainIdAndAddress(client, 1, usdt, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, usdt.Hex(), contract.Address)
+	assert.Len(t, server.Requests(), 3)
+
+	server.FailNext(1, http.StatusServiceUnavailable)
+	_, err = sourcify.GetContractByChainIdAndAddress(server.Client(), 1, usdt, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestServerFailures(t *testing.T) {
+	server := NewServer(t, WithFixtures("../testdata"), WithFailures(func(r *http.Request) int {
+		if r.URL.Path == "/chains" {
+			return http.StatusTooManyRequests
+		}
+		return 0
+	}))
+
+	_, err := sourcify.GetChains(server.Client())
+	assert.ErrorContains(t, err, "injected failure")
+
+	healthy, err := sourcify.GetHealth(server.Client())
+	require.NoError(t, err)
+	assert.True(t, healthy)
+}
+
+func TestServerLatency(t *testing.T) {
+	server := NewServer(t, WithLatency(200*time.Millisecond))
+
+	client := server.Client(sourcify.WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}))
+	_, err := sourcify.GetHealth(client)
+	assert.Error(t, err)
+
+	start := time.Now()
+	healthy, err := sourcify.GetHealth(server.Client())
+	require.NoError(t, err)
+	assert.True(t, healthy)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
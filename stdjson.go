@@ -0,0 +1,131 @@
+package sourcify
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrSourceMissing is returned when the content of a source listed in the metadata is not available.
+	ErrSourceMissing = errors.New("source content missing")
+
+	// ErrSourceHashMismatch is returned when a source does not hash to the keccak256 recorded in the metadata.
+	ErrSourceHashMismatch = errors.New("source keccak256 does not match metadata")
+)
+
+// DefaultOutputSelection is the output selection used by BuildStdJSONInput, as the metadata does not record
+// the outputs requested at compile time. It selects everything needed to verify and inspect a contract.
+var DefaultOutputSelection = OutputSelection{
+	"*": {
+		"*": {"abi", "evm.bytecode", "evm.deployedBytecode", "evm.methodIdentifiers", "metadata", "storageLayout"},
+		"":  {"ast"},
+	},
+}
+
+// BuildStdJSONInput reconstructs a compiler ready standard JSON input from the contract metadata and the sources.
+// Source contents are taken from sources, or from the metadata itself when it was compiled with
+// metadata.useLiteralContent. Every source is checked against the keccak256 recorded in the metadata.
+// Remappings, the optimizer including its details, the EVM version, viaIR, libraries and metadata settings are
+// copied over, while the compilation target, which the compiler does not accept as input, is dropped.
+// The output selection is set to DefaultOutputSelection.
+func BuildStdJSONInput(metadata *Metadata, sources Sources) (*StdJSONInput, error) {
+	toReturn := &StdJSONInput{
+		Language: metadata.Language,
+		Settings: metadata.Settings,
+		Sources:  make(map[string]ContentReference, len(metadata.Sources)),
+	}
+
+	if toReturn.Language == "" {
+		toReturn.Language = "Solidity"
+	}
+
+	toReturn.Settings.CompilationTarget = nil
+	toReturn.Settings.OutputSelection = DefaultOutputSelection
+	if toReturn.Settings.Remappings == nil {
+		toReturn.Settings.Remappings = []string{}
+	}
+
+	for name, metadataSource := range metadata.Sources {
+		content := metadataSource.Content
+		if source, ok := sources[name]; ok {
+			content = source.Content
+		} else if content == "" {
+			return nil, fmt.Errorf("%w: %s", ErrSourceMissing, name)
+		}
+
+		if metadataSource.Keccak256 != "" {
+			hash := "0x" + hex.EncodeToString(keccak256([]byte(content)))
+			if !strings.EqualFold(hash, metadataSource.Keccak256) {
+				return nil, fmt.Errorf("%w: %s has %s, expected %s", ErrSourceHashMismatch, name, hash, metadataSource.Keccak256)
+			}
+		}
+
+		toReturn.Sources[name] = ContentReference{Content: content}
+	}
+
+	return toReturn, nil
+}
+
+// StandardJSONInput returns the standard JSON input the contract was verified with. When the response does not
+// include it, as with partial or older matches, it is reconstructed from the metadata and sources.
+func (c *ContractResponse) StandardJSONInput() (*StdJSONInput, error) {
+	if len(c.StdJSONInput.Sources) > 0 {
+		toReturn := c.StdJSONInput
+		return &toReturn, nil
+	}
+
+	return BuildStdJSONInput(&c.Metadata, c.Sources)
+}
+
+// MarshalJSON encodes the standard JSON input with the libraries in the nested form expected by the compiler
+// (`{"file": {"Library": "0x..."}}`) rather than the flat form used by the metadata. The compiler rejects null
+// settings, so libraries and remappings are encoded as an empty object and list when there are none.
+func (s StdJSONInput) MarshalJSON() ([]byte, error) {
+	type plain StdJSONInput
+
+	if s.Settings.Remappings == nil {
+		s.Settings.Remappings = []string{}
+	}
+
+	encoded, err := json.Marshal(plain(s))
+	if err != nil {
+		return nil, err
+	}
+
+	var input map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &input); err != nil {
+		return nil, err
+	}
+
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(input["settings"], &settings); err != nil {
+		return nil, err
+	}
+
+	if settings["libraries"], err = json.Marshal(s.Settings.Libraries.Nested()); err != nil {
+		return nil, err
+	}
+
+	if input["settings"], err = json.Marshal(settings); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(input)
+}
+
+// Nested returns the libraries grouped by source file, as expected by the standard JSON input.
+// Libraries declared without a file are grouped under the empty file name.
+func (l Libraries) Nested() map[string]map[string]string {
+	toReturn := make(map[string]map[string]string)
+	for name, address := range l {
+		file, library := splitFullyQualifiedName(name)
+		if toReturn[file] == nil {
+			toReturn[file] = make(map[string]string)
+		}
+		toReturn[file][library] = address
+	}
+	return toReturn
+}
@@ -0,0 +1,108 @@
+package sourcify
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildStdJSONInput(t *testing.T) {
+	contract, err := LoadContract(1, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	require.NoError(t, err)
+
+	input, err := BuildStdJSONInput(&contract.Metadata, contract.Sources)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Solidity", input.Language)
+	assert.Nil(t, input.Settings.CompilationTarget)
+	assert.Equal(t, DefaultOutputSelection, input.Settings.OutputSelection)
+	assert.Equal(t, contract.StdJSONInput.Sources, input.Sources)
+	assert.Equal(t, contract.StdJSONInput.Settings.Optimizer, input.Settings.Optimizer)
+
+	encoded, err := json.Marshal(input)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "compilationTarget")
+	assert.NotContains(t, string(encoded), "evmVersion")
+
+	tampered := Sources{"TetherToken.sol": {Content: "contract TetherToken {}"}}
+	_, err = BuildStdJSONInput(&contract.Metadata, tampered)
+	assert.ErrorIs(t, err, ErrSourceHashMismatch)
+
+	_, err = BuildStdJSONInput(&contract.Metadata, Sources{})
+	assert.ErrorIs(t, err, ErrSourceMissing)
+}
+
+func TestBuildStdJSONInput_Settings(t *testing.T) {
+	var metadata Metadata
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"compiler": {"version": "0.8.24+commit.e11b9ed9"},
+		"language": "Solidity",
+		"settings": {
+			"compilationTarget": {"contracts/Token.sol": "Token"},
+			"evmVersion": "cancun",
+			"viaIR": true,
+			"libraries": {"contracts/Math.sol:Math": "0x0000000000000000000000000000000000000001"},
+			"metadata": {"bytecodeHash": "none", "useLiteralContent": true, "appendCBOR": false},
+			"optimizer": {"enabled": true, "runs": 10000, "details": {"yul": true, "yulDetails": {"stackAllocation": true, "optimizerSteps": "dhfoDgvulfnTUtnIf"}}},
+			"remappings": ["@openzeppelin/=lib/openzeppelin/"]
+		},
+		"sources": {
+			"contracts/Token.sol": {"keccak256": "0x1b2d58b8b1d7b1f8a1b47c6e5e1f3c1c0b1b5b1a9c7e4b5b2f1c3e9b1a2c4d5e", "content": "contract Token {}"}
+		},
+		"version": 1
+	}`), &metadata))
+
+	assert.True(t, metadata.Settings.ViaIR)
+	assert.True(t, metadata.Settings.Metadata.UseLiteralContent)
+	require.NotNil(t, metadata.Settings.Metadata.AppendCBOR)
+	assert.False(t, *metadata.Settings.Metadata.AppendCBOR)
+	require.NotNil(t, metadata.Settings.Optimizer.Details)
+	assert.Equal(t, "dhfoDgvulfnTUtnIf", metadata.Settings.Optimizer.Details.YulDetails.OptimizerSteps)
+
+	// The literal content does not hash to the made up keccak256 above.
+	_, err := BuildStdJSONInput(&metadata, nil)
+	assert.ErrorIs(t, err, ErrSourceHashMismatch)
+
+	source := metadata.Sources["contracts/Token.sol"]
+	source.Keccak256 = ""
+	metadata.Sources["contracts/Token.sol"] = source
+
+	input, err := BuildStdJSONInput(&metadata, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "contract Token {}", input.Sources["contracts/Token.sol"].Content)
+
+	encoded, err := json.Marshal(input)
+	require.NoError(t, err)
+
+	var raw struct {
+		Settings map[string]json.RawMessage `json:"settings"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &raw))
+	assert.JSONEq(t, `{"contracts/Math.sol": {"Math": "0x0000000000000000000000000000000000000001"}}`, string(raw.Settings["libraries"]))
+	assert.JSONEq(t, `true`, string(raw.Settings["viaIR"]))
+	assert.JSONEq(t, `{"bytecodeHash": "none", "useLiteralContent": true, "appendCBOR": false}`, string(raw.Settings["metadata"]))
+	assert.NotContains(t, raw.Settings, "compilationTarget")
+
+	var decoded StdJSONInput
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, input.Settings.Libraries, decoded.Settings.Libraries)
+}
+
+func TestStdJSONInput_MarshalJSONWithoutLibraries(t *testing.T) {
+	encoded, err := json.Marshal(StdJSONInput{
+		Language: "Solidity",
+		Sources:  map[string]ContentReference{"A.sol": {Content: "contract A {}"}},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "null")
+
+	var raw struct {
+		Settings map[string]json.RawMessage `json:"settings"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &raw))
+	assert.JSONEq(t, `{}`, string(raw.Settings["libraries"]))
+	assert.JSONEq(t, `[]`, string(raw.Settings["remappings"]))
+}
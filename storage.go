@@ -0,0 +1,374 @@
+package sourcify
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrStorageVariableNotFound is returned when a variable or struct member is not part of the storage layout.
+	ErrStorageVariableNotFound = errors.New("storage variable not found")
+
+	// ErrStoragePathInvalid is returned when a storage variable path cannot be parsed or applied to a type.
+	ErrStoragePathInvalid = errors.New("invalid storage path")
+)
+
+// Storage encodings as reported in the storage layout of the compiler output.
+const (
+	StorageEncodingInplace      = "inplace"
+	StorageEncodingMapping      = "mapping"
+	StorageEncodingDynamicArray = "dynamic_array"
+	StorageEncodingBytes        = "bytes"
+)
+
+// slotSize is the size of a storage slot in bytes.
+const slotSize = 32
+
+// maxUint256 is the modulus storage slot arithmetic wraps around at.
+var maxUint256 = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// Size returns the number of bytes the type occupies in storage.
+func (t StorageType) Size() (int, error) {
+	size, err := strconv.Atoi(t.NumberOfBytes)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of bytes %q for type %s: %w", t.NumberOfBytes, t.Label, err)
+	}
+	return size, nil
+}
+
+// Slots returns the number of storage slots the type occupies.
+func (t StorageType) Slots() (int, error) {
+	size, err := t.Size()
+	if err != nil {
+		return 0, err
+	}
+	return (size + slotSize - 1) / slotSize, nil
+}
+
+// IsStruct reports whether the type is a struct.
+func (t StorageType) IsStruct() bool {
+	return len(t.Members) > 0
+}
+
+// Variable returns the top level storage entry with the given label.
+func (l *StorageLayout) Variable(label string) (StorageEntry, bool) {
+	if l == nil {
+		return StorageEntry{}, false
+	}
+	for _, entry := range l.Storage {
+		if entry.Label == label {
+			return entry, true
+		}
+	}
+	return StorageEntry{}, false
+}
+
+// StorageLocation represents the resolved position of a storage variable.
+type StorageLocation struct {
+	Path   string      // Path the location was resolved for.
+	Slot   common.Hash // Storage slot holding the value, or its first slot for values spanning several slots.
+	Offset int         // Byte offset of the value within the slot, counted from the least significant byte.
+	TypeID string      // Identifier of the value type in the storage layout types.
+	Type   StorageType // Type of the value.
+}
+
+// SlotInt returns the slot as an integer.
+func (l StorageLocation) SlotInt() *big.Int {
+	return new(big.Int).SetBytes(l.Slot.Bytes())
+}
+
+// Locate computes the storage slot and byte offset of a variable path such as `owner`,
+// `balances[0xabc...].amount`, `arr[3]` or `allowances[0xabc...][0xdef...]`.
+// Mapping keys are given as decimal or hex integers, addresses, `true`/`false`, hex for fixed
+// and dynamic bytes and quoted or bare text for strings. Struct members are accessed with a dot.
+func (l *StorageLayout) Locate(path string) (*StorageLocation, error) {
+	segments, err := parseStoragePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := l.Variable(segments[0].name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStorageVariableNotFound, segments[0].name)
+	}
+
+	slot, ok := new(big.Int).SetString(entry.Slot, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid slot %q for %s", entry.Slot, entry.Label)
+	}
+
+	location := &StorageLocation{
+		Path:   path,
+		Offset: entry.Offset,
+		TypeID: entry.Type,
+	}
+
+	for _, segment := range segments[1:] {
+		current, ok := l.Types[location.TypeID]
+		if !ok {
+			return nil, fmt.Errorf("unknown storage type %s", location.TypeID)
+		}
+
+		if segment.index {
+			slot, err = l.locateIndex(location, current, slot, segment.name)
+		} else {
+			slot, err = l.locateMember(location, current, slot, segment.name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	location.Type = l.Types[location.TypeID]
+	location.Slot = common.BigToHash(slot)
+	return location, nil
+}
+
+// locateMember moves the location to the given member of the struct stored at slot.
+func (l *StorageLayout) locateMember(location *StorageLocation, current StorageType, slot *big.Int, name string) (*big.Int, error) {
+	if !current.IsStruct() {
+		return nil, fmt.Errorf("%w: %s is not a struct, cannot access member %s", ErrStoragePathInvalid, current.Label, name)
+	}
+
+	for _, member := range current.Members {
+		if member.Label != name {
+			continue
+		}
+
+		memberSlot, ok := new(big.Int).SetString(member.Slot, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid slot %q for member %s", member.Slot, member.Label)
+		}
+
+		location.Offset = member.Offset
+		location.TypeID = member.Type
+		return wrapSlot(new(big.Int).Add(slot, memberSlot)), nil
+	}
+
+	return nil, fmt.Errorf("%w: member %s of %s", ErrStorageVariableNotFound, name, current.Label)
+}
+
+// locateIndex moves the location to the element of the mapping or array stored at slot.
+func (l *StorageLayout) locateIndex(location *StorageLocation, current StorageType, slot *big.Int, key string) (*big.Int, error) {
+	switch current.Encoding {
+	case StorageEncodingMapping:
+		keyType, ok := l.Types[current.Key]
+		if !ok {
+			return nil, fmt.Errorf("unknown mapping key type %s", current.Key)
+		}
+
+		encoded, err := encodeMappingKey(keyType, key)
+		if err != nil {
+			return nil, err
+		}
+
+		location.Offset = 0
+		location.TypeID = current.Value
+		return new(big.Int).SetBytes(keccak256(encoded, common.BigToHash(slot).Bytes())), nil
+
+	case StorageEncodingDynamicArray:
+		start := new(big.Int).SetBytes(keccak256(common.BigToHash(slot).Bytes()))
+		return l.locateElement(location, current, start, key, -1)
+
+	case StorageEncodingInplace:
+		if current.Base == "" {
+			return nil, fmt.Errorf("%w: %s cannot be indexed", ErrStoragePathInvalid, current.Label)
+		}
+
+		length := -1
+		if open := strings.LastIndex(current.Label, "["); open >= 0 {
+			if value, err := strconv.Atoi(strings.TrimSuffix(current.Label[open+1:], "]")); err == nil {
+				length = value
+			}
+		}
+		return l.locateElement(location, current, slot, key, length)
+
+	default:
+		return nil, fmt.Errorf("%w: %s with encoding %s cannot be indexed", ErrStoragePathInvalid, current.Label, current.Encoding)
+	}
+}
+
+// locateElement moves the location to the element at the given index of an array whose data starts at start.
+// A negative length disables the bounds check, as the length of dynamic arrays is only known on chain.
+func (l *StorageLayout) locateElement(location *StorageLocation, current StorageType, start *big.Int, key string, length int) (*big.Int, error) {
+	index, err := parseInteger(key)
+	if err != nil || index.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid array index %q", ErrStoragePathInvalid, key)
+	}
+	if length >= 0 && index.Cmp(big.NewInt(int64(length))) >= 0 {
+		return nil, fmt.Errorf("%w: index %s out of bounds for %s", ErrStoragePathInvalid, key, current.Label)
+	}
+
+	base, ok := l.Types[current.Base]
+	if !ok {
+		return nil, fmt.Errorf("unknown array base type %s", current.Base)
+	}
+
+	size, err := base.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	location.TypeID = current.Base
+
+	// Elements of up to 16 bytes are packed several per slot, larger ones start a new slot.
+	if size <= slotSize/2 {
+		perSlot := big.NewInt(int64(slotSize / size))
+		slotIndex, position := new(big.Int).DivMod(index, perSlot, new(big.Int))
+		location.Offset = int(position.Int64()) * size
+		return wrapSlot(new(big.Int).Add(start, slotIndex)), nil
+	}
+
+	slots, err := base.Slots()
+	if err != nil {
+		return nil, err
+	}
+
+	location.Offset = 0
+	return wrapSlot(new(big.Int).Add(start, new(big.Int).Mul(index, big.NewInt(int64(slots))))), nil
+}
+
+// storagePathSegment represents a single member access or index in a storage path.
+type storagePathSegment struct {
+	name  string
+	index bool
+}
+
+// parseStoragePath splits a path like `a.b[0x1]["key"].c` into its segments.
+func parseStoragePath(path string) ([]storagePathSegment, error) {
+	var toReturn []storagePathSegment
+
+	for pos := 0; pos < len(path); {
+		switch path[pos] {
+		case '.':
+			pos++
+		case '[':
+			end, err := closingBracket(path, pos)
+			if err != nil {
+				return nil, err
+			}
+			toReturn = append(toReturn, storagePathSegment{name: strings.TrimSpace(path[pos+1 : end]), index: true})
+			pos = end + 1
+			continue
+		}
+
+		start := pos
+		for pos < len(path) && path[pos] != '.' && path[pos] != '[' {
+			pos++
+		}
+		if name := strings.TrimSpace(path[start:pos]); name != "" {
+			toReturn = append(toReturn, storagePathSegment{name: name})
+		} else if pos < len(path) && path[pos] == '.' {
+			return nil, fmt.Errorf("%w: empty member name in %q", ErrStoragePathInvalid, path)
+		}
+	}
+
+	if len(toReturn) == 0 || toReturn[0].index {
+		return nil, fmt.Errorf("%w: %q must start with a variable name", ErrStoragePathInvalid, path)
+	}
+
+	return toReturn, nil
+}
+
+// closingBracket returns the position of the bracket closing the one opened at start, skipping quoted keys.
+func closingBracket(path string, start int) (int, error) {
+	var quote byte
+	for pos := start + 1; pos < len(path); pos++ {
+		switch c := path[pos]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ']':
+			return pos, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unterminated index in %q", ErrStoragePathInvalid, path)
+}
+
+// encodeMappingKey encodes a mapping key the way Solidity does before hashing it with the mapping slot.
+func encodeMappingKey(keyType StorageType, key string) ([]byte, error) {
+	label := keyType.Label
+
+	switch {
+	case label == "string":
+		if unquoted, err := strconv.Unquote(key); err == nil {
+			return []byte(unquoted), nil
+		}
+		return []byte(strings.Trim(key, "'")), nil
+
+	case label == "bytes":
+		return decodeHex(key)
+
+	case label == "bool":
+		value, err := strconv.ParseBool(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid bool key %q", ErrStoragePathInvalid, key)
+		}
+		if value {
+			return common.LeftPadBytes([]byte{1}, slotSize), nil
+		}
+		return make([]byte, slotSize), nil
+
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract ") || strings.HasPrefix(label, "interface "):
+		if !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("%w: invalid address key %q", ErrStoragePathInvalid, key)
+		}
+		return common.LeftPadBytes(common.HexToAddress(key).Bytes(), slotSize), nil
+
+	case strings.HasPrefix(label, "bytes"):
+		value, err := decodeHex(key)
+		if err != nil || len(value) > slotSize {
+			return nil, fmt.Errorf("%w: invalid %s key %q", ErrStoragePathInvalid, label, key)
+		}
+		return common.RightPadBytes(value, slotSize), nil
+
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "enum "):
+		value, err := parseInteger(key)
+		if err != nil || value.Sign() < 0 || value.BitLen() > 256 {
+			return nil, fmt.Errorf("%w: invalid %s key %q", ErrStoragePathInvalid, label, key)
+		}
+		return common.BigToHash(value).Bytes(), nil
+
+	case strings.HasPrefix(label, "int"):
+		value, err := parseInteger(key)
+		if err != nil || value.BitLen() > 255 {
+			return nil, fmt.Errorf("%w: invalid %s key %q", ErrStoragePathInvalid, label, key)
+		}
+		return common.BigToHash(wrapSlot(value)).Bytes(), nil
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported mapping key type %s", ErrStoragePathInvalid, label)
+	}
+}
+
+// parseInteger parses a decimal or 0x prefixed hex integer.
+func parseInteger(value string) (*big.Int, error) {
+	toReturn, ok := new(big.Int).SetString(value, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", value)
+	}
+	return toReturn, nil
+}
+
+// wrapSlot reduces the value modulo 2^256 as the EVM does for storage slot arithmetic.
+func wrapSlot(value *big.Int) *big.Int {
+	return value.Mod(value, maxUint256)
+}
+
+// keccak256 returns the Keccak-256 hash of the concatenated data.
+func keccak256(data ...[]byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		hasher.Write(d)
+	}
+	return hasher.Sum(nil)
+}
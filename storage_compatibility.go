@@ -0,0 +1,423 @@
+package sourcify
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// StorageIssueKind describes the kind of a storage layout incompatibility.
+type StorageIssueKind string
+
+const (
+	// StorageIssueRemoved denotes a variable present in the old layout but missing from the new one.
+	StorageIssueRemoved StorageIssueKind = "removed"
+
+	// StorageIssueMoved denotes a variable that is stored at a different slot or offset after the upgrade.
+	StorageIssueMoved StorageIssueKind = "moved"
+
+	// StorageIssueRetyped denotes a variable whose type changed in an incompatible way.
+	StorageIssueRetyped StorageIssueKind = "retyped"
+
+	// StorageIssueShrunk denotes a variable whose type occupies fewer bytes after the upgrade.
+	StorageIssueShrunk StorageIssueKind = "shrunk"
+
+	// StorageIssueInserted denotes a variable inserted in between existing variables instead of appended.
+	StorageIssueInserted StorageIssueKind = "inserted"
+
+	// StorageIssueRenamed denotes a variable stored at the same position with a compatible type but a different name.
+	StorageIssueRenamed StorageIssueKind = "renamed"
+
+	// StorageIssueGap denotes a storage gap that was not resized to keep the following variables in place.
+	StorageIssueGap StorageIssueKind = "gap"
+)
+
+// StorageIssueSeverity describes how severe a storage layout incompatibility is.
+type StorageIssueSeverity string
+
+const (
+	// StorageIssueSeverityError denotes an incompatibility that corrupts state after the upgrade.
+	StorageIssueSeverityError StorageIssueSeverity = "error"
+
+	// StorageIssueSeverityWarning denotes a change that is likely safe but should be reviewed.
+	StorageIssueSeverityWarning StorageIssueSeverity = "warning"
+)
+
+// StorageIssue represents a single storage layout incompatibility between two implementations.
+type StorageIssue struct {
+	Kind     StorageIssueKind     `json:"kind"`
+	Severity StorageIssueSeverity `json:"severity"`
+	Label    string               `json:"label"`         // Label of the affected variable.
+	Old      *StorageEntry        `json:"old,omitempty"` // Variable in the old layout, if any.
+	New      *StorageEntry        `json:"new,omitempty"` // Variable in the new layout, if any.
+	Message  string               `json:"message"`
+}
+
+// StorageCompatibilityReport represents the result of comparing two storage layouts.
+type StorageCompatibilityReport struct {
+	Issues []StorageIssue `json:"issues"`
+}
+
+// Safe reports whether the upgrade has no error level issues.
+func (r *StorageCompatibilityReport) Safe() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == StorageIssueSeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a human readable summary of the report.
+func (r *StorageCompatibilityReport) String() string {
+	if len(r.Issues) == 0 {
+		return "storage layouts are compatible"
+	}
+
+	var sb strings.Builder
+	for _, issue := range r.Issues {
+		sb.WriteString(fmt.Sprintf("[%s] %s %s: %s\n", issue.Severity, issue.Kind, issue.Label, issue.Message))
+	}
+	return sb.String()
+}
+
+// storageItem is a top level storage variable with its resolved byte range.
+type storageItem struct {
+	entry StorageEntry
+	start *big.Int
+	end   *big.Int
+}
+
+// isGap reports whether the variable is a storage gap reserved for future upgrades.
+func (i storageItem) isGap() bool {
+	return strings.HasPrefix(i.entry.Label, "__gap")
+}
+
+// CheckStorageCompatibility compares the storage layout of an implementation with the layout of the
+// implementation replacing it and reports unsafe changes: removed, moved, retyped, shrunk and inserted
+// variables, as well as storage gaps (`__gap`) that were not shrunk to make room for new variables.
+// Variables are matched by label in declaration order.
+func CheckStorageCompatibility(oldLayout *StorageLayout, newLayout *StorageLayout) (*StorageCompatibilityReport, error) {
+	if oldLayout == nil || newLayout == nil {
+		return nil, ErrStorageLayoutMissing
+	}
+
+	oldItems, err := storageItems(oldLayout)
+	if err != nil {
+		return nil, err
+	}
+
+	newItems, err := storageItems(newLayout)
+	if err != nil {
+		return nil, err
+	}
+
+	oldEnd := new(big.Int)
+	for _, item := range oldItems {
+		if item.end.Cmp(oldEnd) > 0 {
+			oldEnd = item.end
+		}
+	}
+
+	report := &StorageCompatibilityReport{Issues: []StorageIssue{}}
+	addIssue := func(kind StorageIssueKind, severity StorageIssueSeverity, oldItem *storageItem, newItem *storageItem, message string) {
+		issue := StorageIssue{Kind: kind, Severity: severity, Message: message}
+		if oldItem != nil {
+			entry := oldItem.entry
+			issue.Old = &entry
+			issue.Label = entry.Label
+		}
+		if newItem != nil {
+			entry := newItem.entry
+			issue.New = &entry
+			issue.Label = entry.Label
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	matches := matchStorageItems(oldItems, newItems)
+
+	matchedOld := make(map[int]bool)
+	matchedNew := make(map[int]bool)
+	for _, match := range matches {
+		matchedOld[match[0]] = true
+		matchedNew[match[1]] = true
+
+		oldItem, newItem := &oldItems[match[0]], &newItems[match[1]]
+
+		if oldItem.isGap() {
+			if oldItem.end.Cmp(newItem.end) != 0 {
+				addIssue(StorageIssueGap, StorageIssueSeverityError, oldItem, newItem,
+					fmt.Sprintf("gap ends at byte %s instead of %s, it must shrink by exactly the space taken by new variables", newItem.end, oldItem.end))
+			}
+			continue
+		}
+
+		if oldItem.start.Cmp(newItem.start) != 0 {
+			addIssue(StorageIssueMoved, StorageIssueSeverityError, oldItem, newItem,
+				fmt.Sprintf("moved from slot %s offset %d to slot %s offset %d", oldItem.entry.Slot, oldItem.entry.Offset, newItem.entry.Slot, newItem.entry.Offset))
+		}
+
+		if ok, reason := compatibleStorageTypes(oldLayout, oldItem.entry.Type, newLayout, newItem.entry.Type); !ok {
+			oldSize := new(big.Int).Sub(oldItem.end, oldItem.start)
+			newSize := new(big.Int).Sub(newItem.end, newItem.start)
+			if newSize.Cmp(oldSize) < 0 {
+				addIssue(StorageIssueShrunk, StorageIssueSeverityError, oldItem, newItem,
+					fmt.Sprintf("type shrunk from %s (%s bytes) to %s (%s bytes)", oldLayout.Types[oldItem.entry.Type].Label, oldSize, newLayout.Types[newItem.entry.Type].Label, newSize))
+			} else {
+				addIssue(StorageIssueRetyped, StorageIssueSeverityError, oldItem, newItem, reason)
+			}
+		}
+	}
+
+	// Unmatched variables at the same position with compatible types are most likely renames.
+	renamedOld := make(map[int]bool)
+	renamedNew := make(map[int]bool)
+	for i := range oldItems {
+		if matchedOld[i] {
+			continue
+		}
+		for j := range newItems {
+			if matchedNew[j] || renamedNew[j] || oldItems[i].start.Cmp(newItems[j].start) != 0 {
+				continue
+			}
+			if ok, _ := compatibleStorageTypes(oldLayout, oldItems[i].entry.Type, newLayout, newItems[j].entry.Type); ok {
+				renamedOld[i], renamedNew[j] = true, true
+				addIssue(StorageIssueRenamed, StorageIssueSeverityWarning, &oldItems[i], &newItems[j],
+					fmt.Sprintf("renamed from %s to %s", oldItems[i].entry.Label, newItems[j].entry.Label))
+				break
+			}
+		}
+	}
+
+	for i := range oldItems {
+		if matchedOld[i] || renamedOld[i] {
+			continue
+		}
+		kind := StorageIssueRemoved
+		if oldItems[i].isGap() {
+			kind = StorageIssueGap
+		}
+		addIssue(kind, StorageIssueSeverityError, &oldItems[i], nil,
+			fmt.Sprintf("removed from slot %s offset %d", oldItems[i].entry.Slot, oldItems[i].entry.Offset))
+	}
+
+	for j := range newItems {
+		if matchedNew[j] || renamedNew[j] {
+			continue
+		}
+
+		item := &newItems[j]
+
+		// Appending after the last old variable or taking space from an old gap is safe.
+		if item.start.Cmp(oldEnd) >= 0 || withinGap(oldItems, item) {
+			continue
+		}
+
+		addIssue(StorageIssueInserted, StorageIssueSeverityError, nil, item,
+			fmt.Sprintf("inserted at slot %s offset %d, which is used by the old layout", item.entry.Slot, item.entry.Offset))
+	}
+
+	return report, nil
+}
+
+// CheckUpgradeCompatibility fetches the storage layouts of two verified implementations, such as two entries
+// of ProxyResolution.Implementations, and checks whether upgrading from the old to the new one is safe.
+func CheckUpgradeCompatibility(client *Client, chainId int, oldImplementation common.Address, newImplementation common.Address) (*StorageCompatibilityReport, error) {
+	layouts := make([]*StorageLayout, 0, 2)
+	for _, address := range []common.Address{oldImplementation, newImplementation} {
+		contract, err := GetContractByChainIdAndAddress(client, chainId, address, []string{"storageLayout"}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch implementation %s: %w", address.Hex(), err)
+		}
+
+		if contract.StorageLayout == nil {
+			return nil, fmt.Errorf("%w: implementation %s", ErrStorageLayoutMissing, address.Hex())
+		}
+		layouts = append(layouts, contract.StorageLayout)
+	}
+
+	return CheckStorageCompatibility(layouts[0], layouts[1])
+}
+
+// storageItems resolves the byte range of every top level variable of the layout.
+func storageItems(layout *StorageLayout) ([]storageItem, error) {
+	toReturn := make([]storageItem, 0, len(layout.Storage))
+	for _, entry := range layout.Storage {
+		slot, ok := new(big.Int).SetString(entry.Slot, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid slot %q for %s", entry.Slot, entry.Label)
+		}
+
+		storageType, ok := layout.Types[entry.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown storage type %s", entry.Type)
+		}
+
+		size, err := storageType.Size()
+		if err != nil {
+			return nil, err
+		}
+
+		start := new(big.Int).Add(new(big.Int).Mul(slot, big.NewInt(slotSize)), big.NewInt(int64(entry.Offset)))
+		toReturn = append(toReturn, storageItem{
+			entry: entry,
+			start: start,
+			end:   new(big.Int).Add(start, big.NewInt(int64(size))),
+		})
+	}
+	return toReturn, nil
+}
+
+// matchStorageItems pairs variables of both layouts by label using the longest common subsequence,
+// so that a single insertion or removal does not prevent the following variables from matching.
+// Variables left over with the same label are paired afterwards, which reveals reordered variables.
+func matchStorageItems(oldItems []storageItem, newItems []storageItem) [][2]int {
+	lengths := make([][]int, len(oldItems)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(newItems)+1)
+	}
+
+	for i := len(oldItems) - 1; i >= 0; i-- {
+		for j := len(newItems) - 1; j >= 0; j-- {
+			if oldItems[i].entry.Label == newItems[j].entry.Label {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var toReturn [][2]int
+	for i, j := 0, 0; i < len(oldItems) && j < len(newItems); {
+		switch {
+		case oldItems[i].entry.Label == newItems[j].entry.Label:
+			toReturn = append(toReturn, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	matchedOld := make(map[int]bool)
+	matchedNew := make(map[int]bool)
+	for _, match := range toReturn {
+		matchedOld[match[0]], matchedNew[match[1]] = true, true
+	}
+
+	for i := range oldItems {
+		if matchedOld[i] {
+			continue
+		}
+		for j := range newItems {
+			if !matchedNew[j] && oldItems[i].entry.Label == newItems[j].entry.Label {
+				matchedNew[j] = true
+				toReturn = append(toReturn, [2]int{i, j})
+				break
+			}
+		}
+	}
+
+	sort.Slice(toReturn, func(a, b int) bool {
+		return toReturn[a][0] < toReturn[b][0]
+	})
+	return toReturn
+}
+
+// withinGap reports whether the item lies entirely within a storage gap of the old layout.
+func withinGap(oldItems []storageItem, item *storageItem) bool {
+	for _, old := range oldItems {
+		if old.isGap() && item.start.Cmp(old.start) >= 0 && item.end.Cmp(old.end) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// compatibleStorageTypes reports whether a value stored with the old type can be read with the new type.
+// Types are compared structurally, so renamed structs, contracts and enums remain compatible. Structs may gain
+// members at the end as long as they fit in the slots of the struct, which nothing else uses.
+func compatibleStorageTypes(oldLayout *StorageLayout, oldID string, newLayout *StorageLayout, newID string) (bool, string) {
+	return compatibleStorageTypesResizable(oldLayout, oldID, newLayout, newID, false)
+}
+
+// compatibleStorageTypesResizable compares the types like compatibleStorageTypes. When resizable, a struct may
+// also grow beyond its slots, as mapping values do not share their slots with other values.
+func compatibleStorageTypesResizable(oldLayout *StorageLayout, oldID string, newLayout *StorageLayout, newID string, resizable bool) (bool, string) {
+	oldType, ok := oldLayout.Types[oldID]
+	if !ok {
+		return false, fmt.Sprintf("unknown old type %s", oldID)
+	}
+
+	newType, ok := newLayout.Types[newID]
+	if !ok {
+		return false, fmt.Sprintf("unknown new type %s", newID)
+	}
+
+	mismatch := fmt.Sprintf("type changed from %s to %s", oldType.Label, newType.Label)
+
+	if oldType.Encoding != newType.Encoding {
+		return false, mismatch
+	}
+	if oldType.NumberOfBytes != newType.NumberOfBytes && !(resizable && oldType.IsStruct() && newType.IsStruct()) {
+		return false, mismatch
+	}
+
+	switch {
+	case oldType.Encoding == StorageEncodingMapping:
+		if ok, _ := compatibleStorageTypes(oldLayout, oldType.Key, newLayout, newType.Key); !ok {
+			return false, mismatch
+		}
+		if ok, _ := compatibleStorageTypesResizable(oldLayout, oldType.Value, newLayout, newType.Value, true); !ok {
+			return false, mismatch
+		}
+		return true, ""
+
+	case oldType.Base != "" || newType.Base != "":
+		// Array elements are stored back to back, so their structs cannot grow beyond their slots.
+		if ok, _ := compatibleStorageTypes(oldLayout, oldType.Base, newLayout, newType.Base); !ok {
+			return false, mismatch
+		}
+		return true, ""
+
+	case oldType.IsStruct() || newType.IsStruct():
+		if len(newType.Members) < len(oldType.Members) {
+			return false, mismatch
+		}
+		for i := range oldType.Members {
+			oldMember, newMember := oldType.Members[i], newType.Members[i]
+			if oldMember.Slot != newMember.Slot || oldMember.Offset != newMember.Offset {
+				return false, mismatch
+			}
+			if ok, _ := compatibleStorageTypes(oldLayout, oldMember.Type, newLayout, newMember.Type); !ok {
+				return false, mismatch
+			}
+		}
+		return true, ""
+	}
+
+	if oldType.Label == newType.Label || storageTypeFamily(oldType.Label) == storageTypeFamily(newType.Label) {
+		return true, ""
+	}
+	return false, mismatch
+}
+
+// storageTypeFamily groups value type labels that share the same storage representation.
+func storageTypeFamily(label string) string {
+	switch {
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract ") || strings.HasPrefix(label, "interface "):
+		return "address"
+	case strings.HasPrefix(label, "enum "):
+		return "enum"
+	case label == "string" || label == "bytes":
+		return "bytes"
+	default:
+		return label
+	}
+}
@@ -0,0 +1,202 @@
+package sourcify
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testUpgradeTypes are the storage types shared by the upgrade layouts below.
+const testUpgradeTypes = `{
+  "t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+  "t_contract(IToken)5": {"encoding": "inplace", "label": "contract IToken", "numberOfBytes": "20"},
+  "t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+  "t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+  "t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+  "t_array(t_uint256)48_storage": {"base": "t_uint256", "encoding": "inplace", "label": "uint256[48]", "numberOfBytes": "1536"},
+  "t_array(t_uint256)47_storage": {"base": "t_uint256", "encoding": "inplace", "label": "uint256[47]", "numberOfBytes": "1504"},
+  "t_mapping(t_address,t_uint256)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => uint256)", "numberOfBytes": "32", "value": "t_uint256"},
+  "t_struct(Account)1_storage": {"encoding": "inplace", "label": "struct Box.Account", "numberOfBytes": "32", "members": [
+    {"label": "amount", "offset": 0, "slot": "0", "type": "t_uint128"}
+  ]},
+  "t_struct(Account)2_storage": {"encoding": "inplace", "label": "struct Box.Account", "numberOfBytes": "32", "members": [
+    {"label": "amount", "offset": 0, "slot": "0", "type": "t_uint128"},
+    {"label": "nonce", "offset": 16, "slot": "0", "type": "t_uint128"}
+  ]},
+  "t_struct(Account)3_storage": {"encoding": "inplace", "label": "struct Box.Account", "numberOfBytes": "64", "members": [
+    {"label": "amount", "offset": 0, "slot": "0", "type": "t_uint128"},
+    {"label": "owner", "offset": 0, "slot": "1", "type": "t_address"}
+  ]},
+  "t_mapping(t_address,t_struct(Account)1_storage)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => struct Box.Account)", "numberOfBytes": "32", "value": "t_struct(Account)1_storage"},
+  "t_mapping(t_address,t_struct(Account)3_storage)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => struct Box.Account)", "numberOfBytes": "32", "value": "t_struct(Account)3_storage"},
+  "t_array(t_struct(Account)1_storage)dyn_storage": {"base": "t_struct(Account)1_storage", "encoding": "dynamic_array", "label": "struct Box.Account[]", "numberOfBytes": "32"},
+  "t_array(t_struct(Account)2_storage)dyn_storage": {"base": "t_struct(Account)2_storage", "encoding": "dynamic_array", "label": "struct Box.Account[]", "numberOfBytes": "32"},
+  "t_array(t_struct(Account)3_storage)dyn_storage": {"base": "t_struct(Account)3_storage", "encoding": "dynamic_array", "label": "struct Box.Account[]", "numberOfBytes": "32"}
+}`
+
+func testUpgradeLayout(t *testing.T, storage string) *StorageLayout {
+	var layout StorageLayout
+	require.NoError(t, json.Unmarshal([]byte(`{"storage": `+storage+`, "types": `+testUpgradeTypes+`}`), &layout))
+	return &layout
+}
+
+const testUpgradeV1 = `[
+  {"astId": 1, "contract": "Box.sol:Box", "label": "owner", "offset": 0, "slot": "0", "type": "t_address"},
+  {"astId": 2, "contract": "Box.sol:Box", "label": "paused", "offset": 20, "slot": "0", "type": "t_bool"},
+  {"astId": 3, "contract": "Box.sol:Box", "label": "total", "offset": 0, "slot": "1", "type": "t_uint256"},
+  {"astId": 4, "contract": "Box.sol:Box", "label": "__gap", "offset": 0, "slot": "2", "type": "t_array(t_uint256)48_storage"},
+  {"astId": 5, "contract": "Box.sol:Box", "label": "balances", "offset": 0, "slot": "50", "type": "t_mapping(t_address,t_uint256)"}
+]`
+
+func TestCheckStorageCompatibility_Safe(t *testing.T) {
+	// A new variable takes one slot of the gap, the gap shrinks accordingly, a variable is
+	// appended at the end and the owner changes to a contract type of the same size.
+	v2 := testUpgradeLayout(t, `[
+  {"astId": 1, "contract": "Box.sol:BoxV2", "label": "owner", "offset": 0, "slot": "0", "type": "t_contract(IToken)5"},
+  {"astId": 2, "contract": "Box.sol:BoxV2", "label": "paused", "offset": 20, "slot": "0", "type": "t_bool"},
+  {"astId": 3, "contract": "Box.sol:BoxV2", "label": "total", "offset": 0, "slot": "1", "type": "t_uint256"},
+  {"astId": 6, "contract": "Box.sol:BoxV2", "label": "fee", "offset": 0, "slot": "2", "type": "t_uint256"},
+  {"astId": 4, "contract": "Box.sol:BoxV2", "label": "__gap", "offset": 0, "slot": "3", "type": "t_array(t_uint256)47_storage"},
+  {"astId": 5, "contract": "Box.sol:BoxV2", "label": "balances", "offset": 0, "slot": "50", "type": "t_mapping(t_address,t_uint256)"},
+  {"astId": 7, "contract": "Box.sol:BoxV2", "label": "version", "offset": 0, "slot": "51", "type": "t_uint256"}
+]`)
+
+	report, err := CheckStorageCompatibility(testUpgradeLayout(t, testUpgradeV1), v2)
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+	assert.True(t, report.Safe())
+	assert.Equal(t, "storage layouts are compatible", report.String())
+}
+
+func TestCheckStorageCompatibility_Unsafe(t *testing.T) {
+	tests := []struct {
+		name     string
+		storage  string
+		kinds    []StorageIssueKind
+		severity StorageIssueSeverity
+	}{
+		{
+			name: "removed",
+			storage: `[
+  {"label": "owner", "offset": 0, "slot": "0", "type": "t_address"},
+  {"label": "paused", "offset": 20, "slot": "0", "type": "t_bool"},
+  {"label": "__gap", "offset": 0, "slot": "2", "type": "t_array(t_uint256)48_storage"},
+  {"label": "balances", "offset": 0, "slot": "50", "type": "t_mapping(t_address,t_uint256)"}
+]`,
+			kinds: []StorageIssueKind{StorageIssueRemoved},
+		},
+		{
+			name: "reordered",
+			storage: `[
+  {"label": "paused", "offset": 0, "slot": "0", "type": "t_bool"},
+  {"label": "owner", "offset": 1, "slot": "0", "type": "t_address"},
+  {"label": "total", "offset": 0, "slot": "1", "type": "t_uint256"},
+  {"label": "__gap", "offset": 0, "slot": "2", "type": "t_array(t_uint256)48_storage"},
+  {"label": "balances", "offset": 0, "slot": "50", "type": "t_mapping(t_address,t_uint256)"}
+]`,
+			kinds: []StorageIssueKind{StorageIssueMoved, StorageIssueMoved},
+		},
+		{
+			name: "shrunk and retyped",
+			storage: `[
+  {"label": "owner", "offset": 0, "slot": "0", "type": "t_address"},
+  {"label": "paused", "offset": 20, "slot": "0", "type": "t_uint128"},
+  {"label": "total", "offset": 0, "slot": "1", "type": "t_uint128"},
+  {"label": "__gap", "offset": 0, "slot": "2", "type": "t_array(t_uint256)48_storage"},
+  {"label": "balances", "offset": 0, "slot": "50", "type": "t_uint256"}
+]`,
+			kinds: []StorageIssueKind{StorageIssueRetyped, StorageIssueShrunk, StorageIssueRetyped},
+		},
+		{
+			name: "inserted",
+			storage: `[
+  {"label": "admin", "offset": 0, "slot": "0", "type": "t_uint256"},
+  {"label": "owner", "offset": 0, "slot": "1", "type": "t_address"},
+  {"label": "paused", "offset": 20, "slot": "1", "type": "t_bool"},
+  {"label": "total", "offset": 0, "slot": "2", "type": "t_uint256"},
+  {"label": "__gap", "offset": 0, "slot": "3", "type": "t_array(t_uint256)47_storage"},
+  {"label": "balances", "offset": 0, "slot": "50", "type": "t_mapping(t_address,t_uint256)"}
+]`,
+			kinds: []StorageIssueKind{StorageIssueMoved, StorageIssueMoved, StorageIssueMoved, StorageIssueInserted},
+		},
+		{
+			name: "gap not shrunk",
+			storage: `[
+  {"label": "owner", "offset": 0, "slot": "0", "type": "t_address"},
+  {"label": "paused", "offset": 20, "slot": "0", "type": "t_bool"},
+  {"label": "total", "offset": 0, "slot": "1", "type": "t_uint256"},
+  {"label": "fee", "offset": 0, "slot": "2", "type": "t_uint256"},
+  {"label": "__gap", "offset": 0, "slot": "3", "type": "t_array(t_uint256)48_storage"},
+  {"label": "balances", "offset": 0, "slot": "51", "type": "t_mapping(t_address,t_uint256)"}
+]`,
+			kinds: []StorageIssueKind{StorageIssueGap, StorageIssueMoved},
+		},
+		{
+			name: "renamed",
+			storage: `[
+  {"label": "admin", "offset": 0, "slot": "0", "type": "t_address"},
+  {"label": "paused", "offset": 20, "slot": "0", "type": "t_bool"},
+  {"label": "total", "offset": 0, "slot": "1", "type": "t_uint256"},
+  {"label": "__gap", "offset": 0, "slot": "2", "type": "t_array(t_uint256)48_storage"},
+  {"label": "balances", "offset": 0, "slot": "50", "type": "t_mapping(t_address,t_uint256)"}
+]`,
+			kinds:    []StorageIssueKind{StorageIssueRenamed},
+			severity: StorageIssueSeverityWarning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := CheckStorageCompatibility(testUpgradeLayout(t, testUpgradeV1), testUpgradeLayout(t, tt.storage))
+			require.NoError(t, err)
+
+			kinds := make([]StorageIssueKind, 0, len(report.Issues))
+			for _, issue := range report.Issues {
+				kinds = append(kinds, issue.Kind)
+			}
+			assert.Equal(t, tt.kinds, kinds)
+
+			if tt.severity == StorageIssueSeverityWarning {
+				assert.True(t, report.Safe())
+			} else {
+				assert.False(t, report.Safe())
+			}
+			assert.Contains(t, report.String(), string(tt.kinds[0]))
+		})
+	}
+}
+
+func TestCheckStorageCompatibility_StructGrowth(t *testing.T) {
+	variable := func(typeID string) string {
+		return `[{"label": "accounts", "offset": 0, "slot": "0", "type": "` + typeID + `"}]`
+	}
+
+	tests := []struct {
+		name    string
+		oldType string
+		newType string
+		safe    bool
+	}{
+		{name: "mapping value gains a slot", oldType: "t_mapping(t_address,t_struct(Account)1_storage)", newType: "t_mapping(t_address,t_struct(Account)3_storage)", safe: true},
+		{name: "mapping value loses a member", oldType: "t_mapping(t_address,t_struct(Account)3_storage)", newType: "t_mapping(t_address,t_struct(Account)1_storage)"},
+		{name: "array element gains a packed member", oldType: "t_array(t_struct(Account)1_storage)dyn_storage", newType: "t_array(t_struct(Account)2_storage)dyn_storage", safe: true},
+		{name: "array element gains a slot", oldType: "t_array(t_struct(Account)1_storage)dyn_storage", newType: "t_array(t_struct(Account)3_storage)dyn_storage"},
+		{name: "variable gains a packed member", oldType: "t_struct(Account)1_storage", newType: "t_struct(Account)2_storage", safe: true},
+		{name: "variable gains a slot", oldType: "t_struct(Account)1_storage", newType: "t_struct(Account)3_storage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := CheckStorageCompatibility(testUpgradeLayout(t, variable(tt.oldType)), testUpgradeLayout(t, variable(tt.newType)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.safe, report.Safe(), report.String())
+		})
+	}
+}
+
+func TestCheckStorageCompatibility_MissingLayout(t *testing.T) {
+	_, err := CheckStorageCompatibility(nil, testUpgradeLayout(t, testUpgradeV1))
+	assert.ErrorIs(t, err, ErrStorageLayoutMissing)
+}
@@ -0,0 +1,405 @@
+package sourcify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrStorageLayoutMissing is returned when a contract does not provide a storage layout.
+	ErrStorageLayoutMissing = errors.New("storage layout is missing")
+
+	// ErrInvalidDecoderLimit is returned when a storage decoder limit is negative.
+	ErrInvalidDecoderLimit = errors.New("invalid storage decoder limit")
+)
+
+// StorageReader reads raw 32 byte storage slots of a contract, as eth_getStorageAt does.
+type StorageReader interface {
+	StorageAt(ctx context.Context, slot common.Hash) (common.Hash, error)
+}
+
+// StorageReaderFunc adapts a function to the StorageReader interface, for example to wrap an ethclient:
+//
+//	reader := sourcify.StorageReaderFunc(func(ctx context.Context, slot common.Hash) (common.Hash, error) {
+//		value, err := ethClient.StorageAt(ctx, address, slot, nil)
+//		return common.BytesToHash(value), err
+//	})
+type StorageReaderFunc func(ctx context.Context, slot common.Hash) (common.Hash, error)
+
+// StorageAt calls the function.
+func (f StorageReaderFunc) StorageAt(ctx context.Context, slot common.Hash) (common.Hash, error) {
+	return f(ctx, slot)
+}
+
+// StorageMap is a StorageReader backed by an in-memory map. Missing slots read as zero.
+type StorageMap map[common.Hash]common.Hash
+
+// StorageAt returns the value of the slot, or zero when the slot is not set.
+func (m StorageMap) StorageAt(_ context.Context, slot common.Hash) (common.Hash, error) {
+	return m[slot], nil
+}
+
+// StorageValue represents a decoded storage value.
+// Value holds *big.Int for integers and enums, bool, common.Address for addresses and contracts,
+// []byte for fixed and dynamic bytes and string for strings. Structs and arrays carry their
+// decoded elements in Members instead, while mappings carry neither as their keys are unknown.
+type StorageValue struct {
+	Label     string         `json:"label"`               // Variable, member or element label.
+	Path      string         `json:"path"`                // Full path of the value, usable with StorageLayout.Locate.
+	Type      string         `json:"type"`                // Solidity type of the value.
+	Slot      common.Hash    `json:"slot"`                // Slot holding the value.
+	Offset    int            `json:"offset"`              // Byte offset of the value within the slot.
+	Value     any            `json:"value,omitempty"`     // Decoded value for value types, strings and bytes.
+	Length    *big.Int       `json:"length,omitempty"`    // Length of dynamic arrays, strings and bytes as stored on chain.
+	Members   []StorageValue `json:"members,omitempty"`   // Decoded struct members or array elements.
+	Truncated bool           `json:"truncated,omitempty"` // Whether only part of a long array or byte string was decoded.
+}
+
+// Member returns the struct member or array element with the given label.
+func (v StorageValue) Member(label string) (StorageValue, bool) {
+	for _, member := range v.Members {
+		if member.Label == label {
+			return member, true
+		}
+	}
+	return StorageValue{}, false
+}
+
+// StorageDecoderOption sets a configuration option for the storage decoder.
+type StorageDecoderOption func(*StorageDecoder)
+
+// WithMaxArrayLength limits the number of elements decoded per static or dynamic array. It must not be negative.
+func WithMaxArrayLength(length int) StorageDecoderOption {
+	return func(d *StorageDecoder) {
+		d.maxArrayLength = length
+	}
+}
+
+// WithMaxBytesLength limits the number of bytes decoded per long string or bytes value. It must not be negative.
+func WithMaxBytesLength(length int) StorageDecoderOption {
+	return func(d *StorageDecoder) {
+		d.maxBytesLength = length
+	}
+}
+
+// StorageDecoder reconstructs typed Solidity values from raw storage using a verified storage layout.
+type StorageDecoder struct {
+	layout         *StorageLayout
+	reader         StorageReader
+	maxArrayLength int
+	maxBytesLength int
+}
+
+// NewStorageDecoder creates a StorageDecoder reading raw slots through the given reader.
+// By default at most 1024 elements are decoded per array and 64KiB per long string or bytes value.
+func NewStorageDecoder(layout *StorageLayout, reader StorageReader, options ...StorageDecoderOption) (*StorageDecoder, error) {
+	if layout == nil {
+		return nil, ErrStorageLayoutMissing
+	}
+
+	d := &StorageDecoder{
+		layout:         layout,
+		reader:         reader,
+		maxArrayLength: 1024,
+		maxBytesLength: 64 * 1024,
+	}
+
+	for _, option := range options {
+		option(d)
+	}
+
+	if d.maxArrayLength < 0 {
+		return nil, fmt.Errorf("%w: max array length %d", ErrInvalidDecoderLimit, d.maxArrayLength)
+	}
+	if d.maxBytesLength < 0 {
+		return nil, fmt.Errorf("%w: max bytes length %d", ErrInvalidDecoderLimit, d.maxBytesLength)
+	}
+
+	return d, nil
+}
+
+// Decode decodes the value at the given variable path, as accepted by StorageLayout.Locate.
+func (d *StorageDecoder) Decode(ctx context.Context, path string) (*StorageValue, error) {
+	location, err := d.layout.Locate(path)
+	if err != nil {
+		return nil, err
+	}
+
+	label := path
+	if idx := strings.LastIndexAny(path, ".["); idx > 0 {
+		label = strings.TrimPrefix(path[idx:], ".")
+	}
+
+	value, err := d.decode(ctx, newSlotCache(d.reader), label, path, location.TypeID, location.SlotInt(), location.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// Dump decodes every state variable of the storage layout, in declaration order.
+func (d *StorageDecoder) Dump(ctx context.Context) ([]StorageValue, error) {
+	cache := newSlotCache(d.reader)
+
+	toReturn := make([]StorageValue, 0, len(d.layout.Storage))
+	for _, entry := range d.layout.Storage {
+		slot, ok := new(big.Int).SetString(entry.Slot, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid slot %q for %s", entry.Slot, entry.Label)
+		}
+
+		value, err := d.decode(ctx, cache, entry.Label, entry.Label, entry.Type, slot, entry.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", entry.Label, err)
+		}
+		toReturn = append(toReturn, value)
+	}
+
+	return toReturn, nil
+}
+
+// DumpStorage decodes every state variable of the contract using its verified storage layout.
+func (c *ContractResponse) DumpStorage(ctx context.Context, reader StorageReader, options ...StorageDecoderOption) ([]StorageValue, error) {
+	decoder, err := NewStorageDecoder(c.StorageLayout, reader, options...)
+	if err != nil {
+		return nil, err
+	}
+	return decoder.Dump(ctx)
+}
+
+// decode decodes the value of the given type stored at slot and offset.
+func (d *StorageDecoder) decode(ctx context.Context, cache *slotCache, label string, path string, typeID string, slot *big.Int, offset int) (StorageValue, error) {
+	storageType, ok := d.layout.Types[typeID]
+	if !ok {
+		return StorageValue{}, fmt.Errorf("unknown storage type %s", typeID)
+	}
+
+	toReturn := StorageValue{
+		Label:  label,
+		Path:   path,
+		Type:   storageType.Label,
+		Slot:   common.BigToHash(slot),
+		Offset: offset,
+	}
+
+	switch {
+	case storageType.Encoding == StorageEncodingMapping:
+		return toReturn, nil
+
+	case storageType.Encoding == StorageEncodingBytes:
+		return d.decodeBytes(ctx, cache, toReturn, storageType, slot)
+
+	case storageType.Encoding == StorageEncodingDynamicArray:
+		length, err := cache.read(ctx, slot)
+		if err != nil {
+			return StorageValue{}, err
+		}
+		toReturn.Length = new(big.Int).SetBytes(length.Bytes())
+
+		count := d.maxArrayLength
+		if toReturn.Length.IsInt64() && toReturn.Length.Int64() <= int64(count) {
+			count = int(toReturn.Length.Int64())
+		} else {
+			toReturn.Truncated = true
+		}
+
+		start := new(big.Int).SetBytes(keccak256(common.BigToHash(slot).Bytes()))
+		return d.decodeElements(ctx, cache, toReturn, storageType, start, count)
+
+	case storageType.IsStruct():
+		for _, member := range storageType.Members {
+			memberSlot, ok := new(big.Int).SetString(member.Slot, 10)
+			if !ok {
+				return StorageValue{}, fmt.Errorf("invalid slot %q for member %s", member.Slot, member.Label)
+			}
+
+			value, err := d.decode(ctx, cache, member.Label, path+"."+member.Label, member.Type, wrapSlot(memberSlot.Add(memberSlot, slot)), member.Offset)
+			if err != nil {
+				return StorageValue{}, err
+			}
+			toReturn.Members = append(toReturn.Members, value)
+		}
+		return toReturn, nil
+
+	case storageType.Base != "":
+		count := 0
+		if open := strings.LastIndex(storageType.Label, "["); open >= 0 {
+			length, err := strconv.Atoi(strings.TrimSuffix(storageType.Label[open+1:], "]"))
+			if err != nil {
+				return StorageValue{}, fmt.Errorf("invalid static array type %s", storageType.Label)
+			}
+			count = length
+		}
+		if count > d.maxArrayLength {
+			count = d.maxArrayLength
+			toReturn.Truncated = true
+		}
+		return d.decodeElements(ctx, cache, toReturn, storageType, slot, count)
+
+	default:
+		word, err := cache.read(ctx, slot)
+		if err != nil {
+			return StorageValue{}, err
+		}
+
+		size, err := storageType.Size()
+		if err != nil {
+			return StorageValue{}, err
+		}
+		if offset < 0 || offset+size > slotSize {
+			return StorageValue{}, fmt.Errorf("value of %s at offset %d exceeds the slot", label, offset)
+		}
+
+		// Values are right aligned within their slot, the offset counts from the least significant byte.
+		raw := word[slotSize-offset-size : slotSize-offset]
+		toReturn.Value = decodeStorageWord(storageType.Label, raw)
+		return toReturn, nil
+	}
+}
+
+// decodeElements decodes count elements of the array type whose data starts at start.
+func (d *StorageDecoder) decodeElements(ctx context.Context, cache *slotCache, toReturn StorageValue, storageType StorageType, start *big.Int, count int) (StorageValue, error) {
+	base, ok := d.layout.Types[storageType.Base]
+	if !ok {
+		return StorageValue{}, fmt.Errorf("unknown array base type %s", storageType.Base)
+	}
+
+	size, err := base.Size()
+	if err != nil {
+		return StorageValue{}, err
+	}
+
+	slots, err := base.Slots()
+	if err != nil {
+		return StorageValue{}, err
+	}
+
+	for i := 0; i < count; i++ {
+		slot := new(big.Int).Set(start)
+		offset := 0
+
+		// Elements of up to 16 bytes are packed several per slot, larger ones start a new slot.
+		if size <= slotSize/2 {
+			perSlot := slotSize / size
+			slot.Add(slot, big.NewInt(int64(i/perSlot)))
+			offset = (i % perSlot) * size
+		} else {
+			slot.Add(slot, big.NewInt(int64(i*slots)))
+		}
+
+		label := fmt.Sprintf("[%d]", i)
+		value, err := d.decode(ctx, cache, label, toReturn.Path+label, storageType.Base, wrapSlot(slot), offset)
+		if err != nil {
+			return StorageValue{}, err
+		}
+		toReturn.Members = append(toReturn.Members, value)
+	}
+
+	return toReturn, nil
+}
+
+// decodeBytes decodes a string or bytes value using the short and long storage layouts.
+func (d *StorageDecoder) decodeBytes(ctx context.Context, cache *slotCache, toReturn StorageValue, storageType StorageType, slot *big.Int) (StorageValue, error) {
+	word, err := cache.read(ctx, slot)
+	if err != nil {
+		return StorageValue{}, err
+	}
+
+	var data []byte
+	if word[slotSize-1]&1 == 0 {
+		// Short values of up to 31 bytes are stored in the slot itself together with length * 2.
+		length := int(word[slotSize-1]) / 2
+		if length >= slotSize {
+			return StorageValue{}, fmt.Errorf("invalid short %s length %d", storageType.Label, length)
+		}
+		toReturn.Length = big.NewInt(int64(length))
+		data = append([]byte{}, word[:length]...)
+	} else {
+		// Long values store length * 2 + 1 in the slot and the data starting at keccak256(slot).
+		length := new(big.Int).Rsh(new(big.Int).SetBytes(word.Bytes()), 1)
+		toReturn.Length = length
+
+		count := d.maxBytesLength
+		if length.IsInt64() && length.Int64() <= int64(count) {
+			count = int(length.Int64())
+		} else {
+			toReturn.Truncated = true
+		}
+
+		start := new(big.Int).SetBytes(keccak256(common.BigToHash(slot).Bytes()))
+		for i := 0; len(data) < count; i++ {
+			chunk, err := cache.read(ctx, wrapSlot(new(big.Int).Add(start, big.NewInt(int64(i)))))
+			if err != nil {
+				return StorageValue{}, err
+			}
+			data = append(data, chunk.Bytes()...)
+		}
+		data = data[:count]
+	}
+
+	if storageType.Label == "string" {
+		toReturn.Value = string(data)
+	} else {
+		toReturn.Value = data
+	}
+	return toReturn, nil
+}
+
+// decodeStorageWord decodes the raw bytes of a value type according to its Solidity type label.
+func decodeStorageWord(label string, raw []byte) any {
+	switch {
+	case label == "bool":
+		return new(big.Int).SetBytes(raw).Sign() != 0
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract ") || strings.HasPrefix(label, "interface "):
+		return common.BytesToAddress(raw)
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "enum "):
+		return new(big.Int).SetBytes(raw)
+	case strings.HasPrefix(label, "int"):
+		value := new(big.Int).SetBytes(raw)
+		if len(raw) > 0 && raw[0]&0x80 != 0 {
+			value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(raw)*8)))
+		}
+		return value
+	default:
+		// Fixed bytes, function pointers and user defined value types are returned raw.
+		return append([]byte{}, raw...)
+	}
+}
+
+// slotCache caches slot reads so packed values and repeated paths only read each slot once.
+type slotCache struct {
+	reader StorageReader
+	slots  map[common.Hash]common.Hash
+}
+
+// newSlotCache creates an empty slotCache reading through the given reader.
+func newSlotCache(reader StorageReader) *slotCache {
+	return &slotCache{
+		reader: reader,
+		slots:  make(map[common.Hash]common.Hash),
+	}
+}
+
+// read returns the value of the slot, reading it through the underlying reader the first time.
+func (c *slotCache) read(ctx context.Context, slot *big.Int) (common.Hash, error) {
+	key := common.BigToHash(slot)
+
+	if value, ok := c.slots[key]; ok {
+		return value, nil
+	}
+
+	value, err := c.reader.StorageAt(ctx, key)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to read storage slot %s: %w", key.Hex(), err)
+	}
+
+	c.slots[key] = value
+
+	return value, nil
+}
@@ -0,0 +1,209 @@
+package sourcify
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStorage(t *testing.T) StorageMap {
+	slot := func(n int64) common.Hash {
+		return common.BigToHash(big.NewInt(n))
+	}
+	dataStart := func(n int64, add int64) common.Hash {
+		start := new(big.Int).SetBytes(keccak256(slot(n).Bytes()))
+		return common.BigToHash(start.Add(start, big.NewInt(add)))
+	}
+
+	owner := common.HexToAddress("0x0000000000000000000000000000000000000abc")
+
+	// owner at offset 0 and paused at offset 20 share slot 1.
+	var packed common.Hash
+	copy(packed[12:], owner.Bytes())
+	packed[11] = 1
+
+	// Account{amount: 5, nonce: 7, active: true} in the first slot of the element.
+	var account common.Hash
+	account[31] = 5
+	account[15] = 7
+	account[7] = 1
+
+	// small = [1, 2, 3] packed into a single slot.
+	var small common.Hash
+	small[31], small[30], small[29] = 1, 2, 3
+
+	// name = "hello" stored in place with length * 2 in the lowest byte.
+	var name common.Hash
+	copy(name[:], "hello")
+	name[31] = 10
+
+	return StorageMap{
+		slot(0):         slot(1000),
+		slot(1):         packed,
+		slot(3):         slot(2),
+		dataStart(3, 0): slot(11),
+		dataStart(3, 1): slot(22),
+		slot(4):         slot(3),
+		dataStart(4, 0): small,
+		slot(5):         slot(1),
+		dataStart(5, 0): account,
+		dataStart(5, 1): common.BytesToHash(owner.Bytes()),
+		slot(7):         slot(70),
+		slot(9):         slot(90),
+		slot(11):        name,
+	}
+}
+
+func TestStorageDecoder_Dump(t *testing.T) {
+	decoder, err := NewStorageDecoder(loadTestStorageLayout(t), testStorage(t))
+	require.NoError(t, err)
+
+	values, err := decoder.Dump(context.Background())
+	require.NoError(t, err)
+	require.Len(t, values, 11)
+
+	byLabel := make(map[string]StorageValue)
+	for _, value := range values {
+		byLabel[value.Label] = value
+	}
+
+	assert.Equal(t, big.NewInt(1000), byLabel["total"].Value)
+	assert.Equal(t, common.HexToAddress("0x0000000000000000000000000000000000000abc"), byLabel["owner"].Value)
+	assert.Equal(t, true, byLabel["paused"].Value)
+	assert.Nil(t, byLabel["balances"].Value)
+	assert.Empty(t, byLabel["balances"].Members)
+
+	arr := byLabel["arr"]
+	assert.Equal(t, big.NewInt(2), arr.Length)
+	require.Len(t, arr.Members, 2)
+	assert.Equal(t, big.NewInt(22), arr.Members[1].Value)
+	assert.Equal(t, "arr[1]", arr.Members[1].Path)
+
+	small := byLabel["small"]
+	require.Len(t, small.Members, 3)
+	assert.Equal(t, big.NewInt(3), small.Members[2].Value)
+	assert.Equal(t, 2, small.Members[2].Offset)
+
+	accounts := byLabel["accounts"]
+	require.Len(t, accounts.Members, 1)
+	amount, ok := accounts.Members[0].Member("amount")
+	require.True(t, ok)
+	assert.Equal(t, big.NewInt(5), amount.Value)
+	nonce, _ := accounts.Members[0].Member("nonce")
+	assert.Equal(t, big.NewInt(7), nonce.Value)
+	active, _ := accounts.Members[0].Member("active")
+	assert.Equal(t, true, active.Value)
+	accountOwner, _ := accounts.Members[0].Member("owner")
+	assert.Equal(t, "accounts[0].owner", accountOwner.Path)
+	assert.Equal(t, common.HexToAddress("0x0000000000000000000000000000000000000abc"), accountOwner.Value)
+
+	fixed := byLabel["fixedArr"]
+	require.Len(t, fixed.Members, 3)
+	assert.Equal(t, big.NewInt(70), fixed.Members[0].Value)
+	assert.Zero(t, fixed.Members[1].Value.(*big.Int).Sign())
+	assert.Equal(t, big.NewInt(90), fixed.Members[2].Value)
+
+	assert.Equal(t, "hello", byLabel["name"].Value)
+	assert.Equal(t, big.NewInt(5), byLabel["name"].Length)
+}
+
+func TestStorageDecoder_Decode(t *testing.T) {
+	decoder, err := NewStorageDecoder(loadTestStorageLayout(t), testStorage(t), WithMaxArrayLength(1))
+	require.NoError(t, err)
+
+	value, err := decoder.Decode(context.Background(), "accounts[0].nonce")
+	require.NoError(t, err)
+	assert.Equal(t, "nonce", value.Label)
+	assert.Equal(t, big.NewInt(7), value.Value)
+
+	value, err = decoder.Decode(context.Background(), "arr")
+	require.NoError(t, err)
+	assert.True(t, value.Truncated)
+	assert.Len(t, value.Members, 1)
+
+	// Static arrays are capped as well, without reading the slots of the skipped elements.
+	reads := 0
+	counting := StorageReaderFunc(func(ctx context.Context, slot common.Hash) (common.Hash, error) {
+		reads++
+		return testStorage(t).StorageAt(ctx, slot)
+	})
+	decoder, err = NewStorageDecoder(loadTestStorageLayout(t), counting, WithMaxArrayLength(1))
+	require.NoError(t, err)
+
+	value, err = decoder.Decode(context.Background(), "fixedArr")
+	require.NoError(t, err)
+	assert.True(t, value.Truncated)
+	require.Len(t, value.Members, 1)
+	assert.Equal(t, big.NewInt(70), value.Members[0].Value)
+	assert.Equal(t, 1, reads)
+}
+
+func TestStorageDecoder_InvalidLimits(t *testing.T) {
+	_, err := NewStorageDecoder(loadTestStorageLayout(t), StorageMap{}, WithMaxBytesLength(-1))
+	assert.ErrorIs(t, err, ErrInvalidDecoderLimit)
+
+	_, err = NewStorageDecoder(loadTestStorageLayout(t), StorageMap{}, WithMaxArrayLength(-1))
+	assert.ErrorIs(t, err, ErrInvalidDecoderLimit)
+
+	decoder, err := NewStorageDecoder(loadTestStorageLayout(t), testStorage(t), WithMaxArrayLength(0), WithMaxBytesLength(0))
+	require.NoError(t, err)
+	value, err := decoder.Decode(context.Background(), "fixedArr")
+	require.NoError(t, err)
+	assert.True(t, value.Truncated)
+	assert.Empty(t, value.Members)
+}
+
+func TestStorageDecoder_LongString(t *testing.T) {
+	text := strings.Repeat("sourcify", 9)
+
+	var header common.Hash
+	header[31] = byte(len(text)*2 + 1)
+
+	storage := StorageMap{common.BigToHash(big.NewInt(11)): header}
+	start := new(big.Int).SetBytes(keccak256(common.BigToHash(big.NewInt(11)).Bytes()))
+	for i := 0; i*32 < len(text); i++ {
+		chunk := []byte(text[i*32 : min((i+1)*32, len(text))])
+		storage[common.BigToHash(new(big.Int).Add(start, big.NewInt(int64(i))))] = common.BytesToHash(common.RightPadBytes(chunk, 32))
+	}
+
+	decoder, err := NewStorageDecoder(loadTestStorageLayout(t), storage)
+	require.NoError(t, err)
+
+	value, err := decoder.Decode(context.Background(), "name")
+	require.NoError(t, err)
+	assert.Equal(t, text, value.Value)
+
+	decoder, err = NewStorageDecoder(loadTestStorageLayout(t), storage, WithMaxBytesLength(8))
+	require.NoError(t, err)
+
+	value, err = decoder.Decode(context.Background(), "name")
+	require.NoError(t, err)
+	assert.Equal(t, "sourcify", value.Value)
+	assert.True(t, value.Truncated)
+}
+
+func TestStorageDecoder_ReaderError(t *testing.T) {
+	failing := StorageReaderFunc(func(ctx context.Context, slot common.Hash) (common.Hash, error) {
+		return common.Hash{}, errors.New("rpc unavailable")
+	})
+
+	contract := &ContractResponse{StorageLayout: loadTestStorageLayout(t)}
+	_, err := contract.DumpStorage(context.Background(), failing)
+	assert.ErrorContains(t, err, "rpc unavailable")
+
+	_, err = (&ContractResponse{}).DumpStorage(context.Background(), failing)
+	assert.ErrorIs(t, err, ErrStorageLayoutMissing)
+}
+
+func TestDecodeStorageWord(t *testing.T) {
+	assert.Equal(t, big.NewInt(-1), decodeStorageWord("int8", []byte{0xff}))
+	assert.Equal(t, big.NewInt(127), decodeStorageWord("int8", []byte{0x7f}))
+	assert.Equal(t, big.NewInt(2), decodeStorageWord("enum Bank.State", []byte{0x02}))
+	assert.Equal(t, []byte{0xde, 0xad}, decodeStorageWord("bytes2", []byte{0xde, 0xad}))
+}
@@ -0,0 +1,169 @@
+package sourcify
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorageLayout mirrors the solc storage layout output of the following contract:
+//
+//	contract Bank {
+//	    struct Account { uint128 amount; uint64 nonce; bool active; address owner; }
+//	    uint256 total;
+//	    address owner;
+//	    bool paused;
+//	    mapping(address => Account) balances;
+//	    uint256[] arr;
+//	    uint8[] small;
+//	    Account[] accounts;
+//	    mapping(string => uint256) names;
+//	    uint256[3] fixedArr;
+//	    mapping(uint256 => mapping(address => uint256)) nested;
+//	    string name;
+//	}
+const testStorageLayout = `{
+  "storage": [
+    {"astId": 1, "contract": "Bank.sol:Bank", "label": "total", "offset": 0, "slot": "0", "type": "t_uint256"},
+    {"astId": 2, "contract": "Bank.sol:Bank", "label": "owner", "offset": 0, "slot": "1", "type": "t_address"},
+    {"astId": 3, "contract": "Bank.sol:Bank", "label": "paused", "offset": 20, "slot": "1", "type": "t_bool"},
+    {"astId": 4, "contract": "Bank.sol:Bank", "label": "balances", "offset": 0, "slot": "2", "type": "t_mapping(t_address,t_struct(Account)10_storage)"},
+    {"astId": 5, "contract": "Bank.sol:Bank", "label": "arr", "offset": 0, "slot": "3", "type": "t_array(t_uint256)dyn_storage"},
+    {"astId": 6, "contract": "Bank.sol:Bank", "label": "small", "offset": 0, "slot": "4", "type": "t_array(t_uint8)dyn_storage"},
+    {"astId": 7, "contract": "Bank.sol:Bank", "label": "accounts", "offset": 0, "slot": "5", "type": "t_array(t_struct(Account)10_storage)dyn_storage"},
+    {"astId": 8, "contract": "Bank.sol:Bank", "label": "names", "offset": 0, "slot": "6", "type": "t_mapping(t_string_memory_ptr,t_uint256)"},
+    {"astId": 9, "contract": "Bank.sol:Bank", "label": "fixedArr", "offset": 0, "slot": "7", "type": "t_array(t_uint256)3_storage"},
+    {"astId": 11, "contract": "Bank.sol:Bank", "label": "nested", "offset": 0, "slot": "10", "type": "t_mapping(t_uint256,t_mapping(t_address,t_uint256))"},
+    {"astId": 12, "contract": "Bank.sol:Bank", "label": "name", "offset": 0, "slot": "11", "type": "t_string_storage"}
+  ],
+  "types": {
+    "t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+    "t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+    "t_uint8": {"encoding": "inplace", "label": "uint8", "numberOfBytes": "1"},
+    "t_uint64": {"encoding": "inplace", "label": "uint64", "numberOfBytes": "8"},
+    "t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+    "t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+    "t_string_memory_ptr": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+    "t_string_storage": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+    "t_array(t_uint256)dyn_storage": {"base": "t_uint256", "encoding": "dynamic_array", "label": "uint256[]", "numberOfBytes": "32"},
+    "t_array(t_uint8)dyn_storage": {"base": "t_uint8", "encoding": "dynamic_array", "label": "uint8[]", "numberOfBytes": "32"},
+    "t_array(t_struct(Account)10_storage)dyn_storage": {"base": "t_struct(Account)10_storage", "encoding": "dynamic_array", "label": "struct Bank.Account[]", "numberOfBytes": "32"},
+    "t_array(t_uint256)3_storage": {"base": "t_uint256", "encoding": "inplace", "label": "uint256[3]", "numberOfBytes": "96"},
+    "t_mapping(t_address,t_struct(Account)10_storage)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => struct Bank.Account)", "numberOfBytes": "32", "value": "t_struct(Account)10_storage"},
+    "t_mapping(t_string_memory_ptr,t_uint256)": {"encoding": "mapping", "key": "t_string_memory_ptr", "label": "mapping(string => uint256)", "numberOfBytes": "32", "value": "t_uint256"},
+    "t_mapping(t_address,t_uint256)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => uint256)", "numberOfBytes": "32", "value": "t_uint256"},
+    "t_mapping(t_uint256,t_mapping(t_address,t_uint256))": {"encoding": "mapping", "key": "t_uint256", "label": "mapping(uint256 => mapping(address => uint256))", "numberOfBytes": "32", "value": "t_mapping(t_address,t_uint256)"},
+    "t_struct(Account)10_storage": {
+      "encoding": "inplace",
+      "label": "struct Bank.Account",
+      "numberOfBytes": "64",
+      "members": [
+        {"astId": 13, "contract": "Bank.sol:Bank", "label": "amount", "offset": 0, "slot": "0", "type": "t_uint128"},
+        {"astId": 14, "contract": "Bank.sol:Bank", "label": "nonce", "offset": 16, "slot": "0", "type": "t_uint64"},
+        {"astId": 15, "contract": "Bank.sol:Bank", "label": "active", "offset": 24, "slot": "0", "type": "t_bool"},
+        {"astId": 16, "contract": "Bank.sol:Bank", "label": "owner", "offset": 0, "slot": "1", "type": "t_address"}
+      ]
+    }
+  }
+}`
+
+func loadTestStorageLayout(t *testing.T) *StorageLayout {
+	var layout StorageLayout
+	require.NoError(t, json.Unmarshal([]byte(testStorageLayout), &layout))
+	return &layout
+}
+
+func TestStorageType_Unmarshal(t *testing.T) {
+	layout := loadTestStorageLayout(t)
+
+	mapping := layout.Types["t_mapping(t_address,t_struct(Account)10_storage)"]
+	assert.Equal(t, "t_address", mapping.Key)
+	assert.Equal(t, "t_struct(Account)10_storage", mapping.Value)
+
+	account := layout.Types["t_struct(Account)10_storage"]
+	assert.True(t, account.IsStruct())
+	require.Len(t, account.Members, 4)
+	slots, err := account.Slots()
+	require.NoError(t, err)
+	assert.Equal(t, 2, slots)
+
+	assert.Equal(t, "t_uint256", layout.Types["t_array(t_uint256)dyn_storage"].Base)
+}
+
+func TestStorageLayout_Locate(t *testing.T) {
+	layout := loadTestStorageLayout(t)
+
+	holder := "0x0000000000000000000000000000000000000abc"
+	balancesSlot := common.BytesToHash(keccak256(common.LeftPadBytes(common.HexToAddress(holder).Bytes(), 32), common.BigToHash(big.NewInt(2)).Bytes()))
+	arrStart := common.HexToHash("0xc2575a0e9e593c00f959f8c92f12db2869c3395a3b0502d05e2516446f71f85b")
+	smallStart := common.BytesToHash(keccak256(common.BigToHash(big.NewInt(4)).Bytes()))
+	accountsStart := common.BytesToHash(keccak256(common.BigToHash(big.NewInt(5)).Bytes()))
+	nestedOuter := keccak256(common.BigToHash(big.NewInt(7)).Bytes(), common.BigToHash(big.NewInt(10)).Bytes())
+	nestedSlot := common.BytesToHash(keccak256(common.LeftPadBytes(common.HexToAddress(holder).Bytes(), 32), nestedOuter))
+
+	add := func(hash common.Hash, n int64) common.Hash {
+		return common.BigToHash(new(big.Int).Add(hash.Big(), big.NewInt(n)))
+	}
+
+	tests := []struct {
+		path   string
+		slot   common.Hash
+		offset int
+		typeID string
+	}{
+		{path: "total", slot: common.BigToHash(big.NewInt(0)), typeID: "t_uint256"},
+		{path: "paused", slot: common.BigToHash(big.NewInt(1)), offset: 20, typeID: "t_bool"},
+		{path: "balances[" + holder + "]", slot: balancesSlot, typeID: "t_struct(Account)10_storage"},
+		{path: "balances[" + holder + "].amount", slot: balancesSlot, typeID: "t_uint128"},
+		{path: "balances[" + holder + "].active", slot: balancesSlot, offset: 24, typeID: "t_bool"},
+		{path: "balances[" + holder + "].owner", slot: add(balancesSlot, 1), typeID: "t_address"},
+		{path: "arr", slot: common.BigToHash(big.NewInt(3)), typeID: "t_array(t_uint256)dyn_storage"},
+		{path: "arr[3]", slot: add(arrStart, 3), typeID: "t_uint256"},
+		{path: "small[33]", slot: add(smallStart, 1), offset: 1, typeID: "t_uint8"},
+		{path: "accounts[2].owner", slot: add(accountsStart, 5), typeID: "t_address"},
+		{path: `names["alice"]`, slot: common.BytesToHash(keccak256([]byte("alice"), common.BigToHash(big.NewInt(6)).Bytes())), typeID: "t_uint256"},
+		{path: "fixedArr[2]", slot: common.BigToHash(big.NewInt(9)), typeID: "t_uint256"},
+		{path: "nested[7][" + holder + "]", slot: nestedSlot, typeID: "t_uint256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			location, err := layout.Locate(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.slot, location.Slot)
+			assert.Equal(t, tt.offset, location.Offset)
+			assert.Equal(t, tt.typeID, location.TypeID)
+			assert.Equal(t, layout.Types[tt.typeID], location.Type)
+		})
+	}
+}
+
+func TestStorageLayout_LocateErrors(t *testing.T) {
+	layout := loadTestStorageLayout(t)
+
+	tests := []struct {
+		path string
+		err  error
+	}{
+		{path: "missing", err: ErrStorageVariableNotFound},
+		{path: "balances[0x0000000000000000000000000000000000000abc].missing", err: ErrStorageVariableNotFound},
+		{path: "total.amount", err: ErrStoragePathInvalid},
+		{path: "total[1]", err: ErrStoragePathInvalid},
+		{path: "fixedArr[3]", err: ErrStoragePathInvalid},
+		{path: "balances[nope]", err: ErrStoragePathInvalid},
+		{path: "arr[", err: ErrStoragePathInvalid},
+		{path: "[1]", err: ErrStoragePathInvalid},
+		{path: "name[0]", err: ErrStoragePathInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := layout.Locate(tt.path)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -2,9 +2,11 @@ package sourcify
 
 // ABIParameter represents a parameter in an ABI function or event
 type ABIParameter struct {
-	InternalType string `json:"internalType"`
-	Name         string `json:"name"`
-	Type         string `json:"type"`
+	InternalType string         `json:"internalType"`
+	Name         string         `json:"name"`
+	Type         string         `json:"type"`
+	Components   []ABIParameter `json:"components,omitempty"` // Members of tuple types.
+	Indexed      bool           `json:"indexed,omitempty"`    // Whether an event parameter is indexed.
 }
 
 // ABIEntry represents a function, event, or error in an ABI
@@ -26,14 +28,36 @@ type BytecodeHash struct {
 
 // Optimizer represents compiler optimizer settings
 type Optimizer struct {
-	Enabled bool  `json:"enabled"`
-	Runs    int64 `json:"runs"`
+	Enabled bool              `json:"enabled"`
+	Runs    int64             `json:"runs"`
+	Details *OptimizerDetails `json:"details,omitempty"` // Fine grained optimizer switches, if set explicitly
+}
+
+// OptimizerDetails represents the individual optimizer components that can be switched on or off.
+// Nil values are left to the compiler defaults.
+type OptimizerDetails struct {
+	Peephole                               *bool       `json:"peephole,omitempty"`
+	Inliner                                *bool       `json:"inliner,omitempty"`
+	JumpdestRemover                        *bool       `json:"jumpdestRemover,omitempty"`
+	OrderLiterals                          *bool       `json:"orderLiterals,omitempty"`
+	Deduplicate                            *bool       `json:"deduplicate,omitempty"`
+	Cse                                    *bool       `json:"cse,omitempty"`
+	ConstantOptimizer                      *bool       `json:"constantOptimizer,omitempty"`
+	SimpleCounterForLoopUncheckedIncrement *bool       `json:"simpleCounterForLoopUncheckedIncrement,omitempty"`
+	Yul                                    *bool       `json:"yul,omitempty"`
+	YulDetails                             *YulDetails `json:"yulDetails,omitempty"`
+}
+
+// YulDetails represents the settings of the Yul optimizer.
+type YulDetails struct {
+	StackAllocation *bool  `json:"stackAllocation,omitempty"`
+	OptimizerSteps  string `json:"optimizerSteps,omitempty"`
 }
 
 // EVMVersion represents EVM version settings used in compiler settings
 type EVMVersion struct {
 	EvmVersion string        `json:"evmVersion"`
-	Libraries  struct{}      `json:"libraries"`
+	Libraries  Libraries     `json:"libraries"`
 	Metadata   BytecodeHash  `json:"metadata"`
 	Optimizer  Optimizer     `json:"optimizer"`
 	Remappings []interface{} `json:"remappings"`
@@ -57,21 +81,24 @@ type Transformation struct {
 type TransformationValues struct {
 	CborAuxdata          map[string]string `json:"cborAuxdata,omitempty"`
 	ConstructorArguments string            `json:"constructorArguments,omitempty"`
+	Libraries            Libraries         `json:"libraries,omitempty"`
+	Immutables           map[string]string `json:"immutables,omitempty"`
 }
 
-// Libraries defines contract libraries mapping
+// Libraries defines contract libraries mapping, keyed by the fully qualified library name (file:Library)
+// or by the bare library name for contracts compiled with older compilers.
 type Libraries map[string]string
 
 // Bytecode represents a contract's bytecode information
 type Bytecode struct {
 	CborAuxdata          map[string]CborAuxData `json:"cborAuxdata"`
-	LinkReferences       map[string]interface{} `json:"linkReferences"`
+	LinkReferences       LinkReferences         `json:"linkReferences"`
 	OnchainBytecode      string                 `json:"onchainBytecode"`
 	RecompiledBytecode   string                 `json:"recompiledBytecode"`
 	SourceMap            string                 `json:"sourceMap"`
 	TransformationValues TransformationValues   `json:"transformationValues"`
 	Transformations      []Transformation       `json:"transformations"`
-	ImmutableReferences  map[string]interface{} `json:"immutableReferences,omitempty"`
+	ImmutableReferences  ImmutableReferences    `json:"immutableReferences,omitempty"`
 }
 
 // UintType represents a uint type definition
@@ -89,9 +116,13 @@ type StorageLayout struct {
 
 // StorageType represents a type definition in the storage layout
 type StorageType struct {
-	Label         string `json:"label"`
-	Encoding      string `json:"encoding"`
-	NumberOfBytes string `json:"numberOfBytes"`
+	Label         string         `json:"label"`
+	Encoding      string         `json:"encoding"`
+	NumberOfBytes string         `json:"numberOfBytes"`
+	Key           string         `json:"key,omitempty"`     // Type of the mapping key, for mappings
+	Value         string         `json:"value,omitempty"`   // Type of the mapping value, for mappings
+	Base          string         `json:"base,omitempty"`    // Type of the array elements, for static and dynamic arrays
+	Members       []StorageEntry `json:"members,omitempty"` // Members of the struct, for structs
 }
 
 // StorageEntry represents a storage variable in the contract
@@ -139,17 +170,17 @@ type UserDoc struct {
 
 // EVMBytecode represents the EVM bytecode information
 type EVMBytecode struct {
-	LinkReferences map[string]interface{} `json:"linkReferences"`
-	Object         string                 `json:"object"`
-	SourceMap      string                 `json:"sourceMap"`
+	LinkReferences LinkReferences `json:"linkReferences"`
+	Object         string         `json:"object"`
+	SourceMap      string         `json:"sourceMap"`
 }
 
 // EVMDeployedBytecode represents the EVM deployed bytecode information
 type EVMDeployedBytecode struct {
-	ImmutableReferences map[string]interface{} `json:"immutableReferences"`
-	LinkReferences      map[string]interface{} `json:"linkReferences"`
-	Object              string                 `json:"object"`
-	SourceMap           string                 `json:"sourceMap"`
+	ImmutableReferences ImmutableReferences `json:"immutableReferences"`
+	LinkReferences      LinkReferences      `json:"linkReferences"`
+	Object              string              `json:"object"`
+	SourceMap           string              `json:"sourceMap"`
 }
 
 // EVM represents EVM-related information
@@ -160,19 +191,27 @@ type EVM struct {
 
 // MetadataDetail provides additional metadata.
 type MetadataDetail struct {
-	BytecodeHash string `json:"bytecodeHash"` // Hash of the bytecode
+	BytecodeHash      string `json:"bytecodeHash,omitempty"`      // Hash of the bytecode
+	UseLiteralContent bool   `json:"useLiteralContent,omitempty"` // Whether the metadata embeds the source contents instead of URLs
+	AppendCBOR        *bool  `json:"appendCBOR,omitempty"`        // Whether the CBOR metadata is appended to the bytecode, defaults to true
 }
 
 // Settings includes details about the compiler settings used.
 type Settings struct {
-	CompilationTarget CompilationTarget `json:"compilationTarget"` // CompilationTarget represents the compilation target details.
-	EvmVersion        string            `json:"evmVersion"`        // EVM version used
-	Libraries         Libraries         `json:"libraries"`         // Libraries used in the source code
-	Metadata          MetadataDetail    `json:"metadata"`          // MetadataDetail represents additional metadata.
-	Optimizer         Optimizer         `json:"optimizer"`         // Optimizer represents the compiler optimization details.
-	Remappings        []string          `json:"remappings"`        // Remappings used in the source code
+	CompilationTarget CompilationTarget `json:"compilationTarget,omitempty"` // CompilationTarget represents the compilation target details.
+	EvmVersion        string            `json:"evmVersion,omitempty"`        // EVM version used
+	Libraries         Libraries         `json:"libraries"`                   // Libraries used in the source code
+	Metadata          MetadataDetail    `json:"metadata"`                    // MetadataDetail represents additional metadata.
+	Optimizer         Optimizer         `json:"optimizer"`                   // Optimizer represents the compiler optimization details.
+	OutputSelection   OutputSelection   `json:"outputSelection,omitempty"`   // Outputs requested from the compiler, only present in the standard JSON input.
+	Remappings        []string          `json:"remappings"`                  // Remappings used in the source code
+	ViaIR             bool              `json:"viaIR,omitempty"`             // Whether the code was generated through the Yul IR pipeline
 }
 
+// OutputSelection maps file names and contract names to the compiler outputs requested for them.
+// The wildcard `*` selects all files or contracts and the empty contract name selects file level outputs.
+type OutputSelection map[string]map[string][]string
+
 // CompilationTarget holds the details of the compilation target.
 type CompilationTarget map[string]string
 
@@ -200,6 +239,7 @@ type Output struct {
 type MetadataSource struct {
 	Keccak256 string   `json:"keccak256,omitempty"`
 	Urls      []string `json:"urls,omitempty"`
+	Content   string   `json:"content,omitempty"` // Literal source content, present when metadata.useLiteralContent is set
 	License   string   `json:"license,omitempty"`
 }
 
@@ -226,9 +266,9 @@ type Compilation struct {
 
 // ProxyResolution contains information about proxy contract resolution
 type ProxyResolution struct {
-	Implementations []string `json:"implementations"`
-	IsProxy         bool     `json:"isProxy"`
-	ProxyType       string   `json:"proxyType,omitempty"`
+	Implementations []ProxyImplementation `json:"implementations"`
+	IsProxy         bool                  `json:"isProxy"`
+	ProxyType       string                `json:"proxyType,omitempty"`
 }
 
 // StdJSONInput represents the standard JSON input format for the compiler
@@ -272,7 +312,8 @@ type Sources map[string]SourceContent
 
 // OutputDetail holds information about the output parameters of the functions.
 type OutputDetail struct {
-	InternalType string `json:"internalType"` // Internal type of the parameter
-	Name         string `json:"name"`         // Name of the parameter
-	Type         string `json:"type"`         // Type of the parameter
+	InternalType string         `json:"internalType"`         // Internal type of the parameter
+	Name         string         `json:"name"`                 // Name of the parameter
+	Type         string         `json:"type"`                 // Type of the parameter
+	Components   []OutputDetail `json:"components,omitempty"` // Members of tuple types
 }
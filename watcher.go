@@ -0,0 +1,338 @@
+package sourcify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	// defaultWatchInterval is the time between two polls of the watched chains.
+	defaultWatchInterval = time.Minute
+
+	// defaultWatchPageSize is the number of contracts listed per request while catching up.
+	defaultWatchPageSize = 200
+)
+
+var (
+	// ErrWatcherStarted is returned by Run when the watcher has already been run.
+	ErrWatcherStarted = errors.New("watcher already started")
+)
+
+// WatchEvent represents a contract newly verified on a watched chain.
+type WatchEvent struct {
+	ChainID  int
+	Contract ContractBaseResponse
+}
+
+// WatchHandler handles a newly verified contract. Returning an error stops the delivery of the events of the
+// chain until the next poll, which delivers the contract again.
+type WatchHandler func(ctx context.Context, event WatchEvent) error
+
+// CheckpointStore persists the match ID of the last contract delivered for each chain, so a restarted Watcher
+// neither replays nor misses contracts.
+type CheckpointStore interface {
+	// Checkpoint returns the last delivered match ID of the chain, or an empty string when there is none.
+	Checkpoint(chainId int) (string, error)
+	// SetCheckpoint records the last delivered match ID of the chain.
+	SetCheckpoint(chainId int, matchId string) error
+}
+
+// MemoryCheckpointStore keeps checkpoints in memory, for watchers that do not need to survive restarts.
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[int]string
+}
+
+// NewMemoryCheckpointStore creates an empty in-memory checkpoint store.
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[int]string)}
+}
+
+// Checkpoint returns the last delivered match ID of the chain.
+func (s *MemoryCheckpointStore) Checkpoint(chainId int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoints[chainId], nil
+}
+
+// SetCheckpoint records the last delivered match ID of the chain.
+func (s *MemoryCheckpointStore) SetCheckpoint(chainId int, matchId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[chainId] = matchId
+	return nil
+}
+
+// FileCheckpointStore keeps checkpoints in a JSON file mapping chain IDs to match IDs, rewritten atomically on
+// every change.
+type FileCheckpointStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileCheckpointStore creates a checkpoint store persisted at path. The file is created on the first checkpoint.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+// Checkpoint returns the last delivered match ID of the chain.
+func (s *FileCheckpointStore) Checkpoint(chainId int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoints, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	return checkpoints[strconv.Itoa(chainId)], nil
+}
+
+// SetCheckpoint records the last delivered match ID of the chain.
+func (s *FileCheckpointStore) SetCheckpoint(chainId int, matchId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoints, err := s.load()
+	if err != nil {
+		return err
+	}
+	checkpoints[strconv.Itoa(chainId)] = matchId
+
+	data, err := json.MarshalIndent(checkpoints, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoints: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path+".tmp", append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	return os.Rename(s.path+".tmp", s.path)
+}
+
+// load reads the checkpoints keyed by chain ID, empty when the file does not exist yet.
+func (s *FileCheckpointStore) load() (map[string]string, error) {
+	toReturn := make(map[string]string)
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return toReturn, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &toReturn); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoints: %w", err)
+	}
+	return toReturn, nil
+}
+
+// WatcherOption configures a Watcher.
+type WatcherOption func(*Watcher)
+
+// WithWatchInterval sets the time between two polls of the watched chains.
+func WithWatchInterval(interval time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.interval = interval
+	}
+}
+
+// WithWatchPageSize sets the number of contracts listed per request while catching up with the newest contract.
+func WithWatchPageSize(pageSize int) WatcherOption {
+	return func(w *Watcher) {
+		w.pageSize = pageSize
+	}
+}
+
+// WithWatchHandler delivers the events to handler instead of the Events channel.
+func WithWatchHandler(handler WatchHandler) WatcherOption {
+	return func(w *Watcher) {
+		w.handler = handler
+	}
+}
+
+// WithWatchCheckpoints persists the checkpoints of the watcher in store. By default, checkpoints are kept in memory.
+func WithWatchCheckpoints(store CheckpointStore) WatcherOption {
+	return func(w *Watcher) {
+		w.checkpoints = store
+	}
+}
+
+// WithWatchErrorHandler reports the errors of each poll to handler. By default, they are dropped and the chain is
+// polled again after the interval.
+func WithWatchErrorHandler(handler func(chainId int, err error)) WatcherOption {
+	return func(w *Watcher) {
+		w.errorHandler = handler
+	}
+}
+
+// Watcher periodically polls GetContractsByChainId, newest first, for the watched chains and delivers an event for
+// every contract verified since the last delivered one, oldest first. The match ID of each delivered contract is
+// saved to the CheckpointStore, so a restarted watcher resumes where it stopped. A chain without checkpoint starts
+// from its newest contract, without delivering the contracts verified before.
+type Watcher struct {
+	client       *Client
+	chainIds     []int
+	interval     time.Duration
+	pageSize     int
+	handler      WatchHandler
+	checkpoints  CheckpointStore
+	errorHandler func(chainId int, err error)
+	events       chan WatchEvent
+	started      atomic.Bool
+}
+
+// NewWatcher creates a watcher of the chains. Events are delivered on the Events channel, unless a handler is set
+// with WithWatchHandler.
+func NewWatcher(client *Client, chainIds []int, options ...WatcherOption) *Watcher {
+	toReturn := &Watcher{
+		client:      client,
+		chainIds:    chainIds,
+		interval:    defaultWatchInterval,
+		pageSize:    defaultWatchPageSize,
+		checkpoints: NewMemoryCheckpointStore(),
+	}
+	for _, option := range options {
+		option(toReturn)
+	}
+	if toReturn.handler == nil {
+		toReturn.events = make(chan WatchEvent)
+	}
+	return toReturn
+}
+
+// Events returns the channel the events are delivered on, closed when Run returns. A contract is checkpointed once
+// its event is received. Events returns nil when a handler is set with WithWatchHandler.
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Run polls the chains immediately and then at every interval, until the context is done. A watcher runs once,
+// since the Events channel is closed when Run returns; later calls return ErrWatcherStarted.
+func (w *Watcher) Run(ctx context.Context) error {
+	if !w.started.CompareAndSwap(false, true) {
+		return ErrWatcherStarted
+	}
+
+	if w.events != nil {
+		defer close(w.events)
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		_ = w.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll polls every chain once and delivers the contracts verified since their checkpoint. The errors of the
+// chains are reported to the error handler and returned joined.
+func (w *Watcher) Poll(ctx context.Context) error {
+	var errs []error
+	for _, chainId := range w.chainIds {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := w.pollChain(ctx, chainId); err != nil {
+			if w.errorHandler != nil {
+				w.errorHandler(chainId, err)
+			}
+			errs = append(errs, fmt.Errorf("chain %d: %w", chainId, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pollChain lists the contracts of the chain newer than its checkpoint and delivers them oldest first.
+func (w *Watcher) pollChain(ctx context.Context, chainId int) error {
+	checkpoint, err := w.checkpoints.Checkpoint(chainId)
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	var last int64
+	if checkpoint != "" {
+		if last, err = strconv.ParseInt(checkpoint, 10, 64); err != nil {
+			return fmt.Errorf("invalid checkpoint %q: %w", checkpoint, err)
+		}
+	}
+
+	var fresh []ContractBaseResponse
+	afterMatchId := ""
+	for {
+		page, err := GetContractsByChainId(w.client, chainId, "desc", afterMatchId, w.pageSize)
+		if err != nil {
+			return err
+		}
+
+		// Without checkpoint, the newest contract becomes the starting point, or zero on a chain without contracts.
+		if checkpoint == "" {
+			if len(page.Results) == 0 {
+				return w.checkpoints.SetCheckpoint(chainId, "0")
+			}
+			return w.checkpoints.SetCheckpoint(chainId, page.Results[0].MatchID)
+		}
+
+		reached := false
+		for _, contract := range page.Results {
+			matchId, err := strconv.ParseInt(contract.MatchID, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid match ID %q: %w", contract.MatchID, err)
+			}
+			if matchId <= last {
+				reached = true
+				break
+			}
+			fresh = append(fresh, contract)
+		}
+
+		if reached || len(page.Results) < w.pageSize {
+			break
+		}
+		afterMatchId = page.Results[len(page.Results)-1].MatchID
+	}
+
+	for i := len(fresh) - 1; i >= 0; i-- {
+		if err := w.deliver(ctx, WatchEvent{ChainID: chainId, Contract: fresh[i]}); err != nil {
+			return err
+		}
+		if err := w.checkpoints.SetCheckpoint(chainId, fresh[i].MatchID); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+	}
+	return nil
+}
+
+// deliver hands the event to the handler or sends it on the events channel.
+func (w *Watcher) deliver(ctx context.Context, event WatchEvent) error {
+	if w.handler != nil {
+		return w.handler(ctx, event)
+	}
+
+	select {
+	case w.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
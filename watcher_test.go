@@ -0,0 +1,146 @@
+package sourcify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectEvents returns a handler appending the events to events.
+func collectEvents(events *[]WatchEvent) WatchHandler {
+	return func(ctx context.Context, event WatchEvent) error {
+		*events = append(*events, event)
+		return nil
+	}
+}
+
+func matchIds(events []WatchEvent) []string {
+	toReturn := make([]string, 0, len(events))
+	for _, event := range events {
+		toReturn = append(toReturn, event.Contract.MatchID)
+	}
+	return toReturn
+}
+
+func TestWatcherPoll(t *testing.T) {
+	server := newMirrorTestServer(t)
+	for matchId := 1; matchId <= 2; matchId++ {
+		server.add(newMirrorTestContract(t, matchId, fmt.Sprintf("contract C%d {}", matchId)))
+	}
+
+	var events []WatchEvent
+	checkpoints := NewMemoryCheckpointStore()
+	watcher := NewWatcher(NewClient(WithBaseURL(server.URL)), []int{1},
+		WithWatchHandler(collectEvents(&events)),
+		WithWatchCheckpoints(checkpoints),
+		WithWatchPageSize(2),
+	)
+	assert.Nil(t, watcher.Events())
+
+	// The first poll starts from the newest contract.
+	require.NoError(t, watcher.Poll(context.Background()))
+	assert.Empty(t, events)
+	checkpoint, err := checkpoints.Checkpoint(1)
+	require.NoError(t, err)
+	assert.Equal(t, "2", checkpoint)
+
+	// Newer contracts are delivered oldest first, paging back to the checkpoint.
+	for matchId := 3; matchId <= 7; matchId++ {
+		server.add(newMirrorTestContract(t, matchId, fmt.Sprintf("contract C%d {}", matchId)))
+	}
+	require.NoError(t, watcher.Poll(context.Background()))
+	assert.Equal(t, []string{"3", "4", "5", "6", "7"}, matchIds(events))
+	assert.Equal(t, 1, events[0].ChainID)
+	assert.Equal(t, server.contracts[2].address.Hex(), events[0].Contract.Address)
+
+	checkpoint, err = checkpoints.Checkpoint(1)
+	require.NoError(t, err)
+	assert.Equal(t, "7", checkpoint)
+
+	require.NoError(t, watcher.Poll(context.Background()))
+	assert.Len(t, events, 5)
+}
+
+func TestWatcherHandlerError(t *testing.T) {
+	server := newMirrorTestServer(t)
+
+	var events []WatchEvent
+	failing := true
+	checkpoints := NewMemoryCheckpointStore()
+	watcher := NewWatcher(NewClient(WithBaseURL(server.URL)), []int{1},
+		WithWatchCheckpoints(checkpoints),
+		WithWatchHandler(func(ctx context.Context, event WatchEvent) error {
+			if failing && event.Contract.MatchID == "2" {
+				return errors.New("handler failed")
+			}
+			events = append(events, event)
+			return nil
+		}),
+	)
+
+	// An empty chain starts from zero, so its first contracts are delivered.
+	require.NoError(t, watcher.Poll(context.Background()))
+	server.add(newMirrorTestContract(t, 1, "contract C1 {}"))
+	server.add(newMirrorTestContract(t, 2, "contract C2 {}"))
+
+	err := watcher.Poll(context.Background())
+	assert.ErrorContains(t, err, "handler failed")
+	assert.Equal(t, []string{"1"}, matchIds(events))
+
+	failing = false
+	require.NoError(t, watcher.Poll(context.Background()))
+	assert.Equal(t, []string{"1", "2"}, matchIds(events))
+}
+
+func TestWatcherRunEvents(t *testing.T) {
+	server := newMirrorTestServer(t)
+	server.add(newMirrorTestContract(t, 1, "contract C1 {}"))
+
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoints.json"))
+	require.NoError(t, store.SetCheckpoint(1, "0"))
+
+	var reported []error
+	watcher := NewWatcher(NewClient(WithBaseURL(server.URL)), []int{1, 2},
+		WithWatchCheckpoints(store),
+		WithWatchInterval(10*time.Millisecond),
+		WithWatchErrorHandler(func(chainId int, err error) {
+			reported = append(reported, err)
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+
+	select {
+	case event := <-watcher.Events():
+		assert.Equal(t, "1", event.Contract.MatchID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event delivered")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	_, open := <-watcher.Events()
+	assert.False(t, open)
+
+	// Running again would close the events channel twice.
+	assert.ErrorIs(t, watcher.Run(context.Background()), ErrWatcherStarted)
+
+	// The checkpoint survives the watcher, so a new one does not replay the contract.
+	restored := NewFileCheckpointStore(store.path)
+	checkpoint, err := restored.Checkpoint(1)
+	require.NoError(t, err)
+	assert.Equal(t, "1", checkpoint)
+
+	// Chain 2 is not served by the test server.
+	assert.NotEmpty(t, reported)
+}